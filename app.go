@@ -5,24 +5,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"yoyaku/internal/agenda"
+	"yoyaku/internal/api"
 	"yoyaku/internal/db"
+	"yoyaku/internal/export"
+	"yoyaku/internal/feed"
+	"yoyaku/internal/feriados"
+	"yoyaku/internal/ical"
+	"yoyaku/internal/importer"
 	"yoyaku/internal/license"
 	"yoyaku/internal/models"
+	"yoyaku/internal/multipuesto"
+	"yoyaku/internal/server"
 )
 
 type App struct {
-	ctx          context.Context
-	db           *db.DB
-	turnoRepo    *db.TurnoRepo
-	pacienteRepo *db.PacienteRepo
-	configRepo   *db.ConfigRepo
-	licenseRepo  *db.LicenseRepo
-	agendaSvc    *agenda.Service
-	licenseSvc   *license.Service
+	ctx             context.Context
+	db              *db.DB
+	turnoRepo       *db.TurnoRepo
+	pacienteRepo    *db.PacienteRepo
+	profesionalRepo *db.ProfesionalRepo
+	configRepo      *db.ConfigRepo
+	licenseRepo     *db.LicenseRepo
+	feedRepo        *db.FeedRepo
+	tokenAPIRepo    *db.TokenAPIRepo
+	agendaSvc       *agenda.Service
+	licenseSvc      *license.Service
+	importSvc       *importer.Service
+	exportSvc       *export.Service
+	servidor        *server.Server
+	apiHandler      *api.Handler
+	local           *multipuesto.Local
+	anfitrion       *multipuesto.Anfitrion
+	anunciante      *multipuesto.Anunciante
+
+	// backend resuelve los datos de la agenda: la base local o, en un
+	// puesto cliente, la del anfitrión.
+	backendMu       sync.RWMutex
+	backend         multipuesto.Backend
+	cancelarEscucha context.CancelFunc
+	// cancelarUso detiene el registro periódico del último uso.
+	cancelarUso context.CancelFunc
+
+	// zona es la zona horaria configurada del consultorio. El reloj de la
+	// agenda la lee en cada llamada, así un cambio de configuración se
+	// aplica sin reemplazarlo.
+	zona atomic.Pointer[time.Location]
 }
 
 func NewApp() *App {
@@ -42,10 +76,20 @@ func (a *App) startup(ctx context.Context) {
 
 	a.turnoRepo = db.NewTurnoRepo(database)
 	a.pacienteRepo = db.NewPacienteRepo(database)
+	a.profesionalRepo = db.NewProfesionalRepo(database)
 	a.configRepo = db.NewConfigRepo(database)
 	a.licenseRepo = db.NewLicenseRepo(database)
-	a.agendaSvc = agenda.NewService(a.turnoRepo, a.pacienteRepo)
+	a.feedRepo = db.NewFeedRepo(database)
+	a.tokenAPIRepo = db.NewTokenAPIRepo(database)
+	a.agendaSvc = agenda.NewService(a.turnoRepo, a.pacienteRepo, db.NewCierreRepo(database), db.NewConfigRepo(database))
+	a.zona.Store(time.Local)
+	a.agendaSvc.UsarReloj(func() time.Time { return time.Now().In(a.zona.Load()) })
 	a.licenseSvc = license.NewService(a.licenseRepo)
+	a.registrarUso()
+	a.agendaSvc.UsarLicencia(a.licenseSvc.TieneFeature)
+	a.importSvc = importer.NewService(database)
+	a.exportSvc = export.NewService(a.turnoRepo, a.pacienteRepo)
+	a.exportSvc.UsarLicencia(a.licenseSvc.TieneFeature)
 
 	// Seed datos de prueba
 	seedSvc := NewSeedService(database)
@@ -53,15 +97,178 @@ func (a *App) startup(ctx context.Context) {
 		runtime.LogWarningf(ctx, "Error creando datos de prueba: %v", err)
 	}
 
+	a.local = multipuesto.NewLocal(database, a.agendaSvc)
+	a.agendaSvc.Eventos().Suscribir(func(e agenda.Evento) {
+		runtime.EventsEmit(ctx, string(e.Tipo), e)
+	})
+	if err := a.conectarBackend(); err != nil {
+		runtime.LogWarningf(ctx, "Error conectando con el puesto anfitrión: %v", err)
+	}
+	if err := a.aplicarZonaHoraria(); err != nil {
+		runtime.LogWarningf(ctx, "Error leyendo la zona horaria del consultorio: %v", err)
+	}
+
+	a.servidor = server.New()
+	feed.NewHandler(database).Registrar(a.servidor.Handle)
+	a.apiHandler = api.NewHandler(database, a.agendaSvc)
+	a.apiHandler.Registrar(a.servidor.Handle)
+	a.anfitrion = multipuesto.NewAnfitrion(a.local)
+	a.anfitrion.Registrar(a.servidor.Handle)
+	if err := a.iniciarServidor(); err != nil {
+		runtime.LogWarningf(ctx, "Error iniciando servidor local: %v", err)
+	}
+
 	runtime.LogInfo(ctx, "Yoyaku iniciado correctamente")
 }
 
 func (a *App) shutdown(ctx context.Context) {
+	if a.cancelarUso != nil {
+		a.cancelarUso()
+	}
+	if a.cancelarEscucha != nil {
+		a.cancelarEscucha()
+	}
+	if a.servidor != nil {
+		a.detenerServidor(ctx)
+	}
 	if a.db != nil {
 		a.db.Close()
 	}
 }
 
+// registrarUso guarda el último uso al iniciar y cada license.IntervaloUso
+// mientras la aplicación está abierta, para detectar un reloj atrasado.
+func (a *App) registrarUso() {
+	if err := a.licenseSvc.RegistrarUso(); err != nil {
+		runtime.LogWarningf(a.ctx, "Error registrando el último uso: %v", err)
+	}
+
+	ctx, cancelar := context.WithCancel(a.ctx)
+	a.cancelarUso = cancelar
+	go func() {
+		intervalo := time.NewTicker(license.IntervaloUso)
+		defer intervalo.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-intervalo.C:
+				if err := a.licenseSvc.RegistrarUso(); err != nil {
+					runtime.LogWarningf(a.ctx, "Error registrando el último uso: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// iniciarServidor inicia el servidor local si está habilitado en la
+// configuración o si este puesto es anfitrión de otros.
+func (a *App) iniciarServidor() error {
+	config, err := a.configRepo.ObtenerServidor()
+	if err != nil {
+		return err
+	}
+	puesto, err := a.configRepo.ObtenerPuesto()
+	if err != nil {
+		return err
+	}
+
+	esAnfitrion := puesto.Modo == models.PuestoAnfitrion
+	a.apiHandler.Habilitar(config.APIHabilitada)
+	a.anfitrion.Configurar(esAnfitrion, puesto.Clave)
+	if !config.Habilitado && !esAnfitrion {
+		return nil
+	}
+
+	if err := a.servidor.Iniciar(config.Puerto); err != nil {
+		return err
+	}
+	runtime.LogInfof(a.ctx, "Servidor local escuchando en el puerto %d", config.Puerto)
+
+	if esAnfitrion {
+		nombre := ""
+		if c, err := a.configRepo.Obtener(); err == nil {
+			nombre = c.NombreConsultorio
+		}
+		anunciante, err := multipuesto.Anunciar(nombre, a.servidor.Puerto())
+		if err != nil {
+			// Los clientes todavía pueden conectarse con la dirección.
+			runtime.LogWarningf(a.ctx, "No se pudo anunciar el puesto por mDNS: %v", err)
+		} else {
+			a.anunciante = anunciante
+		}
+	}
+	return nil
+}
+
+func (a *App) detenerServidor(ctx context.Context) error {
+	if a.anunciante != nil {
+		a.anunciante.Cerrar()
+		a.anunciante = nil
+	}
+	return a.servidor.Detener(ctx)
+}
+
+// conectarBackend elige el backend según el modo del puesto. En un puesto
+// cliente además publica en el bus local los eventos del anfitrión, para que
+// lleguen al frontend como los propios.
+func (a *App) conectarBackend() error {
+	if a.cancelarEscucha != nil {
+		a.cancelarEscucha()
+		a.cancelarEscucha = nil
+	}
+
+	puesto, err := a.configRepo.ObtenerPuesto()
+	if err != nil || puesto.Modo != models.PuestoCliente {
+		a.usarBackend(a.local)
+		return err
+	}
+
+	remoto := multipuesto.NewRemoto(puesto.DireccionAnfitrion, puesto.Clave)
+	a.usarBackend(remoto)
+
+	ctx, cancelar := context.WithCancel(a.ctx)
+	a.cancelarEscucha = cancelar
+	go remoto.Escuchar(ctx, a.agendaSvc.Eventos().Publicar)
+	return nil
+}
+
+// aplicarZonaHoraria toma la zona horaria de la configuración del
+// consultorio, que en un puesto cliente es la del anfitrión.
+func (a *App) aplicarZonaHoraria() error {
+	config, err := a.datos().ObtenerConfiguracion()
+	if err != nil {
+		return err
+	}
+	zona, err := time.LoadLocation(config.ZonaHoraria)
+	if err != nil {
+		return fmt.Errorf("zona horaria inválida %q: %w", config.ZonaHoraria, err)
+	}
+	a.zona.Store(zona)
+	return nil
+}
+
+func (a *App) usarBackend(backend multipuesto.Backend) {
+	a.backendMu.Lock()
+	defer a.backendMu.Unlock()
+	a.backend = backend
+}
+
+func (a *App) datos() multipuesto.Backend {
+	a.backendMu.RLock()
+	defer a.backendMu.RUnlock()
+	return a.backend
+}
+
+// requiereBaseLocal impide usar en un puesto cliente las funciones que
+// trabajan directamente sobre la base de datos de la máquina.
+func (a *App) requiereBaseLocal() error {
+	if _, remoto := a.datos().(*multipuesto.Remoto); remoto {
+		return fmt.Errorf("disponible sólo en el puesto anfitrión")
+	}
+	return nil
+}
+
 func (a *App) getDataDir() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -75,62 +282,206 @@ func (a *App) GetTurnosDelDia(fecha string) (*models.AgendaDia, error) {
 	if err != nil {
 		return nil, fmt.Errorf("fecha inválida: %w", err)
 	}
-	return a.agendaSvc.ObtenerAgendaDelDia(t)
+	return a.datos().AgendaDelDia(t)
+}
+
+// GetProximoTurno devuelve el próximo turno a atender hoy, o nil si no
+// queda ninguno.
+func (a *App) GetProximoTurno() (*models.Turno, error) {
+	agendaDia, err := a.datos().AgendaDelDia(a.agendaSvc.Ahora())
+	if err != nil {
+		return nil, err
+	}
+	return a.agendaSvc.ObtenerProximoTurno(agendaDia.Turnos), nil
+}
+
+// GetTurnoActual devuelve el turno que está en consulta, o nil si el
+// consultorio está libre.
+func (a *App) GetTurnoActual() (*models.Turno, error) {
+	agendaDia, err := a.datos().AgendaDelDia(a.agendaSvc.Ahora())
+	if err != nil {
+		return nil, err
+	}
+	return a.agendaSvc.ObtenerTurnoActual(agendaDia.Turnos), nil
+}
+
+// GetSalaDeEspera devuelve los pacientes que esperan ser atendidos, en el
+// orden en que corresponde llamarlos.
+func (a *App) GetSalaDeEspera(fecha string) ([]models.Turno, error) {
+	t, err := time.Parse("2006-01-02", fecha)
+	if err != nil {
+		return nil, fmt.Errorf("fecha inválida: %w", err)
+	}
+	return a.datos().SalaDeEspera(t)
 }
 
 func (a *App) GetTurno(id int64) (*models.Turno, error) {
-	return a.turnoRepo.ObtenerPorID(id)
+	return a.datos().ObtenerTurno(id)
 }
 
 func (a *App) CrearTurno(turno *models.Turno) error {
-	return a.turnoRepo.Crear(turno)
+	return a.datos().CrearTurno(turno)
 }
 
 func (a *App) ActualizarTurno(turno *models.Turno) error {
-	return a.turnoRepo.Actualizar(turno)
+	return a.datos().ActualizarTurno(turno)
 }
 
 func (a *App) EliminarTurno(id int64) error {
-	return a.turnoRepo.Eliminar(id)
+	return a.datos().EliminarTurno(id)
 }
 
 func (a *App) CambiarEstadoTurno(id int64, estado string) error {
-	switch models.EstadoTurno(estado) {
-	case models.EstadoAtendido:
-		return a.agendaSvc.MarcarAtendido(id)
-	case models.EstadoAusente:
-		return a.agendaSvc.MarcarAusente(id)
-	case models.EstadoCancelado:
-		return a.agendaSvc.MarcarCancelado(id)
-	case models.EstadoConfirmado:
-		return a.agendaSvc.ConfirmarTurno(id)
-	default:
-		return a.turnoRepo.ActualizarEstado(id, models.EstadoTurno(estado))
-	}
+	return a.datos().CambiarEstadoTurno(id, models.EstadoTurno(estado))
 }
 
 func (a *App) GetPaciente(id int64) (*models.Paciente, error) {
-	return a.pacienteRepo.ObtenerPorID(id)
+	return a.datos().ObtenerPaciente(id)
 }
 
 func (a *App) BuscarPacientes(termino string) ([]models.Paciente, error) {
-	return a.pacienteRepo.Buscar(termino)
+	resultado, err := a.datos().BuscarPacientes(termino, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resultado.Pacientes, nil
+}
+
+func (a *App) BuscarPacientesPaginado(termino string, pagina, porPagina int) (*models.ResultadoBusquedaPacientes, error) {
+	return a.datos().BuscarPacientes(termino, pagina, porPagina)
 }
 
 func (a *App) CrearPaciente(paciente *models.Paciente) error {
-	return a.pacienteRepo.Crear(paciente)
+	return a.datos().CrearPaciente(paciente)
 }
 
 func (a *App) ActualizarPaciente(paciente *models.Paciente) error {
-	return a.pacienteRepo.Actualizar(paciente)
+	return a.datos().ActualizarPaciente(paciente)
 }
 
 func (a *App) EliminarPaciente(id int64) error {
-	return a.pacienteRepo.Eliminar(id)
+	return a.datos().EliminarPaciente(id)
+}
+
+func (a *App) SeleccionarArchivoImportacion() (*models.ArchivoImportacion, error) {
+	ruta, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Importar pacientes",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Planillas (*.csv, *.xlsx)", Pattern: "*.csv;*.xlsx"},
+		},
+	})
+	if err != nil || ruta == "" {
+		return nil, err
+	}
+
+	hoja, err := importer.LeerArchivo(ruta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ArchivoImportacion{
+		Ruta:          ruta,
+		Encabezados:   hoja.Encabezados,
+		TotalFilas:    len(hoja.Filas),
+		MapeoSugerido: importer.DetectarMapeo(hoja.Encabezados),
+	}, nil
+}
+
+func (a *App) ImportarPacientes(ruta string, mapeo models.MapeoImportacion, simular bool) (*models.ReporteImportacion, error) {
+	if err := a.requiereBaseLocal(); err != nil {
+		return nil, err
+	}
+	return a.importSvc.ImportarArchivo(ruta, mapeo, simular)
+}
+
+func (a *App) SeleccionarArchivoCalendario() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Importar calendario",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "iCalendar (*.ics)", Pattern: "*.ics"},
+		},
+	})
+}
+
+// ImportarCalendario importa los turnos de un archivo .ics. Con simular sólo
+// devuelve el reporte para previsualizarlo.
+func (a *App) ImportarCalendario(ruta string, simular, permitirSuperpuestos bool) (*models.ReporteImportacionICS, error) {
+	if err := a.requiereBaseLocal(); err != nil {
+		return nil, err
+	}
+	return a.importSvc.ImportarICSArchivo(ruta, importer.OpcionesICS{
+		Zona:                 a.zona.Load(),
+		Simular:              simular,
+		PermitirSuperpuestos: permitirSuperpuestos,
+	})
+}
+
+// ExportarDatos pide al usuario dónde guardar la exportación y devuelve la
+// ruta elegida, o vacío si canceló el diálogo.
+func (a *App) ExportarDatos(tipo, formato, desde, hasta string) (string, error) {
+	if err := a.requiereBaseLocal(); err != nil {
+		return "", err
+	}
+	fechaDesde, fechaHasta, err := export.ParsearRango(desde, hasta)
+	if err != nil {
+		return "", err
+	}
+
+	ruta, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Exportar datos",
+		DefaultFilename: export.NombreArchivo(export.Tipo(tipo), export.Formato(formato), fechaDesde, fechaHasta),
+		Filters: []runtime.FileFilter{
+			{DisplayName: strings.ToUpper(formato), Pattern: "*." + formato},
+		},
+	})
+	if err != nil || ruta == "" {
+		return "", err
+	}
+
+	if _, err := a.exportSvc.ExportarArchivo(ruta, export.Tipo(tipo), export.Formato(formato), fechaDesde, fechaHasta); err != nil {
+		return "", err
+	}
+
+	return ruta, nil
+}
+
+// ExportarAgendaICS guarda los turnos del rango como archivo .ics para
+// importarlos en el calendario del teléfono. Con redactar se omiten los
+// datos de los pacientes.
+func (a *App) ExportarAgendaICS(desde, hasta string, redactar bool) (string, error) {
+	if err := a.requiereBaseLocal(); err != nil {
+		return "", err
+	}
+	fechaDesde, fechaHasta, err := export.ParsearRango(desde, hasta)
+	if err != nil {
+		return "", err
+	}
+
+	ruta, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Exportar agenda",
+		DefaultFilename: export.NombreArchivo(export.TipoTurnos, export.FormatoICS, fechaDesde, fechaHasta),
+		Filters: []runtime.FileFilter{
+			{DisplayName: "iCalendar (*.ics)", Pattern: "*.ics"},
+		},
+	})
+	if err != nil || ruta == "" {
+		return "", err
+	}
+
+	opciones := ical.Opciones{Redactar: redactar, Zona: a.zona.Load()}
+	if config, err := a.configRepo.Obtener(); err == nil {
+		opciones.NombreCalendario = config.NombreConsultorio
+	}
+
+	if _, err := a.exportSvc.ExportarICSArchivo(ruta, fechaDesde, fechaHasta, opciones); err != nil {
+		return "", err
+	}
+
+	return ruta, nil
 }
 
 func (a *App) GetHistorialPaciente(pacienteID int64) ([]models.Turno, error) {
-	return a.turnoRepo.ListarPorPaciente(pacienteID)
+	return a.datos().HistorialPaciente(pacienteID)
 }
 
 func (a *App) EmitirEvento(evento string, data interface{}) {
@@ -138,15 +489,238 @@ func (a *App) EmitirEvento(evento string, data interface{}) {
 }
 
 func (a *App) GetConfiguracion() (*models.Configuracion, error) {
-	return a.configRepo.Obtener()
+	return a.datos().ObtenerConfiguracion()
 }
 
 func (a *App) GuardarConfiguracion(config *models.Configuracion) error {
-	return a.configRepo.Guardar(config)
+	if err := a.datos().GuardarConfiguracion(config); err != nil {
+		return err
+	}
+	return a.aplicarZonaHoraria()
+}
+
+func (a *App) GetProfesionales() ([]models.Profesional, error) {
+	return a.datos().ListarProfesionales()
+}
+
+func (a *App) CrearProfesional(profesional *models.Profesional) error {
+	return a.datos().CrearProfesional(profesional)
+}
+
+func (a *App) ActualizarProfesional(profesional *models.Profesional) error {
+	return a.datos().ActualizarProfesional(profesional)
+}
+
+// GetFeriados devuelve los feriados nacionales del año.
+func (a *App) GetFeriados(anio int) []models.Feriado {
+	return feriados.Argentina(anio)
+}
+
+// GetDiasCerrados devuelve los días del rango en que el profesional no
+// atiende, para marcarlos en el calendario.
+func (a *App) GetDiasCerrados(profesionalID int64, desde, hasta string) ([]models.DiaCerrado, error) {
+	fechaDesde, fechaHasta, err := export.ParsearRango(desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	return a.datos().DiasCerrados(profesionalID, fechaDesde, fechaHasta)
+}
+
+func (a *App) GetCierres(desde, hasta string) ([]models.Cierre, error) {
+	fechaDesde, fechaHasta, err := export.ParsearRango(desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	return a.datos().ListarCierres(fechaDesde, fechaHasta)
+}
+
+// CrearCierre guarda el cierre y devuelve los turnos ya dados que hay que
+// reprogramar.
+func (a *App) CrearCierre(cierre *models.Cierre) (*models.ReporteCierre, error) {
+	return a.datos().CrearCierre(cierre)
+}
+
+func (a *App) EliminarCierre(id int64) error {
+	return a.datos().EliminarCierre(id)
+}
+
+func (a *App) GetTurnosAfectados(cierreID int64) ([]models.Turno, error) {
+	return a.datos().TurnosAfectados(cierreID)
+}
+
+func (a *App) GetHorarioLaboral() (*models.HorarioLaboral, error) {
+	return a.datos().ObtenerHorarioLaboral()
+}
+
+func (a *App) GuardarHorarioLaboral(horario *models.HorarioLaboral) error {
+	return a.datos().GuardarHorarioLaboral(horario)
+}
+
+// ReprogramarTurnos mueve en bloque los turnos de un día o rango. Con
+// simular sólo devuelve el plan para previsualizarlo.
+func (a *App) ReprogramarTurnos(solicitud models.SolicitudReprogramacion) (*models.PlanReprogramacion, error) {
+	return a.datos().ReprogramarTurnos(solicitud)
+}
+
+// GetMensajesPendientes devuelve los avisos a pacientes que falta enviar.
+func (a *App) GetMensajesPendientes() ([]models.Mensaje, error) {
+	return a.datos().ListarMensajes(models.MensajePendiente)
+}
+
+func (a *App) MarcarMensajeEnviado(id int64) error {
+	return a.datos().CambiarEstadoMensaje(id, models.MensajeEnviado)
+}
+
+func (a *App) DescartarMensaje(id int64) error {
+	return a.datos().CambiarEstadoMensaje(id, models.MensajeDescartado)
+}
+
+func (a *App) GetConfiguracionServidor() (*models.ConfiguracionServidor, error) {
+	return a.configRepo.ObtenerServidor()
+}
+
+// GuardarConfiguracionServidor guarda la configuración y reinicia el
+// servidor local para aplicarla.
+func (a *App) GuardarConfiguracionServidor(config *models.ConfiguracionServidor) error {
+	if config.Puerto < 1 || config.Puerto > 65535 {
+		return fmt.Errorf("puerto inválido: %d", config.Puerto)
+	}
+	if err := a.configRepo.GuardarServidor(config); err != nil {
+		return err
+	}
+
+	return a.reiniciarServidor()
+}
+
+func (a *App) reiniciarServidor() error {
+	if err := a.detenerServidor(a.ctx); err != nil {
+		return err
+	}
+	return a.iniciarServidor()
 }
 
-func (a *App) ValidarLicencia(key string) (*models.InfoLicencia, error) {
-	return a.licenseSvc.ValidarLicencia(key)
+func (a *App) GetConfiguracionPuesto() (*models.ConfiguracionPuesto, error) {
+	return a.configRepo.ObtenerPuesto()
+}
+
+// GuardarConfiguracionPuesto cambia el modo de este puesto. Un puesto
+// anfitrión recibe una clave nueva si no tenía una; un puesto cliente se
+// guarda sólo si el anfitrión responde con la clave indicada.
+func (a *App) GuardarConfiguracionPuesto(config *models.ConfiguracionPuesto) error {
+	switch config.Modo {
+	case models.PuestoIndependiente:
+	case models.PuestoAnfitrion:
+		if config.Clave == "" {
+			clave, err := multipuesto.GenerarClave()
+			if err != nil {
+				return err
+			}
+			config.Clave = clave
+		}
+	case models.PuestoCliente:
+		config.DireccionAnfitrion = strings.TrimSpace(config.DireccionAnfitrion)
+		config.Clave = strings.TrimSpace(config.Clave)
+		if config.DireccionAnfitrion == "" || config.Clave == "" {
+			return fmt.Errorf("la dirección y la clave del puesto anfitrión son obligatorias")
+		}
+		if err := multipuesto.NewRemoto(config.DireccionAnfitrion, config.Clave).Probar(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("modo de puesto inválido: %q", config.Modo)
+	}
+
+	if err := a.configRepo.GuardarPuesto(config); err != nil {
+		return err
+	}
+	if err := a.conectarBackend(); err != nil {
+		return err
+	}
+	if err := a.aplicarZonaHoraria(); err != nil {
+		runtime.LogWarningf(a.ctx, "Error leyendo la zona horaria del consultorio: %v", err)
+	}
+	return a.reiniciarServidor()
+}
+
+// DescubrirAnfitriones busca por mDNS los puestos anfitriones de la red
+// local.
+func (a *App) DescubrirAnfitriones() ([]models.AnfitrionDescubierto, error) {
+	return multipuesto.Descubrir(2 * time.Second)
+}
+
+func (a *App) GetFeedsCalendario() ([]models.FeedCalendario, error) {
+	feeds, err := a.feedRepo.ListarTodos()
+	if err != nil {
+		return nil, err
+	}
+	for i := range feeds {
+		a.completarURLsFeed(&feeds[i])
+	}
+	return feeds, nil
+}
+
+// CrearFeedCalendario crea un feed de suscripción para la agenda de un
+// profesional y devuelve sus URLs en la red local.
+func (a *App) CrearFeedCalendario(profesionalID int64, descripcion string, redactar bool) (*models.FeedCalendario, error) {
+	if err := a.requiereBaseLocal(); err != nil {
+		return nil, err
+	}
+	profesional, err := a.profesionalRepo.ObtenerPorID(profesionalID)
+	if err != nil {
+		return nil, err
+	}
+	if profesional == nil {
+		return nil, fmt.Errorf("profesional %d inexistente", profesionalID)
+	}
+
+	f := &models.FeedCalendario{
+		ProfesionalID: profesionalID,
+		Descripcion:   descripcion,
+		Redactar:      redactar,
+	}
+	if err := a.feedRepo.Crear(f); err != nil {
+		return nil, err
+	}
+	a.completarURLsFeed(f)
+
+	return f, nil
+}
+
+func (a *App) EliminarFeedCalendario(id int64) error {
+	return a.feedRepo.Eliminar(id)
+}
+
+func (a *App) GetTokensAPI() ([]models.TokenAPI, error) {
+	return a.tokenAPIRepo.ListarTodos()
+}
+
+// CrearTokenAPI genera un token para la API local. El token en claro sólo se
+// devuelve en esta llamada; después se guarda únicamente su hash.
+func (a *App) CrearTokenAPI(nombre string) (*models.TokenAPI, error) {
+	if err := a.requiereBaseLocal(); err != nil {
+		return nil, err
+	}
+	nombre = strings.TrimSpace(nombre)
+	if nombre == "" {
+		return nil, fmt.Errorf("el nombre del token es obligatorio")
+	}
+	return a.tokenAPIRepo.Crear(nombre)
+}
+
+func (a *App) EliminarTokenAPI(id int64) error {
+	return a.tokenAPIRepo.Eliminar(id)
+}
+
+func (a *App) completarURLsFeed(f *models.FeedCalendario) {
+	f.URLs = append(a.servidor.URLs(feed.RutaICS(f.Token)), a.servidor.URLs(feed.RutaCalDAV(f.Token))...)
+}
+
+func (a *App) SolicitarActivacion(key string) (string, error) {
+	return a.licenseSvc.SolicitarActivacion(key)
+}
+
+func (a *App) ValidarLicencia(key, activacion string) (*models.InfoLicencia, error) {
+	return a.licenseSvc.ValidarLicencia(key, activacion)
 }
 
 func (a *App) ObtenerInfoLicencia() (*models.InfoLicencia, error) {
@@ -156,3 +730,27 @@ func (a *App) ObtenerInfoLicencia() (*models.InfoLicencia, error) {
 func (a *App) RequiereActivacion() (bool, error) {
 	return a.licenseSvc.RequiereActivacion()
 }
+
+// ImportarListaRevocacion pide el archivo de una lista de revocación firmada
+// y la importa. Devuelve nil si el usuario canceló el diálogo.
+func (a *App) ImportarListaRevocacion() (*models.ListaRevocacion, error) {
+	ruta, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Importar lista de revocación",
+	})
+	if err != nil || ruta == "" {
+		return nil, err
+	}
+	texto, err := os.ReadFile(ruta)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo lista de revocación: %w", err)
+	}
+	return a.licenseSvc.ImportarRevocaciones(string(texto))
+}
+
+func (a *App) TieneFeature(feature string) bool {
+	return a.licenseSvc.TieneFeature(models.Feature(feature))
+}
+
+func (a *App) ObtenerHistorialLicencias() ([]models.LicenciaAplicada, error) {
+	return a.licenseSvc.HistorialLicencias()
+}
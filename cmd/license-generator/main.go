@@ -1,66 +1,386 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
-	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
 	"yoyaku/internal/license"
+	"yoyaku/internal/license/emisor"
+	"yoyaku/internal/models"
 )
 
-func main() {
-	var year int
-	flag.IntVar(&year, "year", 0, "Año de la licencia (ej: 2024)")
-	flag.Parse()
+// Variables de entorno para no repetir las rutas en cada llamada.
+const (
+	variableClave    = "YOYAKU_CLAVE_LICENCIAS"
+	variableRegistro = "YOYAKU_REGISTRO_LICENCIAS"
+)
+
+const uso = `Uso: license-generator <comando> [opciones]
+
+Comandos:
+  keygen      Genera el par de claves de firma
+  issue       Emite una licencia nueva
+  list        Lista las licencias emitidas
+  show        Muestra una licencia y su cliente
+  activate    Responde un código de solicitud con el código de activación
+  revoke      Revoca una licencia
+  renew       Renueva una licencia extendiendo sus actualizaciones
+  revocations Firma la lista de licencias revocadas para distribuir
+
+Use "license-generator <comando> -h" para ver las opciones de cada comando.
+La clave privada nunca debe guardarse en el repositorio ni distribuirse con la aplicación.`
+
+// opciones son las banderas comunes a todos los comandos.
+type opciones struct {
+	clave    string
+	registro string
+	json     bool
+}
 
-	if year == 0 {
-		fmt.Println("Uso: go run cmd/license-generator/main.go -year=2024")
-		fmt.Println("\nGenera una licencia válida para el año especificado")
+func nuevasBanderas(nombre string) (*flag.FlagSet, *opciones) {
+	fs := flag.NewFlagSet(nombre, flag.ExitOnError)
+	o := &opciones{}
+	registro := os.Getenv(variableRegistro)
+	if registro == "" {
+		registro = "licencias.json"
+	}
+	fs.StringVar(&o.clave, "clave", os.Getenv(variableClave), "Archivo PEM con la clave privada (o $"+variableClave+")")
+	fs.StringVar(&o.registro, "registro", registro, "Registro de licencias emitidas (o $"+variableRegistro+")")
+	fs.BoolVar(&o.json, "json", false, "Salida en JSON")
+	return fs, o
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(uso)
 		os.Exit(1)
 	}
 
-	if year < 2024 || year > 2030 {
-		fmt.Printf("Error: El año debe estar entre 2024 y 2030\n")
+	comandos := map[string]func([]string) error{
+		"keygen":      keygen,
+		"issue":       issue,
+		"list":        list,
+		"show":        show,
+		"activate":    activate,
+		"revoke":      revoke,
+		"renew":       renew,
+		"revocations": revocations,
+	}
+	comando, ok := comandos[os.Args[1]]
+	if !ok {
+		fmt.Println(uso)
+		os.Exit(1)
+	}
+	if err := comando(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+}
+
+func keygen(args []string) error {
+	fs, o := nuevasBanderas("keygen")
+	fs.Parse(args)
+	if o.clave == "" {
+		return fmt.Errorf("falta -clave con la ruta donde guardar la clave privada")
+	}
+
+	if _, err := os.Stat(o.clave); err == nil {
+		return fmt.Errorf("%s ya existe; no se sobrescribe una clave privada", o.clave)
+	}
+	privada, publica, err := license.GenerarClaves()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(o.clave, privada, 0600); err != nil {
+		return fmt.Errorf("error guardando clave privada: %w", err)
+	}
+
+	if o.json {
+		return imprimirJSON(map[string]string{"clave": o.clave, "clavePublica": publica})
+	}
+	fmt.Printf("Clave privada guardada en %s\n", o.clave)
+	fmt.Println("Clave pública (para license.ClavePublica):")
+	fmt.Println(publica)
+	return nil
+}
+
+func issue(args []string) error {
+	fs, o := nuevasBanderas("issue")
+	var s emisor.Solicitud
+	var edicion, adicionales string
+	fs.StringVar(&s.ClienteID, "cliente", "", "Cliente existente (CLI-0001); si se omite se da de alta uno nuevo")
+	fs.StringVar(&s.Nombre, "nombre", "", "Nombre del cliente nuevo")
+	fs.StringVar(&s.Contacto, "contacto", "", "Contacto del cliente nuevo (email o teléfono)")
+	fs.IntVar(&s.Maquinas, "maquinas", 1, "Cantidad de máquinas cubiertas")
+	fs.StringVar(&edicion, "edicion", string(models.EdicionBasica), "Edición: basica o pro")
+	fs.StringVar(&adicionales, "funciones", "", "Funciones adicionales a la edición, separadas por comas (multi_profesional, mensajeria, reportes)")
+	fs.IntVar(&s.Meses, "meses", 12, "Meses de actualizaciones incluidas")
+	fs.Parse(args)
+	s.Edicion = models.Edicion(edicion)
+	for _, f := range strings.Split(adicionales, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			s.Adicionales = append(s.Adicionales, models.Feature(f))
+		}
+	}
+
+	generador, registro, err := abrir(o)
+	if err != nil {
+		return err
+	}
+	licencia, err := registro.Emitir(generador, s, time.Now())
+	if err != nil {
+		return err
+	}
+	return mostrar(o, registro, licencia)
+}
+
+func renew(args []string) error {
+	fs, o := nuevasBanderas("renew")
+	var id string
+	var meses int
+	fs.StringVar(&id, "id", "", "Licencia a renovar")
+	fs.IntVar(&meses, "meses", 12, "Meses de actualizaciones a agregar")
+	fs.Parse(args)
+	if id == "" {
+		return fmt.Errorf("falta -id")
+	}
+
+	generador, registro, err := abrir(o)
+	if err != nil {
+		return err
+	}
+	licencia, err := registro.Renovar(generador, id, meses, time.Now())
+	if err != nil {
+		return err
+	}
+	return mostrar(o, registro, licencia)
+}
+
+func activate(args []string) error {
+	fs, o := nuevasBanderas("activate")
+	var codigo string
+	var forzar bool
+	fs.StringVar(&codigo, "codigo", "", "Código de solicitud generado por la aplicación (YOYS1...)")
+	fs.BoolVar(&forzar, "forzar", false, "Activar aunque se supere la cantidad de máquinas de la licencia")
+	fs.Parse(args)
+	if codigo == "" {
+		return fmt.Errorf("falta -codigo")
+	}
+
+	generador, registro, err := abrir(o)
+	if err != nil {
+		return err
+	}
+	licencia, activacion, err := registro.Activar(generador, codigo, forzar, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if o.json {
+		return imprimirJSON(map[string]interface{}{"licencia": licencia.ID, "clienteId": licencia.ClienteID, "activacion": activacion})
+	}
+	fmt.Printf("Licencia:  %s (%s)\n", licencia.ID, nombreCliente(registro, licencia.ClienteID))
+	fmt.Printf("Máquinas:  %d de %d\n", len(licencia.Activaciones), licencia.Maquinas)
+	fmt.Println()
+	fmt.Println("Código de activación:")
+	fmt.Println(activacion)
+	return nil
+}
+
+func revoke(args []string) error {
+	fs, o := nuevasBanderas("revoke")
+	var id, motivo string
+	fs.StringVar(&id, "id", "", "Licencia a revocar")
+	fs.StringVar(&motivo, "motivo", "", "Motivo de la revocación")
+	fs.Parse(args)
+	if id == "" {
+		return fmt.Errorf("falta -id")
+	}
+
+	registro, err := emisor.AbrirRegistro(o.registro)
+	if err != nil {
+		return err
+	}
+	licencia, err := registro.Revocar(id, motivo, time.Now())
+	if err != nil {
+		return err
+	}
+	return mostrar(o, registro, licencia)
+}
+
+func revocations(args []string) error {
+	fs, o := nuevasBanderas("revocations")
+	var salida string
+	fs.StringVar(&salida, "salida", "", "Archivo donde guardar la lista firmada (por defecto, la salida estándar)")
+	fs.Parse(args)
+
+	generador, registro, err := abrir(o)
+	if err != nil {
+		return err
+	}
+	lista, texto, err := registro.ListaRevocacion(generador, time.Now())
+	if err != nil {
+		return err
+	}
+	if salida != "" {
+		if err := os.WriteFile(salida, []byte(texto+"\n"), 0644); err != nil {
+			return fmt.Errorf("error guardando lista de revocación: %w", err)
+		}
+	}
+
+	if o.json {
+		return imprimirJSON(map[string]interface{}{"lista": lista, "texto": texto})
+	}
+	if salida == "" {
+		fmt.Println(texto)
+		return nil
+	}
+	fmt.Printf("Lista de revocación %d con %d licencias guardada en %s\n", lista.Numero, len(lista.Licencias), salida)
+	return nil
+}
+
+func show(args []string) error {
+	fs, o := nuevasBanderas("show")
+	var id string
+	fs.StringVar(&id, "id", "", "Licencia a mostrar")
+	fs.Parse(args)
+	if id == "" {
+		return fmt.Errorf("falta -id")
+	}
+
+	registro, err := emisor.AbrirRegistro(o.registro)
+	if err != nil {
+		return err
+	}
+	licencia := registro.Licencia(id)
+	if licencia == nil {
+		return fmt.Errorf("licencia no encontrada: %s", id)
+	}
+	return mostrar(o, registro, licencia)
+}
+
+func list(args []string) error {
+	fs, o := nuevasBanderas("list")
+	var cliente string
+	fs.StringVar(&cliente, "cliente", "", "Sólo las licencias de este cliente")
+	fs.Parse(args)
+
+	registro, err := emisor.AbrirRegistro(o.registro)
+	if err != nil {
+		return err
+	}
+	licencias := registro.Licencias(cliente)
+
+	if o.json {
+		if licencias == nil {
+			licencias = []emisor.Licencia{}
+		}
+		return imprimirJSON(licencias)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCLIENTE\tNOMBRE\tEDICIÓN\tMÁQUINAS\tEMITIDA\tACTUALIZACIONES\tESTADO")
+	for _, l := range licencias {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			l.ID, l.ClienteID, nombreCliente(registro, l.ClienteID), l.Edicion, l.Maquinas,
+			l.Emision.Format("02/01/2006"), l.ActualizacionesHasta.Format("02/01/2006"), estado(&l))
+	}
+	return w.Flush()
+}
 
-	generator := license.NewGenerator("yoyaku_secret_2024")
-	licenseKey := generator.GenerateLicenseKey(year)
+// abrir carga la clave privada y el registro, lo que necesitan los comandos
+// que firman licencias.
+func abrir(o *opciones) (*license.Generator, *emisor.Registro, error) {
+	if o.clave == "" {
+		return nil, nil, fmt.Errorf("falta -clave (o $%s)", variableClave)
+	}
+	datos, err := os.ReadFile(o.clave)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error leyendo clave privada: %w", err)
+	}
+	privateKey, err := license.LeerClavePrivada(datos)
+	if err != nil {
+		return nil, nil, err
+	}
+	registro, err := emisor.AbrirRegistro(o.registro)
+	if err != nil {
+		return nil, nil, err
+	}
+	return license.NewGenerator(privateKey), registro, nil
+}
+
+// detalle es la salida JSON de issue, renew, revoke y show.
+type detalle struct {
+	emisor.Licencia
+	Cliente *emisor.Cliente `json:"cliente"`
+}
+
+func mostrar(o *opciones, registro *emisor.Registro, l *emisor.Licencia) error {
+	cliente := registro.Cliente(l.ClienteID)
+	if o.json {
+		return imprimirJSON(detalle{Licencia: *l, Cliente: cliente})
+	}
 
 	fmt.Println("╔════════════════════════════════════════╗")
 	fmt.Println("║     GENERADOR DE LICENCIAS YOYAKU      ║")
 	fmt.Println("╚════════════════════════════════════════╝")
 	fmt.Println()
-	fmt.Printf("Año:        %d\n", year)
-	fmt.Printf("Licencia:   %s\n", licenseKey)
-	fmt.Println()
-	fmt.Println("Esta licencia incluye:")
-	fmt.Println("  ✓ Uso perpetuo del software")
-	fmt.Println("  ✓ 1 año de actualizaciones")
-	fmt.Println("  ✓ Soporte técnico durante el período")
-	fmt.Println()
-	fmt.Println("Instrucciones:")
-	fmt.Println("  1. Copie la clave de licencia")
-	fmt.Println("  2. Ingrese la clave en la aplicación")
-	fmt.Println("  3. La licencia se activará inmediatamente")
-	fmt.Println()
-
-	// Validar que la licencia generada sea válida
-	valid, validatedYear, err := generator.ValidateLicenseKey(licenseKey)
-	if err != nil {
-		fmt.Printf("Error validando licencia: %v\n", err)
-		os.Exit(1)
+	fmt.Printf("Licencia:        %s\n", l.ID)
+	fmt.Printf("Cliente:         %s\n", l.ClienteID)
+	if cliente != nil {
+		fmt.Printf("Nombre:          %s\n", cliente.Nombre)
+		if cliente.Contacto != "" {
+			fmt.Printf("Contacto:        %s\n", cliente.Contacto)
+		}
+	}
+	fmt.Printf("Edición:         %s\n", l.Edicion)
+	if len(l.Adicionales) > 0 {
+		adicionales := make([]string, len(l.Adicionales))
+		for i, f := range l.Adicionales {
+			adicionales[i] = string(f)
+		}
+		fmt.Printf("Adicionales:     %s\n", strings.Join(adicionales, ", "))
+	}
+	fmt.Printf("Máquinas:        %d (%d activadas)\n", l.Maquinas, len(l.Activaciones))
+	fmt.Printf("Emitida:         %s\n", l.Emision.Format("02/01/2006"))
+	fmt.Printf("Actualizaciones: hasta %s\n", l.ActualizacionesHasta.Format("02/01/2006"))
+	if l.Renueva != "" {
+		fmt.Printf("Renueva:         %s\n", l.Renueva)
+	}
+	fmt.Printf("Estado:          %s\n", estado(l))
+	if l.MotivoRevocacion != "" {
+		fmt.Printf("Motivo:          %s\n", l.MotivoRevocacion)
 	}
+	for _, a := range l.Activaciones {
+		fmt.Printf("  Activada en %s el %s\n", a.Huella, a.Fecha.Format("02/01/2006"))
+	}
+	fmt.Println()
+	fmt.Println("Clave:")
+	fmt.Println(l.Clave)
+	return nil
+}
 
-	if !valid {
-		fmt.Println("Error: La licencia generada no es válida")
-		os.Exit(1)
+func estado(l *emisor.Licencia) string {
+	if l.Revocada() {
+		return "revocada el " + l.RevocadaAt.Format("02/01/2006")
 	}
+	return "vigente"
+}
 
-	if strconv.Itoa(validatedYear) != strconv.Itoa(year) {
-		fmt.Printf("Error: Año de validación no coincide (%d != %d)\n", validatedYear, year)
-		os.Exit(1)
+func nombreCliente(registro *emisor.Registro, id string) string {
+	if c := registro.Cliente(id); c != nil {
+		return c.Nombre
 	}
+	return ""
+}
 
-	fmt.Println("✓ Licencia validada correctamente")
+func imprimirJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
 }
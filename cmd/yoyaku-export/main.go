@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/export"
+	"yoyaku/internal/ical"
+	"yoyaku/internal/license"
+)
+
+func main() {
+	var dataDir, tipo, formato, desde, hasta, salida string
+	var redactar bool
+	flag.StringVar(&dataDir, "datos", defaultDataDir(), "Directorio de datos de yoyaku")
+	flag.StringVar(&tipo, "tipo", "", "Qué exportar: pacientes, turnos o no_shows")
+	flag.StringVar(&formato, "formato", "csv", "Formato de salida: csv, json o ics (sólo turnos)")
+	flag.StringVar(&desde, "desde", "", "Fecha inicial AAAA-MM-DD (turnos y no_shows)")
+	flag.StringVar(&hasta, "hasta", "", "Fecha final AAAA-MM-DD (turnos y no_shows)")
+	flag.StringVar(&salida, "salida", "", "Archivo de salida (por defecto, salida estándar)")
+	flag.BoolVar(&redactar, "redactar", false, "Ocultar los datos de los pacientes en el formato ics")
+	flag.Parse()
+
+	if tipo == "" {
+		fmt.Println("Uso: go run cmd/yoyaku-export/main.go -tipo=turnos -formato=csv -desde=2025-01-01 -hasta=2025-01-31 [-salida=turnos.csv]")
+		fmt.Println("\nExporta pacientes, turnos o no-shows de la base de datos local")
+		os.Exit(1)
+	}
+
+	fechaDesde, fechaHasta, err := export.ParsearRango(desde, hasta)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, "yoyaku.db")); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: no se encontró la base de datos en %s\n", dataDir)
+		os.Exit(1)
+	}
+
+	database, err := db.NewDB(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	svc := export.NewService(db.NewTurnoRepo(database), db.NewPacienteRepo(database))
+	svc.UsarLicencia(license.NewService(db.NewLicenseRepo(database)).TieneFeature)
+
+	var n int
+	switch {
+	case export.Formato(formato) == export.FormatoICS:
+		if export.Tipo(tipo) != export.TipoTurnos {
+			fmt.Fprintln(os.Stderr, "Error: el formato ics sólo está disponible para turnos")
+			os.Exit(1)
+		}
+		var zona *time.Location
+		zona, err = db.NewConfigRepo(database).ObtenerZonaHoraria()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opciones := ical.Opciones{NombreCalendario: "yoyaku", Zona: zona, Redactar: redactar}
+		if salida == "" {
+			n, err = svc.ExportarICS(os.Stdout, fechaDesde, fechaHasta, opciones)
+		} else {
+			n, err = svc.ExportarICSArchivo(salida, fechaDesde, fechaHasta, opciones)
+		}
+	case salida == "":
+		n, err = svc.Exportar(os.Stdout, export.Tipo(tipo), export.Formato(formato), fechaDesde, fechaHasta)
+	default:
+		n, err = svc.ExportarArchivo(salida, export.Tipo(tipo), export.Formato(formato), fechaDesde, fechaHasta)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "✓ %d registros exportados\n", n)
+}
+
+func defaultDataDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".yoyaku")
+}
@@ -30,7 +30,7 @@ function PageLoader() {
 }
 
 function App() {
-  const { requiresActivation, activateLicense, loading } = useLicense()
+  const { requiresActivation, requestActivation, activateLicense, loading } = useLicense()
   const [showLicenseModal, setShowLicenseModal] = useState(false)
 
   useEffect(() => {
@@ -39,8 +39,8 @@ function App() {
     }
   }, [loading, requiresActivation])
 
-  const handleActivate = async (key) => {
-    await activateLicense(key)
+  const handleActivate = async (key, activation) => {
+    await activateLicense(key, activation)
     setShowLicenseModal(false)
   }
 
@@ -66,6 +66,7 @@ function App() {
       <LicenseModal
         isOpen={showLicenseModal}
         onClose={() => {}}
+        onRequestActivation={requestActivation}
         onActivate={handleActivate}
       />
     </>
@@ -1,6 +1,17 @@
 import { useState } from 'react'
 import './ConfigForm.css'
 
+// DIAS sigue la numeración de time.Weekday: 0 es domingo.
+const DIAS = [
+  { valor: 1, nombre: 'Lun' },
+  { valor: 2, nombre: 'Mar' },
+  { valor: 3, nombre: 'Mié' },
+  { valor: 4, nombre: 'Jue' },
+  { valor: 5, nombre: 'Vie' },
+  { valor: 6, nombre: 'Sáb' },
+  { valor: 0, nombre: 'Dom' },
+]
+
 export function ConfigForm({ config, onSave }) {
   const [formData, setFormData] = useState(config || {})
   const [guardando, setGuardando] = useState(false)
@@ -16,6 +27,20 @@ export function ConfigForm({ config, onSave }) {
     setFormData(prev => ({ ...prev, [field]: value }))
   }
 
+  const horario = { apertura: '09:00', cierre: '18:00', ...formData.horario }
+  horario.dias = horario.dias || []
+
+  const handleHorario = (field, value) => {
+    handleChange('horario', { ...horario, [field]: value })
+  }
+
+  const toggleDia = (dia) => {
+    const dias = horario.dias.includes(dia)
+      ? horario.dias.filter(d => d !== dia)
+      : [...horario.dias, dia].sort()
+    handleHorario('dias', dias)
+  }
+
   return (
     <form onSubmit={handleSubmit} className="config-form">
       <div className="form-section">
@@ -52,6 +77,47 @@ export function ConfigForm({ config, onSave }) {
         </div>
       </div>
 
+      <div className="form-section">
+        <h3 className="section-title">Horario de Atención</h3>
+
+        <div className="form-group">
+          <label className="form-label">Horario</label>
+          <div className="horario-horas">
+            <input
+              type="time"
+              className="form-input"
+              aria-label="Apertura"
+              value={horario.apertura}
+              onChange={e => handleHorario('apertura', e.target.value)}
+            />
+            <span>a</span>
+            <input
+              type="time"
+              className="form-input"
+              aria-label="Cierre"
+              value={horario.cierre}
+              onChange={e => handleHorario('cierre', e.target.value)}
+            />
+          </div>
+        </div>
+
+        <div className="form-group">
+          <label className="form-label">Días</label>
+          <div className="horario-dias">
+            {DIAS.map(({ valor, nombre }) => (
+              <label key={valor} className="horario-dia">
+                <input
+                  type="checkbox"
+                  checked={horario.dias.includes(valor)}
+                  onChange={() => toggleDia(valor)}
+                />
+                {nombre}
+              </label>
+            ))}
+          </div>
+        </div>
+      </div>
+
       <div className="form-section">
         <h3 className="section-title">Mensajes de WhatsApp</h3>
 
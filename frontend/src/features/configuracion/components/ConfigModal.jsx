@@ -99,17 +99,6 @@ export function ConfigModal({ isOpen, onClose }) {
                 placeholder="Ej: Av. Libertador 1234, Piso 3"
               />
             </div>
-
-            <div className="form-group">
-              <label className="form-label">Horario de Atención</label>
-              <input
-                type="text"
-                className="form-input"
-                value={formData.horarioAtencion}
-                onChange={e => handleChange('horarioAtencion', e.target.value)}
-                placeholder="Ej: Lunes a Viernes de 9:00 a 18:00"
-              />
-            </div>
           </div>
 
           <div className="form-section">
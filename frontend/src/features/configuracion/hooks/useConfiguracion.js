@@ -1,5 +1,10 @@
 import { useState, useEffect, useCallback } from 'react'
-import { getConfiguracion, guardarConfiguracion } from '../../../wailsbridge'
+import {
+  getConfiguracion,
+  guardarConfiguracion,
+  getHorarioLaboral,
+  guardarHorarioLaboral,
+} from '../../../wailsbridge'
 
 export function useConfiguracion() {
   const [config, setConfig] = useState(null)
@@ -11,9 +16,9 @@ export function useConfiguracion() {
     setError(null)
     try {
       console.log('Fetching configuration...')
-      const data = await getConfiguracion()
+      const [data, horario] = await Promise.all([getConfiguracion(), getHorarioLaboral()])
       console.log('Configuration loaded:', data)
-      setConfig(data)
+      setConfig({ ...data, horario })
     } catch (err) {
       console.error('Error loading configuration:', err)
       setError(err.message || 'Error cargando configuración')
@@ -26,10 +31,16 @@ export function useConfiguracion() {
     fetchConfig()
   }, [fetchConfig])
 
+  // El horario se guarda aparte: horarioAtencion es sólo su descripción y
+  // se vuelve a leer después de guardar.
   const guardar = async (nuevaConfig) => {
     try {
-      await guardarConfiguracion(nuevaConfig)
-      setConfig(nuevaConfig)
+      const { horario, ...general } = nuevaConfig
+      await guardarConfiguracion(general)
+      if (horario) {
+        await guardarHorarioLaboral(horario)
+      }
+      await fetchConfig()
       return true
     } catch (err) {
       setError(err.message || 'Error guardando configuración')
@@ -1,12 +1,20 @@
+import { useState } from 'react'
 import { useConfiguracion } from '../hooks/useConfiguracion'
 import { ConfigForm } from '../components/ConfigForm'
 import LicenseStatus from '../../license/components/LicenseStatus'
+import { LicenseModal } from '../../license/components/LicenseModal'
 import { useLicense } from '../../license/hooks/useLicense'
 import './ConfiguracionPage.css'
 
 export default function ConfiguracionPage() {
   const { config, loading, guardar } = useConfiguracion()
-  const { licenseInfo } = useLicense()
+  const { licenseInfo, requestActivation, activateLicense, importRevocations } = useLicense()
+  const [showRenovar, setShowRenovar] = useState(false)
+
+  const handleRenovar = async (key, activation) => {
+    await activateLicense(key, activation)
+    setShowRenovar(false)
+  }
 
   if (loading) {
     return (
@@ -26,8 +34,25 @@ export default function ConfiguracionPage() {
       </header>
 
       <LicenseStatus info={licenseInfo} />
+      {licenseInfo && (
+        <div className="license-renovar">
+          <button type="button" className="btn-secondary" onClick={() => importRevocations().catch(() => {})}>
+            Importar lista de revocación
+          </button>
+          <button type="button" className="btn-secondary" onClick={() => setShowRenovar(true)}>
+            {licenseInfo.estado === 'prueba' ? 'Activar licencia' : 'Renovar licencia'}
+          </button>
+        </div>
+      )}
 
       <ConfigForm config={config} onSave={guardar} />
+
+      <LicenseModal
+        isOpen={showRenovar}
+        onClose={() => setShowRenovar(false)}
+        onRequestActivation={requestActivation}
+        onActivate={handleRenovar}
+      />
     </div>
   )
 }
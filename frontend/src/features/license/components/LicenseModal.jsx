@@ -1,8 +1,10 @@
 import { useState, useEffect } from 'react'
 import './LicenseModal.css'
 
-export function LicenseModal({ isOpen, onActivate, onClose }) {
+export function LicenseModal({ isOpen, onRequestActivation, onActivate, onClose }) {
   const [licenseKey, setLicenseKey] = useState('')
+  const [requestCode, setRequestCode] = useState('')
+  const [activationCode, setActivationCode] = useState('')
   const [error, setError] = useState('')
   const [loading, setLoading] = useState(false)
   const [success, setSuccess] = useState(false)
@@ -11,6 +13,8 @@ export function LicenseModal({ isOpen, onActivate, onClose }) {
   useEffect(() => {
     if (isOpen) {
       setLicenseKey('')
+      setRequestCode('')
+      setActivationCode('')
       setError('')
       setSuccess(false)
       setLoading(false)
@@ -25,7 +29,13 @@ export function LicenseModal({ isOpen, onActivate, onClose }) {
     setLoading(true)
 
     try {
-      await onActivate(licenseKey.trim())
+      // Primer paso: generar el código de solicitud con la huella de esta
+      // máquina. Segundo paso: activar con el código que devuelve soporte.
+      if (!requestCode) {
+        setRequestCode(await onRequestActivation(licenseKey.trim()))
+        return
+      }
+      await onActivate(licenseKey.trim(), activationCode.trim())
       setSuccess(true)
       setTimeout(() => {
         onClose?.()
@@ -37,40 +47,25 @@ export function LicenseModal({ isOpen, onActivate, onClose }) {
     }
   }
 
-  const formatLicenseKey = (value) => {
-    // Si ya tiene el formato correcto (YOY2025-XXXX-XXXX), no lo tocamos
-    const cleanValue = value.replace(/[^A-Za-z0-9-]/g, '').toUpperCase()
-    
-    // Si ya tiene guiones, asumimos que está bien formateada
-    if (cleanValue.includes('-')) {
-      return cleanValue.slice(0, 17) // YOY2025-XXXX-XXXX = 17 chars
-    }
-    
-    // Si no tiene guiones, formateamos automáticamente
-    const cleaned = cleanValue.replace(/-/g, '')
-    if (cleaned.length <= 7) {
-      return cleaned // YOY2025
-    }
-    
-    // Formato: YOY2025-XXXX-XXXX
-    const part1 = cleaned.slice(0, 7) // YOY2025
-    const part2 = cleaned.slice(7, 11) // XXXX
-    const part3 = cleaned.slice(11, 15) // XXXX
-    
-    let result = part1
-    if (part2) result += '-' + part2
-    if (part3) result += '-' + part3
-    
-    return result
+  const handleChange = (e) => {
+    setLicenseKey(e.target.value)
+    // Una clave distinta necesita otra solicitud
+    setRequestCode('')
+    setActivationCode('')
+    if (error) setError('')
   }
 
-  const handleChange = (e) => {
-    const formatted = formatLicenseKey(e.target.value)
-    setLicenseKey(formatted)
+  const handleActivationChange = (e) => {
+    setActivationCode(e.target.value)
     if (error) setError('')
   }
 
-  const isValidFormat = licenseKey.length >= 15 && licenseKey.includes('-')
+  // Los códigos son <prefijo>.<datos>.<firma>; la verificación real la hace el backend
+  const tieneFormato = (prefijo, valor) =>
+    new RegExp(`^${prefijo}\\.[\\w-]+\\.[\\w-]+$`).test(valor.replace(/\s/g, ''))
+  const isValidFormat = requestCode
+    ? tieneFormato('YOYA1', activationCode)
+    : tieneFormato('YOY1', licenseKey)
 
   return (
     <div className="modal-overlay" onClick={onClose}>
@@ -113,21 +108,55 @@ export function LicenseModal({ isOpen, onActivate, onClose }) {
 
                 <div className="form-group">
                   <label className="form-label">Clave de Licencia</label>
-                  <input
-                    type="text"
+                  <textarea
                     className={`form-input license-input ${error ? 'error' : ''}`}
                     value={licenseKey}
                     onChange={handleChange}
-                    placeholder="YOY2025-XXXX-XXXX"
-                    maxLength={17}
+                    placeholder="YOY1.XXXX.XXXX"
+                    rows={4}
+                    spellCheck={false}
                     disabled={loading}
                     autoFocus
                   />
                   <span className="form-hint">
-                    Formato: YOYAAAA-XXXX-XXXX (se ingresa automáticamente)
+                    Pegue la licencia completa tal como la recibió (comienza con YOY1.)
                   </span>
                 </div>
 
+                {requestCode && (
+                  <>
+                    <div className="form-group">
+                      <label className="form-label">Código de solicitud</label>
+                      <textarea
+                        className="form-input license-input"
+                        value={requestCode}
+                        rows={3}
+                        readOnly
+                        onFocus={e => e.target.select()}
+                      />
+                      <span className="form-hint">
+                        Envíe este código a soporte. Identifica esta computadora y no requiere conexión a internet.
+                      </span>
+                    </div>
+
+                    <div className="form-group">
+                      <label className="form-label">Código de activación</label>
+                      <textarea
+                        className={`form-input license-input ${error ? 'error' : ''}`}
+                        value={activationCode}
+                        onChange={handleActivationChange}
+                        placeholder="YOYA1.XXXX.XXXX"
+                        rows={3}
+                        spellCheck={false}
+                        disabled={loading}
+                      />
+                      <span className="form-hint">
+                        Pegue el código de activación que le envió soporte (comienza con YOYA1.)
+                      </span>
+                    </div>
+                  </>
+                )}
+
                 {error && (
                   <div className="license-error">
                     <span className="error-icon">!</span>
@@ -152,7 +181,9 @@ export function LicenseModal({ isOpen, onActivate, onClose }) {
                   className="btn-primary" 
                   disabled={loading || !isValidFormat}
                 >
-                  {loading ? 'Activando...' : 'Activar Licencia'}
+                  {loading
+                    ? (requestCode ? 'Activando...' : 'Generando...')
+                    : (requestCode ? 'Activar Licencia' : 'Generar código de solicitud')}
                 </button>
               </div>
 
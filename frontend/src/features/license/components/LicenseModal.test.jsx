@@ -4,6 +4,7 @@ import LicenseModal from './LicenseModal'
 
 describe('LicenseModal', () => {
   const mockOnClose = vi.fn()
+  const mockOnRequestActivation = vi.fn()
   const mockOnActivate = vi.fn()
 
   beforeEach(() => {
@@ -31,7 +32,7 @@ describe('LicenseModal', () => {
       />
     )
 
-    expect(screen.getByRole('button', { name: /.activar licencia/i })).toBeInTheDocument()
+    expect(screen.getByRole('button', { name: /generar código de solicitud/i })).toBeInTheDocument()
     expect(screen.getByText('Bienvenido a Yoyaku - Sistema de Gestión de Turnos')).toBeInTheDocument()
   })
 
@@ -51,7 +52,7 @@ describe('LicenseModal', () => {
     expect(screen.getByText('Renovación opcional para nuevas versiones')).toBeInTheDocument()
   })
 
-  it('should format license key input correctly', () => {
+  it('should keep pasted license key as is', () => {
     render(
       <LicenseModal
         isOpen={true}
@@ -60,11 +61,11 @@ describe('LicenseModal', () => {
       />
     )
 
-    const input = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
+    const input = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
     
-    fireEvent.change(input, { target: { value: 'yoy2024abcd1234' } })
+    fireEvent.change(input, { target: { value: 'YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl' } })
     
-    expect(input.value).toBe('YOY2-024A-BCD1-234')
+    expect(input.value).toBe('YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl')
   })
 
   it('should disable submit button when license key is incomplete', () => {
@@ -72,15 +73,16 @@ describe('LicenseModal', () => {
       <LicenseModal
         isOpen={true}
         onClose={mockOnClose}
+        onRequestActivation={mockOnRequestActivation}
         onActivate={mockOnActivate}
       />
     )
 
-    const submitButton = screen.getByRole('button', { name: /.activar licencia/i })
+    const submitButton = screen.getByRole('button', { name: /generar código de solicitud/i })
     expect(submitButton).toBeDisabled()
 
-    const input = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
-    fireEvent.change(input, { target: { value: 'YOY2024-TEST' } })
+    const input = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
+    fireEvent.change(input, { target: { value: 'YOY1.eyJpZCI6IngifQ' } })
     
     expect(submitButton).toBeDisabled()
   })
@@ -90,54 +92,67 @@ describe('LicenseModal', () => {
       <LicenseModal
         isOpen={true}
         onClose={mockOnClose}
+        onRequestActivation={mockOnRequestActivation}
         onActivate={mockOnActivate}
       />
     )
 
-    const input = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
-    fireEvent.change(input, { target: { value: 'YOY2024-ABCD-EFGH' } })
+    const input = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
+    fireEvent.change(input, { target: { value: 'YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl' } })
 
-    const submitButton = screen.getByRole('button', { name: /.activar licencia/i })
+    const submitButton = screen.getByRole('button', { name: /generar código de solicitud/i })
     expect(submitButton).not.toBeDisabled()
   })
 
-  it('should call onActivate with license key when form is submitted', async () => {
+  it('should request an activation code and then activate with the response', async () => {
+    mockOnRequestActivation.mockResolvedValueOnce('YOYS1.c29saWNpdHVk')
     mockOnActivate.mockResolvedValueOnce({})
 
     render(
       <LicenseModal
         isOpen={true}
         onClose={mockOnClose}
+        onRequestActivation={mockOnRequestActivation}
         onActivate={mockOnActivate}
       />
     )
 
-    const input = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
-    fireEvent.change(input, { target: { value: 'YOY2024-ABCD-EFGH' } })
+    const input = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
+    fireEvent.change(input, { target: { value: 'YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl' } })
+    fireEvent.click(screen.getByRole('button', { name: /generar código de solicitud/i }))
 
-    const submitButton = screen.getByRole('button', { name: /.activar licencia/i })
+    await waitFor(() => {
+      expect(screen.getByDisplayValue('YOYS1.c29saWNpdHVk')).toBeInTheDocument()
+    })
+    expect(mockOnRequestActivation).toHaveBeenCalledWith('YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl')
+
+    const submitButton = screen.getByRole('button', { name: /activar licencia/i })
+    expect(submitButton).toBeDisabled()
+
+    fireEvent.change(screen.getByPlaceholderText('YOYA1.XXXX.XXXX'), { target: { value: 'YOYA1.YWN0aXZhY2lvbg.ZmlybWE' } })
     fireEvent.click(submitButton)
 
     await waitFor(() => {
-      expect(mockOnActivate).toHaveBeenCalledWith('YOY2024-ABCD-EFGH')
+      expect(mockOnActivate).toHaveBeenCalledWith('YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl', 'YOYA1.YWN0aXZhY2lvbg.ZmlybWE')
     })
   })
 
-  it('should display error message when activation fails', async () => {
-    mockOnActivate.mockRejectedValueOnce(new Error('Licencia inválida'))
+  it('should display error message when the request fails', async () => {
+    mockOnRequestActivation.mockRejectedValueOnce(new Error('Licencia inválida'))
 
     render(
       <LicenseModal
         isOpen={true}
         onClose={mockOnClose}
+        onRequestActivation={mockOnRequestActivation}
         onActivate={mockOnActivate}
       />
     )
 
-    const input = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
-    fireEvent.change(input, { target: { value: 'YOY2024-ABCD-EFGH' } })
+    const input = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
+    fireEvent.change(input, { target: { value: 'YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl' } })
 
-    const submitButton = screen.getByRole('button', { name: /.activar licencia/i })
+    const submitButton = screen.getByRole('button', { name: /generar código de solicitud/i })
     fireEvent.click(submitButton)
 
     await waitFor(() => {
@@ -145,25 +160,26 @@ describe('LicenseModal', () => {
     })
   })
 
-  it('should show loading state during activation', async () => {
-    mockOnActivate.mockImplementation(() => new Promise(() => {}))
+  it('should show loading state while generating the request', async () => {
+    mockOnRequestActivation.mockImplementation(() => new Promise(() => {}))
 
     render(
       <LicenseModal
         isOpen={true}
         onClose={mockOnClose}
+        onRequestActivation={mockOnRequestActivation}
         onActivate={mockOnActivate}
       />
     )
 
-    const input = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
-    fireEvent.change(input, { target: { value: 'YOY2024-ABCD-EFGH' } })
+    const input = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
+    fireEvent.change(input, { target: { value: 'YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl' } })
 
-    const submitButton = screen.getByRole('button', { name: /activar licencia/i })
+    const submitButton = screen.getByRole('button', { name: /generar código de solicitud/i })
     fireEvent.click(submitButton)
 
     await waitFor(() => {
-      expect(screen.getByText('Activando...')).toBeInTheDocument()
+      expect(screen.getByText('Generando...')).toBeInTheDocument()
     })
     expect(input).toBeDisabled()
   })
@@ -177,9 +193,9 @@ describe('LicenseModal', () => {
       />
     )
 
-    const input = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
-    fireEvent.change(input, { target: { value: 'YOY2024-ABCD-EFGH' } })
-    expect(input.value).toBe('YOY2-024A-BCDE-FGH')
+    const input = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
+    fireEvent.change(input, { target: { value: 'YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl' } })
+    expect(input.value).toBe('YOY1.eyJpZCI6IngifQ.c2lnbmF0dXJl')
 
     // Close and reopen modal
     rerender(
@@ -198,7 +214,7 @@ describe('LicenseModal', () => {
       />
     )
 
-    const newInput = screen.getByPlaceholderText('YOY2024-XXXX-XXXX')
+    const newInput = screen.getByPlaceholderText('YOY1.XXXX.XXXX')
     expect(newInput.value).toBe('')
   })
 
@@ -4,23 +4,38 @@ import './LicenseStatus.css';
 const LicenseStatus = ({ info }) => {
   if (!info) return null;
 
+  // Una versión publicada fuera del período se usa igual, pero se avisa.
+  const noCubierta = info.cobertura === 'no_cubierta';
+
   const getStatusClass = () => {
+    if (noCubierta) return 'warning';
     switch (info.estado) {
       case 'activa':
         return info.diasRestantes <= 30 ? 'warning' : 'active';
+      case 'prueba':
+        return info.diasRestantes <= 7 ? 'warning' : 'expired';
       case 'expirada':
         return 'expired';
+      case 'alterada':
+      case 'reloj_atrasado':
+        return 'warning';
       default:
         return 'inactive';
     }
   };
 
   const getStatusIcon = () => {
+    if (noCubierta) return '⚠️';
     switch (info.estado) {
       case 'activa':
         return info.diasRestantes <= 30 ? '⚠️' : '✅';
+      case 'prueba':
+        return '⏳';
       case 'expirada':
         return 'ℹ️';
+      case 'alterada':
+      case 'reloj_atrasado':
+        return '⚠️';
       default:
         return '❌';
     }
@@ -31,12 +46,17 @@ const LicenseStatus = ({ info }) => {
       <div className="license-status-icon">{getStatusIcon()}</div>
       <div className="license-status-content">
         <p className="license-status-message">{info.mensaje}</p>
-        {info.estado === 'activa' && info.diasRestantes > 0 && (
+        {info.edicion && (
+          <p className="license-status-edicion">
+            Edición {info.edicion === 'pro' ? 'Pro' : 'Básica'}
+          </p>
+        )}
+        {(info.estado === 'activa' || info.estado === 'prueba') && info.diasRestantes > 0 && (
           <div className="license-status-bar">
             <div
               className="license-status-progress"
               style={{
-                width: `${Math.min(100, (info.diasRestantes / 365) * 100)}%`
+                width: `${Math.min(100, (info.diasRestantes / (info.estado === 'prueba' ? 30 : 365)) * 100)}%`
               }}
             />
           </div>
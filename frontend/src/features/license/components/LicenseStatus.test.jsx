@@ -21,6 +21,12 @@ describe('LicenseStatus', () => {
     expect(screen.getByText('✅')).toBeInTheDocument()
   })
 
+  it('should show the license edition', () => {
+    render(<LicenseStatus info={{ estado: 'activa', diasRestantes: 300, edicion: 'pro', mensaje: 'Licencia activa.' }} />)
+
+    expect(screen.getByText('Edición Pro')).toBeInTheDocument()
+  })
+
   it('should render warning status when license is about to expire', () => {
     const mockInfo = {
       estado: 'activa',
@@ -47,6 +53,34 @@ describe('LicenseStatus', () => {
     expect(screen.getByText('ℹ️')).toBeInTheDocument()
   })
 
+  it('should warn when this version is not covered by the update period', () => {
+    const mockInfo = {
+      estado: 'expirada',
+      diasRestantes: -30,
+      cobertura: 'no_cubierta',
+      mensaje: 'Esta versión se publicó el 01/03/2025, después del fin de su período de actualizaciones (01/01/2025).',
+    }
+
+    const { container } = render(<LicenseStatus info={mockInfo} />)
+
+    expect(screen.getByText(mockInfo.mensaje)).toBeInTheDocument()
+    expect(screen.getByText('⚠️')).toBeInTheDocument()
+    expect(container.firstChild).toHaveClass('warning')
+  })
+
+  it('should render trial status', () => {
+    const mockInfo = {
+      estado: 'prueba',
+      diasRestantes: 20,
+      mensaje: 'Período de prueba: quedan 20 días (hasta el 30/03/2025).',
+    }
+
+    render(<LicenseStatus info={mockInfo} />)
+
+    expect(screen.getByText(mockInfo.mensaje)).toBeInTheDocument()
+    expect(screen.getByText('⏳')).toBeInTheDocument()
+  })
+
   it('should render inactive license status', () => {
     const mockInfo = {
       estado: 'no_configurada',
@@ -132,6 +166,34 @@ describe('LicenseStatus', () => {
     expect(statusElement).toHaveClass('expired')
   })
 
+  it('should warn when the stored license was tampered with', () => {
+    const mockInfo = {
+      estado: 'alterada',
+      diasRestantes: 100,
+      mensaje: 'Los datos guardados de la licencia fueron modificados',
+    }
+
+    render(<LicenseStatus info={mockInfo} />)
+
+    const statusElement = document.querySelector('.license-status')
+    expect(statusElement).toHaveClass('warning')
+    expect(screen.getByText('⚠️')).toBeInTheDocument()
+  })
+
+  it('should warn when the system clock is behind the last use', () => {
+    const mockInfo = {
+      estado: 'reloj_atrasado',
+      diasRestantes: 5,
+      mensaje: 'La fecha de la computadora es anterior a la última vez que se usó Yoyaku',
+    }
+
+    render(<LicenseStatus info={mockInfo} />)
+
+    const statusElement = document.querySelector('.license-status')
+    expect(statusElement).toHaveClass('warning')
+    expect(document.querySelector('.license-status-bar')).toBeNull()
+  })
+
   it('should cap progress bar at 100%', () => {
     const mockInfo = {
       estado: 'activa',
@@ -25,10 +25,20 @@ export const useLicense = () => {
     }
   }, []);
 
-  const activateLicense = useCallback(async (key) => {
+  const requestActivation = useCallback(async (key) => {
     try {
       setError(null);
-      const info = await window.go.main.App.ValidarLicencia(key);
+      return await window.go.main.App.SolicitarActivacion(key);
+    } catch (err) {
+      setError(err.message);
+      throw err;
+    }
+  }, []);
+
+  const activateLicense = useCallback(async (key, activation) => {
+    try {
+      setError(null);
+      const info = await window.go.main.App.ValidarLicencia(key, activation);
       setLicenseInfo(info);
       setRequiresActivation(false);
       return info;
@@ -38,6 +48,20 @@ export const useLicense = () => {
     }
   }, []);
 
+  const importRevocations = useCallback(async () => {
+    try {
+      setError(null);
+      const lista = await window.go.main.App.ImportarListaRevocacion();
+      if (lista) {
+        await checkLicense();
+      }
+      return lista;
+    } catch (err) {
+      setError(err.message);
+      throw err;
+    }
+  }, [checkLicense]);
+
   useEffect(() => {
     checkLicense();
   }, [checkLicense]);
@@ -47,7 +71,9 @@ export const useLicense = () => {
     requiresActivation,
     loading,
     error,
+    requestActivation,
     activateLicense,
+    importRevocations,
     refreshLicense: checkLicense
   };
 };
\ No newline at end of file
@@ -74,9 +74,9 @@ describe('useLicense', () => {
     })
 
     // Activate license
-    await result.current.activateLicense('YOY2025-ABCD-EFGH')
+    await result.current.activateLicense('YOY1.datos.firma', 'YOYA1.datos.firma')
 
-    expect(window.go.main.App.ValidarLicencia).toHaveBeenCalledWith('YOY2025-ABCD-EFGH')
+    expect(window.go.main.App.ValidarLicencia).toHaveBeenCalledWith('YOY1.datos.firma', 'YOYA1.datos.firma')
     
     await waitFor(() => {
       expect(result.current.licenseInfo).toEqual(mockLicenseInfo)
@@ -96,7 +96,7 @@ describe('useLicense', () => {
     })
 
     // Try to activate with invalid key
-    await expect(result.current.activateLicense('INVALID-KEY')).rejects.toThrow('Invalid license key')
+    await expect(result.current.activateLicense('INVALID-KEY', '')).rejects.toThrow('Invalid license key')
 
     await waitFor(() => {
       expect(result.current.error).toBe('Invalid license key')
@@ -151,10 +151,23 @@ describe('useLicense', () => {
       mensaje: 'Licencia activada',
     })
 
-    await result.current.activateLicense('YOY2025-ABCD-EFGH')
+    await result.current.activateLicense('YOY1.datos.firma', 'YOYA1.datos.firma')
 
     await waitFor(() => {
       expect(result.current.error).toBeNull()
     })
   })
-})
\ No newline at end of file
+
+  it('should request an activation code for the license key', async () => {
+    window.go.main.App.SolicitarActivacion.mockResolvedValueOnce('YOYS1.solicitud')
+
+    const { result } = renderHook(() => useLicense())
+
+    await waitFor(() => {
+      expect(result.current.loading).toBe(false)
+    })
+
+    await expect(result.current.requestActivation('YOY1.datos.firma')).resolves.toBe('YOYS1.solicitud')
+    expect(window.go.main.App.SolicitarActivacion).toHaveBeenCalledWith('YOY1.datos.firma')
+  })
+})
@@ -4,9 +4,13 @@ import '@testing-library/jest-dom'
 window.go = {
   main: {
     App: {
+      SolicitarActivacion: vi.fn(),
       ValidarLicencia: vi.fn(),
       ObtenerInfoLicencia: vi.fn(),
       RequiereActivacion: vi.fn(),
+      ObtenerHistorialLicencias: vi.fn(),
+      TieneFeature: vi.fn(),
+      ImportarListaRevocacion: vi.fn(),
     }
   }
 }
\ No newline at end of file
@@ -17,6 +17,8 @@ import {
   GetHistorialPaciente,
   GetConfiguracion,
   GuardarConfiguracion,
+  GetHorarioLaboral,
+  GuardarHorarioLaboral,
 } from '../../wailsjs/go/main/App'
 
 // ==================== TURNOS ====================
@@ -53,6 +55,10 @@ export const getConfiguracion = () => GetConfiguracion()
 
 export const guardarConfiguracion = (config) => GuardarConfiguracion(config)
 
+export const getHorarioLaboral = () => GetHorarioLaboral()
+
+export const guardarHorarioLaboral = (horario) => GuardarHorarioLaboral(horario)
+
 // ==================== UTILIDADES ====================
 
 export const formatFecha = (date) => {
@@ -24,6 +24,8 @@ export function GetConfiguracion():Promise<models.Configuracion>;
 
 export function GetHistorialPaciente(arg1:number):Promise<Array<models.Turno>>;
 
+export function GetHorarioLaboral():Promise<models.HorarioLaboral>;
+
 export function GetPaciente(arg1:number):Promise<models.Paciente>;
 
 export function GetTurno(arg1:number):Promise<models.Turno>;
@@ -32,6 +34,8 @@ export function GetTurnosDelDia(arg1:string):Promise<models.AgendaDia>;
 
 export function GuardarConfiguracion(arg1:models.Configuracion):Promise<void>;
 
+export function GuardarHorarioLaboral(arg1:models.HorarioLaboral):Promise<void>;
+
 export function ObtenerInfoLicencia():Promise<models.InfoLicencia>;
 
 export function RequiereActivacion():Promise<boolean>;
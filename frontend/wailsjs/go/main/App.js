@@ -46,6 +46,10 @@ export function GetHistorialPaciente(arg1) {
   return window['go']['main']['App']['GetHistorialPaciente'](arg1);
 }
 
+export function GetHorarioLaboral() {
+  return window['go']['main']['App']['GetHorarioLaboral']();
+}
+
 export function GetPaciente(arg1) {
   return window['go']['main']['App']['GetPaciente'](arg1);
 }
@@ -62,6 +66,10 @@ export function GuardarConfiguracion(arg1) {
   return window['go']['main']['App']['GuardarConfiguracion'](arg1);
 }
 
+export function GuardarHorarioLaboral(arg1) {
+  return window['go']['main']['App']['GuardarHorarioLaboral'](arg1);
+}
+
 export function ObtenerInfoLicencia() {
   return window['go']['main']['App']['ObtenerInfoLicencia']();
 }
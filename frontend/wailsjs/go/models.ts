@@ -186,6 +186,22 @@ export namespace models {
 		    return a;
 		}
 	}
+	export class HorarioLaboral {
+	    apertura: string;
+	    cierre: string;
+	    dias: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HorarioLaboral(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.apertura = source["apertura"];
+	        this.cierre = source["cierre"];
+	        this.dias = source["dias"];
+	    }
+	}
 	export class InfoLicencia {
 	    estado: string;
 	    // Go type: time
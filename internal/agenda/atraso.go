@@ -0,0 +1,133 @@
+package agenda
+
+import (
+	"sort"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+const (
+	// duracionPorDefecto se usa para los turnos cargados sin duración.
+	duracionPorDefecto = 30 * time.Minute
+	// toleranciaLlegada es cuánto después de su horario se sigue esperando a
+	// un paciente que no llegó antes de sacarlo de la proyección.
+	toleranciaLlegada = 15 * time.Minute
+	// Límites del factor de ritmo, para que una consulta anómala no
+	// distorsione toda la proyección.
+	factorMinimo = 0.5
+	factorMaximo = 2.0
+)
+
+// CalcularAtraso proyecta el resto del día de cada profesional a partir de
+// los horarios reales registrados. La consulta en curso termina según su
+// inicio real, y los turnos que faltan se encadenan en orden de llamado con
+// su duración ajustada al ritmo de las consultas ya terminadas. El atraso
+// actual es la demora proyectada del próximo paciente, o la de la consulta
+// en curso si no queda nadie esperando.
+//
+// Sólo se proyectan los turnos de hoy; para otros días la proyección está
+// vacía.
+func (s *Service) CalcularAtraso(turnos []models.Turno) *models.ProyeccionAtraso {
+	ahora := s.reloj()
+	hoy := ahora.Format("2006-01-02")
+
+	porProfesional := make(map[int64][]models.Turno)
+	var profesionales []int64
+	for _, turno := range turnos {
+		if turno.Fecha.Format("2006-01-02") != hoy {
+			continue
+		}
+		if _, ok := porProfesional[turno.ProfesionalID]; !ok {
+			profesionales = append(profesionales, turno.ProfesionalID)
+		}
+		porProfesional[turno.ProfesionalID] = append(porProfesional[turno.ProfesionalID], turno)
+	}
+	sort.Slice(profesionales, func(i, j int) bool { return profesionales[i] < profesionales[j] })
+
+	proyeccion := &models.ProyeccionAtraso{Estimaciones: []models.EstimacionTurno{}}
+	for _, id := range profesionales {
+		atraso := s.proyectar(porProfesional[id], ahora, proyeccion)
+		proyeccion.AtrasoMinutos = max(proyeccion.AtrasoMinutos, int(atraso.Minutes()))
+	}
+	return proyeccion
+}
+
+// proyectar agrega a proyeccion las estimaciones de los turnos de un
+// profesional y devuelve su atraso actual.
+func (s *Service) proyectar(turnos []models.Turno, ahora time.Time, proyeccion *models.ProyeccionAtraso) time.Duration {
+	factor := factorRitmo(turnos)
+
+	cursor := ahora
+	var atraso time.Duration
+	var cola []models.Turno
+	for _, turno := range turnos {
+		programado := s.horarioProgramado(turno)
+		switch turno.Estado {
+		case models.EstadoEnConsulta:
+			if turno.HoraInicio == nil {
+				continue
+			}
+			if fin := turno.HoraInicio.Add(duracionEstimada(turno, factor)); fin.After(cursor) {
+				cursor = fin
+			}
+			atraso = max(atraso, turno.HoraInicio.Sub(programado))
+		case models.EstadoEnEspera:
+			cola = append(cola, turno)
+		case models.EstadoPendiente, models.EstadoConfirmado:
+			if ahora.Sub(programado) <= toleranciaLlegada {
+				cola = append(cola, turno)
+			}
+		}
+	}
+
+	s.ordenarParaLlamar(cola)
+	for i, turno := range cola {
+		programado := s.horarioProgramado(turno)
+		inicio := cursor
+		if programado.After(inicio) {
+			inicio = programado
+		}
+		if turno.HoraLlegada != nil && turno.HoraLlegada.After(inicio) {
+			inicio = *turno.HoraLlegada
+		}
+
+		demora := max(inicio.Sub(programado), 0)
+		proyeccion.Estimaciones = append(proyeccion.Estimaciones, models.EstimacionTurno{
+			TurnoID:        turno.ID,
+			InicioEstimado: inicio,
+			DemoraMinutos:  int(demora.Minutes()),
+		})
+		if i == 0 {
+			atraso = demora
+		}
+		cursor = inicio.Add(duracionEstimada(turno, factor))
+	}
+
+	return max(atraso, 0)
+}
+
+// factorRitmo compara la duración real de las consultas terminadas con la
+// programada. Sin consultas registradas se asume que se cumple el horario.
+func factorRitmo(turnos []models.Turno) float64 {
+	var real, programada time.Duration
+	for _, turno := range turnos {
+		if turno.Estado != models.EstadoAtendido || turno.HoraInicio == nil || turno.HoraFin == nil {
+			continue
+		}
+		real += turno.HoraFin.Sub(*turno.HoraInicio)
+		programada += duracionEstimada(turno, 1)
+	}
+	if programada == 0 {
+		return 1
+	}
+	return min(max(float64(real)/float64(programada), factorMinimo), factorMaximo)
+}
+
+func duracionEstimada(turno models.Turno, factor float64) time.Duration {
+	duracion := time.Duration(turno.Duracion) * time.Minute
+	if duracion <= 0 {
+		duracion = duracionPorDefecto
+	}
+	return time.Duration(float64(duracion) * factor)
+}
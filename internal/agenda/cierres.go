@@ -0,0 +1,176 @@
+package agenda
+
+import (
+	"fmt"
+	"time"
+
+	"yoyaku/internal/feriados"
+	"yoyaku/internal/models"
+)
+
+// maxDiasConsulta limita el rango de DiasCerrados, que recorre día por día.
+const maxDiasConsulta = 366
+
+// DiaCerradoError indica que se intentó dar un turno en un día sin atención.
+type DiaCerradoError struct {
+	Dia models.DiaCerrado
+}
+
+func (e *DiaCerradoError) Error() string {
+	return fmt.Sprintf("no se dan turnos el %s: %s", e.Dia.Fecha.Format("02/01/2006"), e.Dia.Motivo)
+}
+
+// DiasCerrados devuelve los días de desde a hasta inclusive en que el
+// profesional no atiende, por feriado nacional o por un cierre propio o de
+// todo el consultorio. Con profesionalID en cero sólo cuentan los cierres
+// del consultorio.
+func (s *Service) DiasCerrados(profesionalID int64, desde, hasta time.Time) ([]models.DiaCerrado, error) {
+	desde, hasta = dia(desde), dia(hasta)
+	if hasta.Before(desde) {
+		return nil, fmt.Errorf("la fecha de fin es anterior a la de inicio")
+	}
+	if hasta.Sub(desde) > maxDiasConsulta*24*time.Hour {
+		return nil, fmt.Errorf("el rango no puede superar los %d días", maxDiasConsulta)
+	}
+
+	cierres, err := s.cierreRepo.ListarPorProfesional(profesionalID, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+
+	porAnio := map[int]map[time.Time]string{}
+	dias := []models.DiaCerrado{}
+	for d := desde; !d.After(hasta); d = d.AddDate(0, 0, 1) {
+		if porAnio[d.Year()] == nil {
+			porAnio[d.Year()] = map[time.Time]string{}
+			for _, f := range feriados.Argentina(d.Year()) {
+				porAnio[d.Year()][f.Fecha] = f.Nombre
+			}
+		}
+		if nombre, ok := porAnio[d.Year()][d]; ok {
+			dias = append(dias, models.DiaCerrado{Fecha: d, Motivo: "Feriado: " + nombre, Feriado: true})
+			continue
+		}
+		for _, c := range cierres {
+			if !d.Before(c.Desde) && !d.After(c.Hasta) {
+				dias = append(dias, models.DiaCerrado{Fecha: d, Motivo: motivoCierre(c), CierreID: c.ID})
+				break
+			}
+		}
+	}
+	return dias, nil
+}
+
+func (s *Service) ListarCierres(desde, hasta time.Time) ([]models.Cierre, error) {
+	return s.cierreRepo.ListarPorRango(desde, hasta)
+}
+
+// CrearCierre guarda el cierre y devuelve los turnos ya dados que caen en él,
+// para reprogramarlos. Los turnos no se modifican. Sin Hasta el cierre es de
+// un solo día.
+func (s *Service) CrearCierre(cierre *models.Cierre) (*models.ReporteCierre, error) {
+	if cierre.Desde.IsZero() {
+		return nil, fmt.Errorf("falta la fecha de inicio del cierre")
+	}
+	if cierre.Hasta.IsZero() {
+		cierre.Hasta = cierre.Desde
+	}
+	cierre.Desde, cierre.Hasta = dia(cierre.Desde), dia(cierre.Hasta)
+	if cierre.Hasta.Before(cierre.Desde) {
+		return nil, fmt.Errorf("la fecha de fin es anterior a la de inicio")
+	}
+
+	if err := s.cierreRepo.Crear(cierre); err != nil {
+		return nil, err
+	}
+	s.eventos.Publicar(eventoCierre(EventoCierreCreado, cierre))
+
+	afectados, err := s.turnosAfectados(cierre)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReporteCierre{Cierre: cierre, TurnosAfectados: afectados}, nil
+}
+
+func (s *Service) EliminarCierre(cierreID int64) error {
+	cierre, err := s.obtenerCierre(cierreID)
+	if err != nil {
+		return err
+	}
+	if err := s.cierreRepo.Eliminar(cierreID); err != nil {
+		return err
+	}
+	s.eventos.Publicar(eventoCierre(EventoCierreEliminado, cierre))
+	return nil
+}
+
+// TurnosAfectados devuelve los turnos todavía activos que caen en el cierre.
+func (s *Service) TurnosAfectados(cierreID int64) ([]models.Turno, error) {
+	cierre, err := s.obtenerCierre(cierreID)
+	if err != nil {
+		return nil, err
+	}
+	return s.turnosAfectados(cierre)
+}
+
+func (s *Service) turnosAfectados(cierre *models.Cierre) ([]models.Turno, error) {
+	var turnos []models.Turno
+	var err error
+	if cierre.ProfesionalID == 0 {
+		turnos, err = s.turnoRepo.ListarPorRango(cierre.Desde, cierre.Hasta)
+	} else {
+		turnos, err = s.turnoRepo.ListarPorProfesional(cierre.ProfesionalID, cierre.Desde, cierre.Hasta)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	afectados := []models.Turno{}
+	for _, turno := range turnos {
+		switch turno.Estado {
+		case models.EstadoPendiente, models.EstadoConfirmado, models.EstadoEnEspera, models.EstadoEnConsulta:
+			afectados = append(afectados, turno)
+		}
+	}
+	return afectados, nil
+}
+
+// verificarDiaAbierto devuelve un *DiaCerradoError si el profesional no
+// atiende en la fecha.
+func (s *Service) verificarDiaAbierto(profesionalID int64, fecha time.Time) error {
+	if profesionalID == 0 {
+		profesionalID = models.ProfesionalPrincipalID
+	}
+	dias, err := s.DiasCerrados(profesionalID, fecha, fecha)
+	if err != nil {
+		return err
+	}
+	if len(dias) > 0 {
+		return &DiaCerradoError{Dia: dias[0]}
+	}
+	return nil
+}
+
+func (s *Service) obtenerCierre(cierreID int64) (*models.Cierre, error) {
+	cierre, err := s.cierreRepo.ObtenerPorID(cierreID)
+	if err != nil {
+		return nil, err
+	}
+	if cierre == nil {
+		return nil, fmt.Errorf("cierre %d inexistente", cierreID)
+	}
+	return cierre, nil
+}
+
+func motivoCierre(cierre models.Cierre) string {
+	if cierre.Motivo == "" {
+		return "Cierre"
+	}
+	return cierre.Motivo
+}
+
+// dia devuelve la medianoche UTC del día de t, la forma en que se guardan
+// las fechas de turnos y cierres.
+func dia(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
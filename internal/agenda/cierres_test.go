@@ -0,0 +1,104 @@
+package agenda
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+func TestCrearTurno_Feriado(t *testing.T) {
+	s := setupService(t)
+
+	paciente := &models.Paciente{Nombre: "Juan Pérez"}
+	if err := s.CrearPaciente(paciente); err != nil {
+		t.Fatalf("CrearPaciente() failed: %v", err)
+	}
+
+	// 24/03/2025: Día Nacional de la Memoria.
+	turno := &models.Turno{PacienteID: paciente.ID, Fecha: time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC), Hora: models.NuevaHora(10, 0)}
+	var cerrado *DiaCerradoError
+	if err := s.CrearTurno(turno); !errors.As(err, &cerrado) || !cerrado.Dia.Feriado {
+		t.Fatalf("CrearTurno() en feriado error = %v, want *DiaCerradoError de feriado", err)
+	}
+	if turno.ID != 0 {
+		t.Error("CrearTurno() en feriado guardó el turno")
+	}
+}
+
+func TestCierres(t *testing.T) {
+	s := setupService(t)
+
+	antes := crearTurno(t, s, models.NuevaHora(9, 0))
+	otro := &models.Turno{PacienteID: antes.PacienteID, ProfesionalID: 2, Fecha: fechaPrueba, Hora: models.NuevaHora(9, 0)}
+	if err := s.CrearTurno(otro); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+
+	reporte, err := s.CrearCierre(&models.Cierre{
+		ProfesionalID: models.ProfesionalPrincipalID,
+		Desde:         fechaPrueba,
+		Hasta:         fechaPrueba.AddDate(0, 0, 4),
+		Motivo:        "Congreso",
+	})
+	if err != nil {
+		t.Fatalf("CrearCierre() failed: %v", err)
+	}
+	if len(reporte.TurnosAfectados) != 1 || reporte.TurnosAfectados[0].ID != antes.ID {
+		t.Errorf("TurnosAfectados = %v, want sólo el turno %d", reporte.TurnosAfectados, antes.ID)
+	}
+
+	nuevo := &models.Turno{PacienteID: antes.PacienteID, Fecha: fechaPrueba.AddDate(0, 0, 2), Hora: models.NuevaHora(11, 0)}
+	var cerrado *DiaCerradoError
+	if err := s.CrearTurno(nuevo); !errors.As(err, &cerrado) || cerrado.Dia.Motivo != "Congreso" {
+		t.Errorf("CrearTurno() en cierre error = %v, want *DiaCerradoError por Congreso", err)
+	}
+
+	// El cierre es sólo del profesional principal.
+	nuevo.ProfesionalID = 2
+	if err := s.CrearTurno(nuevo); err != nil {
+		t.Errorf("CrearTurno() de otro profesional failed: %v", err)
+	}
+
+	// Los turnos que quedaron dentro del cierre se pueden seguir editando.
+	antes.Motivo = "Reprogramar"
+	if err := s.ActualizarTurno(antes); err != nil {
+		t.Errorf("ActualizarTurno() sin cambiar la fecha failed: %v", err)
+	}
+
+	dias, err := s.DiasCerrados(models.ProfesionalPrincipalID, fechaPrueba, fechaPrueba.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatalf("DiasCerrados() failed: %v", err)
+	}
+	if len(dias) != 5 {
+		t.Errorf("len(DiasCerrados()) = %d, want 5", len(dias))
+	}
+
+	if err := s.EliminarCierre(reporte.Cierre.ID); err != nil {
+		t.Fatalf("EliminarCierre() failed: %v", err)
+	}
+	nuevo.ID, nuevo.ProfesionalID = 0, 0
+	if err := s.CrearTurno(nuevo); err != nil {
+		t.Errorf("CrearTurno() después de eliminar el cierre failed: %v", err)
+	}
+}
+
+func TestCrearCierre_Validacion(t *testing.T) {
+	s := setupService(t)
+
+	if _, err := s.CrearCierre(&models.Cierre{}); err == nil {
+		t.Error("CrearCierre() sin fecha should fail")
+	}
+	if _, err := s.CrearCierre(&models.Cierre{Desde: fechaPrueba, Hasta: fechaPrueba.AddDate(0, 0, -1)}); err == nil {
+		t.Error("CrearCierre() con fin anterior al inicio should fail")
+	}
+
+	reporte, err := s.CrearCierre(&models.Cierre{Desde: fechaPrueba})
+	if err != nil {
+		t.Fatalf("CrearCierre() de un día failed: %v", err)
+	}
+	if !reporte.Cierre.Hasta.Equal(fechaPrueba) {
+		t.Errorf("Hasta = %v, want %v", reporte.Cierre.Hasta, fechaPrueba)
+	}
+}
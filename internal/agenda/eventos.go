@@ -0,0 +1,108 @@
+package agenda
+
+import (
+	"sync"
+
+	"yoyaku/internal/models"
+)
+
+// TipoEvento identifica un evento de dominio. Se usa también como nombre del
+// evento de Wails con el que se reenvía al frontend.
+type TipoEvento string
+
+const (
+	EventoTurnoCreado         TipoEvento = "turno:creado"
+	EventoTurnoActualizado    TipoEvento = "turno:actualizado"
+	EventoTurnoEliminado      TipoEvento = "turno:eliminado"
+	EventoTurnoEstadoCambiado TipoEvento = "turno:estado"
+	EventoPacienteCreado      TipoEvento = "paciente:creado"
+	EventoPacienteActualizado TipoEvento = "paciente:actualizado"
+	EventoPacienteEliminado   TipoEvento = "paciente:eliminado"
+	EventoCierreCreado        TipoEvento = "cierre:creado"
+	EventoCierreEliminado     TipoEvento = "cierre:eliminado"
+)
+
+// Evento describe una modificación ya guardada. Fecha (AAAA-MM-DD) indica el
+// día de agenda afectado, para que cada vista decida si debe recargar; si un
+// turno cambió de día, FechaAnterior es el día que dejó.
+type Evento struct {
+	Tipo           TipoEvento         `json:"tipo"`
+	TurnoID        int64              `json:"turnoId,omitempty"`
+	PacienteID     int64              `json:"pacienteId,omitempty"`
+	Fecha          string             `json:"fecha,omitempty"`
+	FechaAnterior  string             `json:"fechaAnterior,omitempty"`
+	EstadoAnterior models.EstadoTurno `json:"estadoAnterior,omitempty"`
+	Estado         models.EstadoTurno `json:"estado,omitempty"`
+	Turno          *models.Turno      `json:"turno,omitempty"`
+	Paciente       *models.Paciente   `json:"paciente,omitempty"`
+	Cierre         *models.Cierre     `json:"cierre,omitempty"`
+}
+
+// Bus reparte los eventos de dominio entre los suscriptores. Publicar llama a
+// cada suscriptor en la goroutine de quien publica, así que los suscriptores
+// no deben bloquear.
+type Bus struct {
+	mu           sync.Mutex
+	suscriptores map[int]func(Evento)
+	siguiente    int
+}
+
+func NewBus() *Bus {
+	return &Bus{suscriptores: make(map[int]func(Evento))}
+}
+
+// Suscribir registra fn para recibir los eventos. La función devuelta
+// cancela la suscripción.
+func (b *Bus) Suscribir(fn func(Evento)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.siguiente
+	b.siguiente++
+	b.suscriptores[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.suscriptores, id)
+	}
+}
+
+func (b *Bus) Publicar(e Evento) {
+	b.mu.Lock()
+	fns := make([]func(Evento), 0, len(b.suscriptores))
+	for _, fn := range b.suscriptores {
+		fns = append(fns, fn)
+	}
+	b.mu.Unlock()
+
+	for _, fn := range fns {
+		fn(e)
+	}
+}
+
+func eventoTurno(tipo TipoEvento, turno *models.Turno) Evento {
+	return Evento{
+		Tipo:       tipo,
+		TurnoID:    turno.ID,
+		PacienteID: turno.PacienteID,
+		Fecha:      turno.Fecha.Format("2006-01-02"),
+		Estado:     turno.Estado,
+		Turno:      turno,
+	}
+}
+
+func eventoPaciente(tipo TipoEvento, paciente *models.Paciente) Evento {
+	return Evento{
+		Tipo:       tipo,
+		PacienteID: paciente.ID,
+		Paciente:   paciente,
+	}
+}
+
+func eventoCierre(tipo TipoEvento, cierre *models.Cierre) Evento {
+	return Evento{
+		Tipo:   tipo,
+		Cierre: cierre,
+	}
+}
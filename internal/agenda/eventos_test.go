@@ -0,0 +1,81 @@
+package agenda
+
+import (
+	"testing"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+func setupService(t *testing.T) *Service {
+	t.Helper()
+
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	s := NewService(db.NewTurnoRepo(database), db.NewPacienteRepo(database), db.NewCierreRepo(database), db.NewConfigRepo(database))
+	s.UsarReloj(func() time.Time { return ahoraPrueba })
+	return s
+}
+
+// ahoraPrueba es la hora fija de los tests: lunes 10/03/2025 a las 10:00 en
+// Buenos Aires, sin depender de la zona horaria de la máquina.
+var ahoraPrueba = time.Date(2025, 3, 10, 10, 0, 0, 0, time.FixedZone("ART", -3*60*60))
+
+func TestEventos(t *testing.T) {
+	s := setupService(t)
+
+	var eventos []Evento
+	cancelar := s.Eventos().Suscribir(func(e Evento) { eventos = append(eventos, e) })
+
+	paciente := &models.Paciente{Nombre: "María González"}
+	if err := s.CrearPaciente(paciente); err != nil {
+		t.Fatalf("CrearPaciente() failed: %v", err)
+	}
+
+	turno := &models.Turno{
+		PacienteID: paciente.ID,
+		Fecha:      time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Hora:       models.NuevaHora(10, 0),
+		Estado:     models.EstadoConfirmado,
+	}
+	if err := s.CrearTurno(turno); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+	if err := s.MarcarAusente(turno.ID); err != nil {
+		t.Fatalf("MarcarAusente() failed: %v", err)
+	}
+	if err := s.CambiarEstado(999, models.EstadoAtendido); err == nil {
+		t.Error("CambiarEstado() on a missing turno should fail")
+	}
+
+	want := []TipoEvento{EventoPacienteCreado, EventoTurnoCreado, EventoTurnoEstadoCambiado}
+	if len(eventos) != len(want) {
+		t.Fatalf("eventos = %+v, want %v", eventos, want)
+	}
+	for i, tipo := range want {
+		if eventos[i].Tipo != tipo {
+			t.Errorf("eventos[%d].Tipo = %v, want %v", i, eventos[i].Tipo, tipo)
+		}
+	}
+
+	estado := eventos[2]
+	if estado.TurnoID != turno.ID || estado.Fecha != "2025-03-10" {
+		t.Errorf("evento de estado = %+v, want turno %d del 2025-03-10", estado, turno.ID)
+	}
+	if estado.EstadoAnterior != models.EstadoConfirmado || estado.Estado != models.EstadoAusente {
+		t.Errorf("evento de estado %v -> %v, want confirmado -> ausente", estado.EstadoAnterior, estado.Estado)
+	}
+
+	cancelar()
+	if err := s.EliminarTurno(turno.ID); err != nil {
+		t.Fatalf("EliminarTurno() failed: %v", err)
+	}
+	if len(eventos) != len(want) {
+		t.Errorf("se recibieron eventos después de cancelar la suscripción")
+	}
+}
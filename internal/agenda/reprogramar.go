@@ -0,0 +1,309 @@
+package agenda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yoyaku/internal/models"
+	"yoyaku/internal/telefono"
+)
+
+const (
+	// pasoReprogramacion es cada cuántos minutos se prueba un horario al
+	// buscar lugar para un turno.
+	pasoReprogramacion = 15
+	// maxDiasReprogramacion es hasta cuántos días hacia adelante se busca
+	// lugar con ReprogramarProximosLibres.
+	maxDiasReprogramacion = 60
+)
+
+// ReprogramarTurnos propone un nuevo horario para cada turno pendiente o
+// confirmado del rango, dentro del horario laboral y sin superponerse con
+// otros turnos del profesional ni caer en días cerrados. Salvo que se pida
+// simular, aplica el plan en una transacción, deja los turnos pendientes de
+// confirmar y encola un mensaje de aviso para cada paciente con teléfono si
+// la licencia incluye los mensajes.
+func (s *Service) ReprogramarTurnos(solicitud models.SolicitudReprogramacion) (*models.PlanReprogramacion, error) {
+	plan, err := s.planificarReprogramacion(solicitud)
+	if err != nil || solicitud.Simular || len(plan.Cambios) == 0 {
+		return plan, err
+	}
+
+	mensajes, err := s.mensajesReprogramacion(plan.Cambios)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.turnoRepo.Reprogramar(plan.Cambios, mensajes); err != nil {
+		return nil, err
+	}
+	plan.Aplicado = true
+	plan.MensajesEncolados = len(mensajes)
+
+	for _, cambio := range plan.Cambios {
+		turno := cambio.Turno
+		turno.Fecha, turno.Hora, turno.Estado = cambio.Fecha, cambio.Hora, models.EstadoPendiente
+		evento := eventoTurno(EventoTurnoActualizado, &turno)
+		evento.FechaAnterior = cambio.Turno.Fecha.Format("2006-01-02")
+		s.eventos.Publicar(evento)
+	}
+	return plan, nil
+}
+
+func (s *Service) planificarReprogramacion(solicitud models.SolicitudReprogramacion) (*models.PlanReprogramacion, error) {
+	if solicitud.Desde.IsZero() {
+		return nil, fmt.Errorf("falta la fecha de inicio del rango a reprogramar")
+	}
+	if solicitud.Hasta.IsZero() {
+		solicitud.Hasta = solicitud.Desde
+	}
+	desde, hasta := dia(solicitud.Desde), dia(solicitud.Hasta)
+	if hasta.Before(desde) {
+		return nil, fmt.Errorf("la fecha de fin es anterior a la de inicio")
+	}
+	enRango := func(d time.Time) bool {
+		return !d.Before(desde) && !d.After(hasta)
+	}
+
+	// Los turnos no se mueven a días que ya pasaron.
+	hoy := dia(s.reloj())
+	var destino time.Time
+	switch solicitud.Estrategia {
+	case models.ReprogramarAlDia:
+		if solicitud.FechaDestino.IsZero() {
+			return nil, fmt.Errorf("falta la fecha destino")
+		}
+		destino = dia(solicitud.FechaDestino)
+		if destino.Before(hoy) {
+			return nil, fmt.Errorf("la fecha destino ya pasó")
+		}
+	case models.ReprogramarProximosLibres:
+		destino = hasta.AddDate(0, 0, 1)
+		if !solicitud.FechaDestino.IsZero() {
+			destino = dia(solicitud.FechaDestino)
+		}
+		if destino.Before(hoy) {
+			destino = hoy
+		}
+	default:
+		return nil, fmt.Errorf("estrategia de reprogramación inválida: %q", solicitud.Estrategia)
+	}
+	if enRango(destino) {
+		return nil, fmt.Errorf("la fecha destino está dentro del rango a reprogramar")
+	}
+
+	horario, err := s.configRepo.ObtenerHorarioLaboral()
+	if err != nil {
+		return nil, err
+	}
+
+	turnos, err := s.listarTurnos(solicitud.ProfesionalID, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	var mover []models.Turno
+	for _, turno := range turnos {
+		if turno.Estado == models.EstadoPendiente || turno.Estado == models.EstadoConfirmado {
+			mover = append(mover, turno)
+		}
+	}
+	sort.SliceStable(mover, func(i, j int) bool {
+		if !mover[i].Fecha.Equal(mover[j].Fecha) {
+			return mover[i].Fecha.Before(mover[j].Fecha)
+		}
+		return mover[i].Hora < mover[j].Hora
+	})
+
+	ocupacion := &ocupacion{service: s, horario: *horario, ahora: s.reloj(), dias: map[claveDia]*diaOcupado{}, excluidos: map[int64]bool{}}
+	for _, turno := range mover {
+		ocupacion.excluidos[turno.ID] = true
+	}
+
+	plan := &models.PlanReprogramacion{Cambios: []models.CambioTurno{}, SinLugar: []models.Turno{}}
+	for _, turno := range mover {
+		duracion := int(duracionEstimada(turno, 1) / time.Minute)
+
+		var fecha time.Time
+		var hora models.Hora
+		encontrado := false
+		if solicitud.Estrategia == models.ReprogramarAlDia {
+			fecha = destino
+			hora, encontrado, err = ocupacion.buscar(turno.ProfesionalID, destino, turno.Hora, duracion)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			for i := 0; i < maxDiasReprogramacion && !encontrado; i++ {
+				fecha = destino.AddDate(0, 0, i)
+				if enRango(fecha) {
+					continue
+				}
+				hora, encontrado, err = ocupacion.buscar(turno.ProfesionalID, fecha, -1, duracion)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if !encontrado {
+			plan.SinLugar = append(plan.SinLugar, turno)
+			continue
+		}
+		ocupacion.reservar(turno.ProfesionalID, fecha, hora, duracion)
+		plan.Cambios = append(plan.Cambios, models.CambioTurno{Turno: turno, Fecha: fecha, Hora: hora})
+	}
+	return plan, nil
+}
+
+// mensajesReprogramacion arma el aviso de cada cambio con la plantilla de la
+// configuración. Los pacientes sin teléfono no reciben mensaje.
+func (s *Service) mensajesReprogramacion(cambios []models.CambioTurno) ([]*models.Mensaje, error) {
+	if s.VerificarFeature(models.FeatureMensajeria) != nil {
+		return nil, nil
+	}
+	config, err := s.configRepo.Obtener()
+	if err != nil {
+		return nil, err
+	}
+
+	var mensajes []*models.Mensaje
+	for _, cambio := range cambios {
+		paciente := cambio.Turno.Paciente
+		if paciente == nil || telefono.Normalizar(paciente.Telefono) == "" {
+			continue
+		}
+		nombre, _, _ := strings.Cut(strings.TrimSpace(paciente.Nombre), " ")
+		texto := strings.NewReplacer(
+			"{nombre}", nombre,
+			"{fecha_anterior}", cambio.Turno.Fecha.Format("02/01/2006"),
+			"{hora_anterior}", cambio.Turno.Hora.String(),
+			"{fecha}", cambio.Fecha.Format("02/01/2006"),
+			"{hora}", cambio.Hora.String(),
+		).Replace(config.MensajeReprogramacion)
+
+		mensajes = append(mensajes, &models.Mensaje{
+			PacienteID: paciente.ID,
+			TurnoID:    cambio.Turno.ID,
+			Telefono:   telefono.Normalizar(paciente.Telefono),
+			Texto:      texto,
+		})
+	}
+	return mensajes, nil
+}
+
+// listarTurnos devuelve los turnos del profesional en el rango, o los de
+// todos si profesionalID es cero.
+func (s *Service) listarTurnos(profesionalID int64, desde, hasta time.Time) ([]models.Turno, error) {
+	if profesionalID == 0 {
+		return s.turnoRepo.ListarPorRango(desde, hasta)
+	}
+	return s.turnoRepo.ListarPorProfesional(profesionalID, desde, hasta)
+}
+
+type claveDia struct {
+	profesionalID int64
+	fecha         time.Time
+}
+
+// diaOcupado son los intervalos ya tomados de un día, en minutos desde la
+// medianoche.
+type diaOcupado struct {
+	abierto    bool
+	intervalos [][2]int
+}
+
+// ocupacion lleva la agenda de los días candidatos mientras se arma un plan,
+// incluyendo los lugares ya asignados en el mismo plan.
+type ocupacion struct {
+	service   *Service
+	horario   models.HorarioLaboral
+	ahora     time.Time
+	dias      map[claveDia]*diaOcupado
+	excluidos map[int64]bool
+}
+
+// buscar devuelve un horario libre para un turno de la duración indicada:
+// preferida si está libre o, si no, el primero del día. Hoy sólo se
+// consideran los horarios que todavía no pasaron.
+func (o *ocupacion) buscar(profesionalID int64, fecha time.Time, preferida models.Hora, duracion int) (models.Hora, bool, error) {
+	d, err := o.dia(profesionalID, fecha)
+	if err != nil || !d.abierto {
+		return 0, false, err
+	}
+
+	minimo := int(o.horario.Apertura)
+	if fecha.Equal(dia(o.ahora)) {
+		minimo = max(minimo, proximoPaso(o.ahora))
+	}
+	libre := func(inicio int) bool {
+		if inicio < minimo || inicio+duracion > int(o.horario.Cierre) {
+			return false
+		}
+		for _, intervalo := range d.intervalos {
+			if inicio < intervalo[1] && intervalo[0] < inicio+duracion {
+				return false
+			}
+		}
+		return true
+	}
+
+	if preferida.Valida() && libre(int(preferida)) {
+		return preferida, true, nil
+	}
+	for inicio := minimo; inicio+duracion <= int(o.horario.Cierre); inicio += pasoReprogramacion {
+		if libre(inicio) {
+			return models.Hora(inicio), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// proximoPaso devuelve el primer horario, en minutos desde la medianoche,
+// múltiplo de pasoReprogramacion que no es anterior a t.
+func proximoPaso(t time.Time) int {
+	minutos := t.Hour()*60 + t.Minute()
+	if t.Second() > 0 || t.Nanosecond() > 0 {
+		minutos++
+	}
+	return (minutos + pasoReprogramacion - 1) / pasoReprogramacion * pasoReprogramacion
+}
+
+func (o *ocupacion) reservar(profesionalID int64, fecha time.Time, hora models.Hora, duracion int) {
+	d := o.dias[claveDia{profesionalID, fecha}]
+	d.intervalos = append(d.intervalos, [2]int{int(hora), int(hora) + duracion})
+}
+
+func (o *ocupacion) dia(profesionalID int64, fecha time.Time) (*diaOcupado, error) {
+	clave := claveDia{profesionalID, fecha}
+	if d, ok := o.dias[clave]; ok {
+		return d, nil
+	}
+
+	d := &diaOcupado{}
+	o.dias[clave] = d
+	if !o.horario.Atiende(fecha) {
+		return d, nil
+	}
+	cerrados, err := o.service.DiasCerrados(profesionalID, fecha, fecha)
+	if err != nil {
+		return nil, err
+	}
+	if len(cerrados) > 0 {
+		return d, nil
+	}
+	d.abierto = true
+
+	turnos, err := o.service.turnoRepo.ListarPorProfesional(profesionalID, fecha, fecha)
+	if err != nil {
+		return nil, err
+	}
+	for _, turno := range turnos {
+		if o.excluidos[turno.ID] || turno.Estado == models.EstadoCancelado || turno.Estado == models.EstadoAusente {
+			continue
+		}
+		inicio := int(turno.Hora)
+		d.intervalos = append(d.intervalos, [2]int{inicio, inicio + int(duracionEstimada(turno, 1)/time.Minute)})
+	}
+	return d, nil
+}
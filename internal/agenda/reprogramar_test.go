@@ -0,0 +1,202 @@
+package agenda
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+func TestReprogramarTurnos_AlDia(t *testing.T) {
+	s := setupService(t)
+
+	primero := crearTurno(t, s, models.NuevaHora(9, 0))
+	segundo := crearTurno(t, s, models.NuevaHora(9, 30))
+	cancelado := crearTurno(t, s, models.NuevaHora(10, 0))
+	if err := s.MarcarCancelado(cancelado.ID); err != nil {
+		t.Fatalf("MarcarCancelado() failed: %v", err)
+	}
+	paciente, err := s.pacienteRepo.ObtenerPorID(primero.PacienteID)
+	if err != nil {
+		t.Fatalf("ObtenerPorID() failed: %v", err)
+	}
+	paciente.Telefono = "+54 9 11 1234-5678"
+	if err := s.ActualizarPaciente(paciente); err != nil {
+		t.Fatalf("ActualizarPaciente() failed: %v", err)
+	}
+
+	// El martes ya hay un turno a las 9:30 que el segundo no puede pisar.
+	martes := fechaPrueba.AddDate(0, 0, 1)
+	ocupado := &models.Turno{PacienteID: cancelado.PacienteID, Fecha: martes, Hora: models.NuevaHora(9, 30), Duracion: 30}
+	if err := s.CrearTurno(ocupado); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+
+	solicitud := models.SolicitudReprogramacion{
+		Desde:        fechaPrueba,
+		Estrategia:   models.ReprogramarAlDia,
+		FechaDestino: martes,
+		Simular:      true,
+	}
+	plan, err := s.ReprogramarTurnos(solicitud)
+	if err != nil {
+		t.Fatalf("ReprogramarTurnos() failed: %v", err)
+	}
+	if plan.Aplicado || len(plan.Cambios) != 2 || len(plan.SinLugar) != 0 {
+		t.Fatalf("plan = %+v, want 2 cambios sin aplicar", plan)
+	}
+	want := map[int64]models.Hora{primero.ID: models.NuevaHora(9, 0), segundo.ID: models.NuevaHora(10, 0)}
+	for _, cambio := range plan.Cambios {
+		if !cambio.Fecha.Equal(martes) || cambio.Hora != want[cambio.Turno.ID] {
+			t.Errorf("turno %d -> %s %s, want %s %s", cambio.Turno.ID, cambio.Fecha.Format("2006-01-02"), cambio.Hora, martes.Format("2006-01-02"), want[cambio.Turno.ID])
+		}
+	}
+	if turno, _ := s.turnoRepo.ObtenerPorID(primero.ID); !turno.Fecha.Equal(fechaPrueba) {
+		t.Error("simular modificó el turno")
+	}
+
+	solicitud.Simular = false
+	plan, err = s.ReprogramarTurnos(solicitud)
+	if err != nil {
+		t.Fatalf("ReprogramarTurnos() failed: %v", err)
+	}
+	if !plan.Aplicado || plan.MensajesEncolados != 1 {
+		t.Errorf("plan = %+v, want aplicado con 1 mensaje", plan)
+	}
+
+	turno, err := s.turnoRepo.ObtenerPorID(segundo.ID)
+	if err != nil {
+		t.Fatalf("ObtenerPorID() failed: %v", err)
+	}
+	if !turno.Fecha.Equal(martes) || turno.Hora != models.NuevaHora(10, 0) || turno.Estado != models.EstadoPendiente {
+		t.Errorf("turno reprogramado = %s %s %s, want martes 10:00 pendiente", turno.Fecha.Format("2006-01-02"), turno.Hora, turno.Estado)
+	}
+	if turno, _ := s.turnoRepo.ObtenerPorID(cancelado.ID); !turno.Fecha.Equal(fechaPrueba) {
+		t.Error("se reprogramó un turno cancelado")
+	}
+}
+
+func TestReprogramarTurnos_ProximosLibres(t *testing.T) {
+	s := setupService(t)
+
+	// Viernes 21/03/2025: el sábado y el domingo no se atiende y el lunes 24
+	// es feriado, así que el primer lugar es el martes.
+	viernes := time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC)
+	paciente := &models.Paciente{Nombre: "Ana López"}
+	if err := s.CrearPaciente(paciente); err != nil {
+		t.Fatalf("CrearPaciente() failed: %v", err)
+	}
+	turno := &models.Turno{PacienteID: paciente.ID, Fecha: viernes, Hora: models.NuevaHora(16, 0), Duracion: 30, Estado: models.EstadoPendiente}
+	if err := s.CrearTurno(turno); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+
+	plan, err := s.ReprogramarTurnos(models.SolicitudReprogramacion{
+		Desde:      viernes,
+		Estrategia: models.ReprogramarProximosLibres,
+		Simular:    true,
+	})
+	if err != nil {
+		t.Fatalf("ReprogramarTurnos() failed: %v", err)
+	}
+	if len(plan.Cambios) != 1 {
+		t.Fatalf("plan = %+v, want 1 cambio", plan)
+	}
+	cambio := plan.Cambios[0]
+	if cambio.Fecha.Format("2006-01-02") != "2025-03-25" || cambio.Hora != models.NuevaHora(9, 0) {
+		t.Errorf("cambio = %s %s, want 2025-03-25 09:00", cambio.Fecha.Format("2006-01-02"), cambio.Hora)
+	}
+}
+
+func TestReprogramarTurnos_SinLugar(t *testing.T) {
+	s := setupService(t)
+	turno := crearTurno(t, s, models.NuevaHora(9, 0))
+
+	plan, err := s.ReprogramarTurnos(models.SolicitudReprogramacion{
+		Desde:        fechaPrueba,
+		Estrategia:   models.ReprogramarAlDia,
+		FechaDestino: time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("ReprogramarTurnos() failed: %v", err)
+	}
+	if plan.Aplicado || len(plan.SinLugar) != 1 || plan.SinLugar[0].ID != turno.ID {
+		t.Errorf("plan = %+v, want el turno sin lugar", plan)
+	}
+
+	_, err = s.ReprogramarTurnos(models.SolicitudReprogramacion{
+		Desde:        fechaPrueba,
+		Estrategia:   models.ReprogramarAlDia,
+		FechaDestino: fechaPrueba,
+	})
+	if err == nil || !strings.Contains(err.Error(), "dentro del rango") {
+		t.Errorf("destino dentro del rango error = %v", err)
+	}
+
+	_, err = s.ReprogramarTurnos(models.SolicitudReprogramacion{
+		Desde:        fechaPrueba,
+		Estrategia:   models.ReprogramarAlDia,
+		FechaDestino: fechaPrueba.AddDate(0, 0, -7),
+	})
+	if err == nil || !strings.Contains(err.Error(), "ya pasó") {
+		t.Errorf("destino pasado error = %v", err)
+	}
+}
+
+func TestReprogramarTurnos_ProximosLibresDesdeHoy(t *testing.T) {
+	s := setupService(t)
+
+	// Reprogramar un día que ya pasó busca lugar a partir de hoy.
+	anterior := fechaPrueba.AddDate(0, 0, -4)
+	paciente := &models.Paciente{Nombre: "Ana López"}
+	if err := s.CrearPaciente(paciente); err != nil {
+		t.Fatalf("CrearPaciente() failed: %v", err)
+	}
+	turno := &models.Turno{PacienteID: paciente.ID, Fecha: anterior, Hora: models.NuevaHora(16, 0), Duracion: 30, Estado: models.EstadoPendiente}
+	if err := s.CrearTurno(turno); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+
+	plan, err := s.ReprogramarTurnos(models.SolicitudReprogramacion{
+		Desde:      anterior,
+		Estrategia: models.ReprogramarProximosLibres,
+		Simular:    true,
+	})
+	if err != nil {
+		t.Fatalf("ReprogramarTurnos() failed: %v", err)
+	}
+	// Hoy ya son las 10:00, así que el primer lugar no es a la apertura.
+	if len(plan.Cambios) != 1 || !plan.Cambios[0].Fecha.Equal(fechaPrueba) || plan.Cambios[0].Hora != models.NuevaHora(10, 0) {
+		t.Errorf("plan = %+v, want el turno movido al %s 10:00", plan, fechaPrueba.Format("2006-01-02"))
+	}
+}
+
+func TestReprogramarTurnos_AlDiaHoy(t *testing.T) {
+	s := setupService(t)
+	s.UsarReloj(func() time.Time { return ahoraPrueba.Add(5 * time.Minute) })
+
+	// A las 10:05 el turno de las 9:00 no puede quedar a las 9:00 de hoy.
+	martes := fechaPrueba.AddDate(0, 0, 1)
+	paciente := &models.Paciente{Nombre: "Ana López"}
+	if err := s.CrearPaciente(paciente); err != nil {
+		t.Fatalf("CrearPaciente() failed: %v", err)
+	}
+	turno := &models.Turno{PacienteID: paciente.ID, Fecha: martes, Hora: models.NuevaHora(9, 0), Duracion: 30, Estado: models.EstadoPendiente}
+	if err := s.CrearTurno(turno); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+
+	plan, err := s.ReprogramarTurnos(models.SolicitudReprogramacion{
+		Desde:        martes,
+		Estrategia:   models.ReprogramarAlDia,
+		FechaDestino: fechaPrueba,
+		Simular:      true,
+	})
+	if err != nil {
+		t.Fatalf("ReprogramarTurnos() failed: %v", err)
+	}
+	if len(plan.Cambios) != 1 || plan.Cambios[0].Hora != models.NuevaHora(10, 15) {
+		t.Errorf("plan = %+v, want el turno movido a las 10:15", plan)
+	}
+}
@@ -2,22 +2,77 @@ package agenda
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"yoyaku/internal/db"
 	"yoyaku/internal/models"
 )
 
+// Reloj devuelve la hora actual. Los cálculos de la agenda lo usan en lugar
+// de time.Now para poder probarse con una hora fija.
+type Reloj func() time.Time
+
 type Service struct {
 	turnoRepo    *db.TurnoRepo
 	pacienteRepo *db.PacienteRepo
+	cierreRepo   *db.CierreRepo
+	configRepo   *db.ConfigRepo
+	eventos      *Bus
+	reloj        Reloj
+	tieneFeature func(models.Feature) bool
 }
 
-func NewService(turnoRepo *db.TurnoRepo, pacienteRepo *db.PacienteRepo) *Service {
+func NewService(turnoRepo *db.TurnoRepo, pacienteRepo *db.PacienteRepo, cierreRepo *db.CierreRepo, configRepo *db.ConfigRepo) *Service {
 	return &Service{
 		turnoRepo:    turnoRepo,
 		pacienteRepo: pacienteRepo,
+		cierreRepo:   cierreRepo,
+		configRepo:   configRepo,
+		eventos:      NewBus(),
+		reloj:        time.Now,
+	}
+}
+
+// UsarReloj reemplaza el reloj del servicio. Los horarios de los turnos se
+// interpretan en la zona horaria de la hora que devuelve.
+func (s *Service) UsarReloj(reloj Reloj) {
+	s.reloj = reloj
+}
+
+// UsarLicencia indica qué funciones habilita la licencia. Hasta que se
+// llama, el servicio las habilita todas.
+func (s *Service) UsarLicencia(tieneFeature func(models.Feature) bool) {
+	s.tieneFeature = tieneFeature
+}
+
+// VerificarFeature devuelve un *models.FeatureNoIncluidaError si la licencia
+// no habilita f.
+func (s *Service) VerificarFeature(f models.Feature) error {
+	if s.tieneFeature != nil && !s.tieneFeature(f) {
+		return &models.FeatureNoIncluidaError{Feature: f}
 	}
+	return nil
+}
+
+// verificarProfesional controla que la licencia permita dar turnos a otro
+// profesional que el principal.
+func (s *Service) verificarProfesional(profesionalID int64) error {
+	if profesionalID == 0 || profesionalID == models.ProfesionalPrincipalID {
+		return nil
+	}
+	return s.VerificarFeature(models.FeatureMultiProfesional)
+}
+
+// Ahora devuelve la hora actual según el reloj del servicio.
+func (s *Service) Ahora() time.Time {
+	return s.reloj()
+}
+
+// Eventos devuelve el bus en el que se publican las modificaciones hechas a
+// través del servicio.
+func (s *Service) Eventos() *Bus {
+	return s.eventos
 }
 
 func (s *Service) ObtenerAgendaDelDia(fecha time.Time) (*models.AgendaDia, error) {
@@ -36,108 +91,301 @@ func (s *Service) ObtenerAgendaDelDia(fecha time.Time) (*models.AgendaDia, error
 		turnosConRiesgo[i] = turno
 	}
 
-	atraso := s.CalcularAtraso(turnosConRiesgo)
+	proyeccion := s.CalcularAtraso(turnosConRiesgo)
+	for _, estimacion := range proyeccion.Estimaciones {
+		for i := range turnosConRiesgo {
+			if turnosConRiesgo[i].ID == estimacion.TurnoID {
+				inicio := estimacion.InicioEstimado
+				turnosConRiesgo[i].InicioEstimado = &inicio
+				turnosConRiesgo[i].DemoraEstimada = estimacion.DemoraMinutos
+			}
+		}
+	}
 	pendientes := s.contarPendientes(turnosConRiesgo)
 
 	return &models.AgendaDia{
 		Fecha:            fecha.Format("2006-01-02"),
 		Turnos:           turnosConRiesgo,
-		AtrasoMinutos:    atraso,
+		AtrasoMinutos:    proyeccion.AtrasoMinutos,
 		TotalTurnos:      len(turnosConRiesgo),
 		TurnosPendientes: pendientes,
 	}, nil
 }
 
-func (s *Service) CalcularAtraso(turnos []models.Turno) int {
-	if len(turnos) == 0 {
-		return 0
+// SalaDeEspera devuelve los pacientes que llegaron y esperan ser atendidos
+// en el día indicado, en el orden en que corresponde llamarlos.
+func (s *Service) SalaDeEspera(fecha time.Time) ([]models.Turno, error) {
+	turnos, err := s.turnoRepo.ListarPorFecha(fecha)
+	if err != nil {
+		return nil, err
 	}
 
-	now := time.Now()
-	horaActual := now.Hour()*60 + now.Minute()
-
-	var atrasoTotal int
-	var turnosAtendidosOEnCurso int
-
+	cola := []models.Turno{}
 	for _, turno := range turnos {
-		horaTurno := s.parseHora(turno.Hora)
-
-		switch turno.Estado {
-		case models.EstadoAtendido:
-			turnosAtendidosOEnCurso++
-			if horaTurno < horaActual {
-				diferencia := horaActual - horaTurno
-				if diferencia > atrasoTotal {
-					atrasoTotal = diferencia
-				}
-			}
-		case models.EstadoConfirmado, models.EstadoPendiente:
-			if horaTurno < horaActual {
-				diferencia := horaActual - horaTurno
-				if diferencia > atrasoTotal {
-					atrasoTotal = diferencia
-				}
-			}
+		if turno.Estado == models.EstadoEnEspera {
+			cola = append(cola, turno)
 		}
 	}
 
-	return atrasoTotal
+	s.ordenarParaLlamar(cola)
+	return cola, nil
 }
 
+// ObtenerProximoTurno devuelve el próximo turno a atender: el primero de la
+// sala de espera o, si no hay nadie esperando, el siguiente turno pendiente
+// que todavía puede llegar. Devuelve nil si no queda ninguno.
 func (s *Service) ObtenerProximoTurno(turnos []models.Turno) *models.Turno {
-	now := time.Now()
-	horaActual := now.Hour()*60 + now.Minute()
+	ahora := s.reloj()
 
+	var enEspera, porLlegar []models.Turno
 	for _, turno := range turnos {
-		if turno.Estado == models.EstadoConfirmado || turno.Estado == models.EstadoPendiente {
-			horaTurno := s.parseHora(turno.Hora)
-			if horaTurno >= horaActual-30 {
-				return &turno
+		switch turno.Estado {
+		case models.EstadoEnEspera:
+			enEspera = append(enEspera, turno)
+		case models.EstadoPendiente, models.EstadoConfirmado:
+			if ahora.Sub(s.horarioProgramado(turno)) <= toleranciaLlegada {
+				porLlegar = append(porLlegar, turno)
 			}
 		}
 	}
 
+	for _, cola := range [][]models.Turno{enEspera, porLlegar} {
+		if len(cola) > 0 {
+			s.ordenarParaLlamar(cola)
+			return &cola[0]
+		}
+	}
 	return nil
 }
 
-func (s *Service) MarcarAtendido(turnoID int64) error {
-	return s.turnoRepo.ActualizarEstado(turnoID, models.EstadoAtendido)
+// ObtenerTurnoActual devuelve el turno que está en consulta, o nil si el
+// consultorio está libre. Si hubiera más de uno se toma el último iniciado.
+func (s *Service) ObtenerTurnoActual(turnos []models.Turno) *models.Turno {
+	var actual *models.Turno
+	for i, turno := range turnos {
+		if turno.Estado != models.EstadoEnConsulta {
+			continue
+		}
+		if actual == nil || (turno.HoraInicio != nil && (actual.HoraInicio == nil || turno.HoraInicio.After(*actual.HoraInicio))) {
+			actual = &turnos[i]
+		}
+	}
+	if actual == nil {
+		return nil
+	}
+	copia := *actual
+	return &copia
 }
 
-func (s *Service) MarcarAusente(turnoID int64) error {
-	turno, err := s.turnoRepo.ObtenerPorID(turnoID)
+// CrearTurno guarda el turno salvo que caiga en un feriado o en un cierre
+// del profesional, en cuyo caso devuelve un *DiaCerradoError.
+func (s *Service) CrearTurno(turno *models.Turno) error {
+	if err := s.verificarProfesional(turno.ProfesionalID); err != nil {
+		return err
+	}
+	if err := s.verificarDiaAbierto(turno.ProfesionalID, turno.Fecha); err != nil {
+		return err
+	}
+	if err := s.turnoRepo.Crear(turno); err != nil {
+		return err
+	}
+	s.eventos.Publicar(eventoTurno(EventoTurnoCreado, turno))
+	return nil
+}
+
+// ActualizarTurno guarda los cambios del turno. Sólo se controla que el día
+// esté abierto si el turno cambia de fecha o de profesional, para poder
+// seguir editando los turnos que quedaron dentro de un cierre. Un cambio de
+// estado se aplica con CambiarEstado para respetar sus reglas.
+func (s *Service) ActualizarTurno(turno *models.Turno) error {
+	anterior, err := s.obtenerTurno(turno.ID)
 	if err != nil {
 		return err
 	}
-	if turno == nil {
-		return nil
+	if anterior.ProfesionalID != turno.ProfesionalID {
+		if err := s.verificarProfesional(turno.ProfesionalID); err != nil {
+			return err
+		}
+	}
+	if !dia(anterior.Fecha).Equal(dia(turno.Fecha)) || anterior.ProfesionalID != turno.ProfesionalID {
+		if err := s.verificarDiaAbierto(turno.ProfesionalID, turno.Fecha); err != nil {
+			return err
+		}
+	}
+	if err := s.turnoRepo.Actualizar(turno); err != nil {
+		return err
+	}
+	evento := eventoTurno(EventoTurnoActualizado, turno)
+	if !dia(anterior.Fecha).Equal(dia(turno.Fecha)) {
+		evento.FechaAnterior = anterior.Fecha.Format("2006-01-02")
+	}
+	s.eventos.Publicar(evento)
+
+	if turno.Estado != "" && turno.Estado != anterior.Estado {
+		return s.CambiarEstado(turno.ID, turno.Estado)
+	}
+	return nil
+}
+
+func (s *Service) EliminarTurno(turnoID int64) error {
+	turno, err := s.obtenerTurno(turnoID)
+	if err != nil {
+		return err
+	}
+	if err := s.turnoRepo.Eliminar(turnoID); err != nil {
+		return err
+	}
+	s.eventos.Publicar(eventoTurno(EventoTurnoEliminado, turno))
+	return nil
+}
+
+// CambiarEstado aplica el cambio de estado con las reglas de cada estado:
+// registra el no-show al pasar a ausente, una sola vez aunque se vuelva a
+// marcar, y los horarios reales de llegada, inicio y fin de la consulta al
+// pasar por la sala de espera.
+func (s *Service) CambiarEstado(turnoID int64, estado models.EstadoTurno) error {
+	turno, err := s.obtenerTurno(turnoID)
+	if err != nil {
+		return err
 	}
+	anterior := turno.Estado
 
-	if err := s.turnoRepo.ActualizarEstado(turnoID, models.EstadoAusente); err != nil {
+	ahora := s.reloj()
+	registrarAsistencia(turno, estado, ahora)
+	if estado == models.EstadoAusente {
+		err = s.turnoRepo.MarcarAusente(turno, ahora)
+	} else {
+		err = s.turnoRepo.ActualizarAsistencia(turno)
+	}
+	if err != nil {
 		return err
 	}
 
-	return s.pacienteRepo.RegistrarNoShow(turno.PacienteID, turnoID, time.Now())
+	evento := eventoTurno(EventoTurnoEstadoCambiado, turno)
+	evento.EstadoAnterior = anterior
+	s.eventos.Publicar(evento)
+	return nil
+}
+
+// registrarAsistencia actualiza el estado y los horarios del turno. Volver a
+// pendiente o confirmado deshace la llegada.
+func registrarAsistencia(turno *models.Turno, estado models.EstadoTurno, ahora time.Time) {
+	switch estado {
+	case models.EstadoEnEspera:
+		if turno.HoraLlegada == nil {
+			turno.HoraLlegada = &ahora
+		}
+		turno.HoraInicio = nil
+		turno.HoraFin = nil
+	case models.EstadoEnConsulta:
+		if turno.HoraLlegada == nil {
+			turno.HoraLlegada = &ahora
+		}
+		if turno.HoraInicio == nil {
+			turno.HoraInicio = &ahora
+		}
+		turno.HoraFin = nil
+	case models.EstadoAtendido:
+		if turno.HoraInicio != nil && turno.HoraFin == nil {
+			turno.HoraFin = &ahora
+		}
+	case models.EstadoPendiente, models.EstadoConfirmado:
+		turno.HoraLlegada = nil
+		turno.HoraInicio = nil
+		turno.HoraFin = nil
+	}
+	turno.Estado = estado
+}
+
+// MarcarLlegada registra que el paciente llegó y está en la sala de espera.
+func (s *Service) MarcarLlegada(turnoID int64) error {
+	return s.CambiarEstado(turnoID, models.EstadoEnEspera)
+}
+
+// IniciarConsulta registra que el paciente pasó al consultorio.
+func (s *Service) IniciarConsulta(turnoID int64) error {
+	return s.CambiarEstado(turnoID, models.EstadoEnConsulta)
+}
+
+func (s *Service) MarcarAtendido(turnoID int64) error {
+	return s.CambiarEstado(turnoID, models.EstadoAtendido)
+}
+
+func (s *Service) MarcarAusente(turnoID int64) error {
+	return s.CambiarEstado(turnoID, models.EstadoAusente)
 }
 
 func (s *Service) MarcarCancelado(turnoID int64) error {
-	return s.turnoRepo.ActualizarEstado(turnoID, models.EstadoCancelado)
+	return s.CambiarEstado(turnoID, models.EstadoCancelado)
 }
 
 func (s *Service) ConfirmarTurno(turnoID int64) error {
-	return s.turnoRepo.ActualizarEstado(turnoID, models.EstadoConfirmado)
+	return s.CambiarEstado(turnoID, models.EstadoConfirmado)
+}
+
+func (s *Service) CrearPaciente(paciente *models.Paciente) error {
+	if err := s.pacienteRepo.Crear(paciente); err != nil {
+		return err
+	}
+	s.eventos.Publicar(eventoPaciente(EventoPacienteCreado, paciente))
+	return nil
+}
+
+func (s *Service) ActualizarPaciente(paciente *models.Paciente) error {
+	if err := s.pacienteRepo.Actualizar(paciente); err != nil {
+		return err
+	}
+	s.eventos.Publicar(eventoPaciente(EventoPacienteActualizado, paciente))
+	return nil
+}
+
+func (s *Service) EliminarPaciente(pacienteID int64) error {
+	if err := s.pacienteRepo.Eliminar(pacienteID); err != nil {
+		return err
+	}
+	s.eventos.Publicar(Evento{Tipo: EventoPacienteEliminado, PacienteID: pacienteID})
+	return nil
 }
 
-func (s *Service) parseHora(horaStr string) int {
-	var hora, minuto int
-	fmt.Sscanf(horaStr, "%d:%d", &hora, &minuto)
-	return hora*60 + minuto
+func (s *Service) obtenerTurno(turnoID int64) (*models.Turno, error) {
+	turno, err := s.turnoRepo.ObtenerPorID(turnoID)
+	if err != nil {
+		return nil, err
+	}
+	if turno == nil {
+		return nil, fmt.Errorf("turno %d inexistente", turnoID)
+	}
+	return turno, nil
+}
+
+// ordenarParaLlamar ordena los turnos por el momento desde el que el paciente
+// puede ser atendido: quien llega antes de hora no adelanta a los turnos
+// anteriores, y quien llega tarde pasa detrás de los que ya estaban
+// esperando.
+func (s *Service) ordenarParaLlamar(turnos []models.Turno) {
+	orden := func(turno models.Turno) time.Time {
+		programado := s.horarioProgramado(turno)
+		if turno.HoraLlegada != nil && turno.HoraLlegada.After(programado) {
+			return *turno.HoraLlegada
+		}
+		return programado
+	}
+	sort.SliceStable(turnos, func(i, j int) bool {
+		return orden(turnos[i]).Before(orden(turnos[j]))
+	})
+}
+
+// horarioProgramado devuelve el momento en que el turno debía empezar, en la
+// zona horaria del reloj.
+func (s *Service) horarioProgramado(turno models.Turno) time.Time {
+	return turno.Hora.EnFecha(turno.Fecha, s.reloj().Location())
 }
 
 func (s *Service) contarPendientes(turnos []models.Turno) int {
 	count := 0
 	for _, turno := range turnos {
-		if turno.Estado == models.EstadoConfirmado || turno.Estado == models.EstadoPendiente {
+		switch turno.Estado {
+		case models.EstadoConfirmado, models.EstadoPendiente, models.EstadoEnEspera:
 			count++
 		}
 	}
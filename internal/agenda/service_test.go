@@ -0,0 +1,381 @@
+package agenda
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+var fechaPrueba = time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+// minutos devuelve la hora de prueba desplazada en m minutos.
+func minutos(m int) *time.Time {
+	t := ahoraPrueba.Add(time.Duration(m) * time.Minute)
+	return &t
+}
+
+// turnoA arma un turno de hoy m minutos después de la hora de prueba.
+func turnoA(id int64, m, duracion int, estado models.EstadoTurno) models.Turno {
+	return models.Turno{
+		ID:       id,
+		Fecha:    fechaPrueba,
+		Hora:     models.HoraDe(*minutos(m)),
+		Duracion: duracion,
+		Estado:   estado,
+	}
+}
+
+func crearTurno(t *testing.T, s *Service, hora models.Hora) *models.Turno {
+	t.Helper()
+
+	paciente := &models.Paciente{Nombre: "Paciente " + hora.String()}
+	if err := s.CrearPaciente(paciente); err != nil {
+		t.Fatalf("CrearPaciente() failed: %v", err)
+	}
+	turno := &models.Turno{PacienteID: paciente.ID, Fecha: fechaPrueba, Hora: hora, Duracion: 30, Estado: models.EstadoConfirmado}
+	if err := s.CrearTurno(turno); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+	return turno
+}
+
+func TestAsistencia(t *testing.T) {
+	s := setupService(t)
+	turno := crearTurno(t, s, models.NuevaHora(9, 0))
+
+	ahora := ahoraPrueba
+	s.UsarReloj(func() time.Time { return ahora })
+
+	pasos := []struct {
+		hora   time.Time
+		accion func(int64) error
+	}{
+		{*minutos(-65), s.MarcarLlegada},
+		{*minutos(-50), s.IniciarConsulta},
+		{*minutos(-30), s.MarcarAtendido},
+	}
+	for _, paso := range pasos {
+		ahora = paso.hora
+		if err := paso.accion(turno.ID); err != nil {
+			t.Fatalf("cambio de estado failed: %v", err)
+		}
+	}
+
+	guardado, err := s.turnoRepo.ObtenerPorID(turno.ID)
+	if err != nil {
+		t.Fatalf("ObtenerPorID() failed: %v", err)
+	}
+	if guardado.Estado != models.EstadoAtendido {
+		t.Errorf("Estado = %v, want atendido", guardado.Estado)
+	}
+	horarios := []struct {
+		nombre string
+		got    *time.Time
+		want   time.Time
+	}{
+		{"HoraLlegada", guardado.HoraLlegada, pasos[0].hora},
+		{"HoraInicio", guardado.HoraInicio, pasos[1].hora},
+		{"HoraFin", guardado.HoraFin, pasos[2].hora},
+	}
+	for _, h := range horarios {
+		if h.got == nil || !h.got.Equal(h.want) {
+			t.Errorf("%s = %v, want %v", h.nombre, h.got, h.want)
+		}
+	}
+
+	if err := s.CambiarEstado(turno.ID, models.EstadoConfirmado); err != nil {
+		t.Fatalf("CambiarEstado() failed: %v", err)
+	}
+	guardado, _ = s.turnoRepo.ObtenerPorID(turno.ID)
+	if guardado.HoraLlegada != nil || guardado.HoraInicio != nil || guardado.HoraFin != nil {
+		t.Error("volver a confirmado debería deshacer la llegada")
+	}
+}
+
+func TestCambiarEstado_Ausente(t *testing.T) {
+	s := setupService(t)
+	turno := crearTurno(t, s, models.NuevaHora(9, 0))
+
+	// Marcar ausente un turno que ya lo estaba no cuenta otra falta.
+	for i := 0; i < 2; i++ {
+		if err := s.CambiarEstado(turno.ID, models.EstadoAusente); err != nil {
+			t.Fatalf("CambiarEstado() failed: %v", err)
+		}
+	}
+
+	noShows, err := s.pacienteRepo.ListarNoShows(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListarNoShows() failed: %v", err)
+	}
+	if len(noShows) != 1 {
+		t.Errorf("no-shows = %d, want 1", len(noShows))
+	}
+}
+
+func TestActualizarTurno_Estado(t *testing.T) {
+	s := setupService(t)
+	turno := crearTurno(t, s, models.NuevaHora(9, 0))
+
+	// Un cambio de estado al editar el turno sigue las reglas de CambiarEstado.
+	turno.Estado = models.EstadoEnEspera
+	if err := s.ActualizarTurno(turno); err != nil {
+		t.Fatalf("ActualizarTurno() failed: %v", err)
+	}
+	guardado, err := s.turnoRepo.ObtenerPorID(turno.ID)
+	if err != nil {
+		t.Fatalf("ObtenerPorID() failed: %v", err)
+	}
+	if guardado.Estado != models.EstadoEnEspera || guardado.HoraLlegada == nil {
+		t.Errorf("turno = %s con llegada %v, want en espera con llegada", guardado.Estado, guardado.HoraLlegada)
+	}
+
+	for i := 0; i < 2; i++ {
+		guardado.Estado = models.EstadoAusente
+		if err := s.ActualizarTurno(guardado); err != nil {
+			t.Fatalf("ActualizarTurno() failed: %v", err)
+		}
+	}
+	noShows, err := s.pacienteRepo.ListarNoShows(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListarNoShows() failed: %v", err)
+	}
+	if len(noShows) != 1 {
+		t.Errorf("no-shows = %d, want 1", len(noShows))
+	}
+}
+
+func TestSalaDeEspera(t *testing.T) {
+	s := setupService(t)
+
+	tarde := crearTurno(t, s, models.NuevaHora(9, 0))
+	temprano := crearTurno(t, s, models.NuevaHora(9, 30))
+	ultimo := crearTurno(t, s, models.NuevaHora(10, 0))
+	crearTurno(t, s, models.NuevaHora(10, 30)) // todavía no llegó
+
+	// 09:00 llega 09:40, 09:30 llega 08:50 y 10:00 llega 09:00.
+	for turno, llegada := range map[*models.Turno]*time.Time{tarde: minutos(-20), temprano: minutos(-70), ultimo: minutos(-60)} {
+		turno.Estado = models.EstadoEnEspera
+		turno.HoraLlegada = llegada
+		if err := s.turnoRepo.ActualizarAsistencia(turno); err != nil {
+			t.Fatalf("ActualizarAsistencia() failed: %v", err)
+		}
+	}
+
+	cola, err := s.SalaDeEspera(fechaPrueba)
+	if err != nil {
+		t.Fatalf("SalaDeEspera() failed: %v", err)
+	}
+
+	want := []int64{temprano.ID, tarde.ID, ultimo.ID}
+	if len(cola) != len(want) {
+		t.Fatalf("SalaDeEspera() = %d turnos, want %d", len(cola), len(want))
+	}
+	for i, id := range want {
+		if cola[i].ID != id {
+			t.Errorf("cola[%d] = turno de las %s, want turno %d", i, cola[i].Hora, id)
+		}
+	}
+}
+
+func TestCalcularAtraso(t *testing.T) {
+	s := setupService(t)
+	estimaciones := func(p *models.ProyeccionAtraso) map[int64]int {
+		demoras := make(map[int64]int)
+		for _, e := range p.Estimaciones {
+			demoras[e.TurnoID] = e.DemoraMinutos
+		}
+		return demoras
+	}
+
+	t.Run("cola detrás de la consulta en curso", func(t *testing.T) {
+		enConsulta := turnoA(1, -30, 30, models.EstadoEnConsulta)
+		enConsulta.HoraInicio = minutos(-15)
+		enEspera := turnoA(2, -10, 20, models.EstadoEnEspera)
+		enEspera.HoraLlegada = minutos(-20)
+		pendiente := turnoA(3, 30, 30, models.EstadoPendiente)
+
+		p := s.CalcularAtraso([]models.Turno{enConsulta, enEspera, pendiente})
+		// La consulta en curso termina a +15; el de -10 empieza ahí (25 de
+		// demora) y termina a +35, 5 minutos después del turno de +30.
+		if p.AtrasoMinutos != 25 {
+			t.Errorf("AtrasoMinutos = %d, want 25", p.AtrasoMinutos)
+		}
+		demoras := estimaciones(p)
+		if len(demoras) != 2 || demoras[2] != 25 || demoras[3] != 5 {
+			t.Errorf("estimaciones = %v, want map[2:25 3:5]", demoras)
+		}
+		for _, e := range p.Estimaciones {
+			if e.TurnoID == 2 && !e.InicioEstimado.Equal(*minutos(15)) {
+				t.Errorf("InicioEstimado = %v, want %v", e.InicioEstimado, *minutos(15))
+			}
+		}
+	})
+
+	t.Run("un turno atendido a la mañana no genera demora", func(t *testing.T) {
+		atendido := turnoA(1, -90, 30, models.EstadoAtendido)
+		atendido.HoraInicio = minutos(-80)
+		atendido.HoraFin = minutos(-50)
+		pendiente := turnoA(2, 60, 30, models.EstadoConfirmado)
+
+		p := s.CalcularAtraso([]models.Turno{atendido, pendiente})
+		if p.AtrasoMinutos != 0 || estimaciones(p)[2] != 0 {
+			t.Errorf("proyección = %+v, want sin demora", p)
+		}
+	})
+
+	t.Run("sin cola se informa la demora de la consulta en curso", func(t *testing.T) {
+		enConsulta := turnoA(1, -30, 30, models.EstadoEnConsulta)
+		enConsulta.HoraInicio = minutos(-15)
+		ausente := turnoA(2, -20, 30, models.EstadoPendiente) // no llegó
+
+		p := s.CalcularAtraso([]models.Turno{enConsulta, ausente})
+		if p.AtrasoMinutos != 15 {
+			t.Errorf("AtrasoMinutos = %d, want 15", p.AtrasoMinutos)
+		}
+		if len(p.Estimaciones) != 0 {
+			t.Errorf("Estimaciones = %v, want none", p.Estimaciones)
+		}
+	})
+
+	t.Run("las consultas lentas alargan la proyección", func(t *testing.T) {
+		// Una consulta de 20 minutos duró 30: factor 1,5.
+		atendido := turnoA(1, -60, 20, models.EstadoAtendido)
+		atendido.HoraInicio = minutos(-60)
+		atendido.HoraFin = minutos(-30)
+		enConsulta := turnoA(2, -5, 20, models.EstadoEnConsulta)
+		enConsulta.HoraInicio = minutos(-5)
+		pendiente := turnoA(3, 10, 20, models.EstadoPendiente)
+
+		p := s.CalcularAtraso([]models.Turno{atendido, enConsulta, pendiente})
+		if demora := estimaciones(p)[3]; demora != 15 {
+			t.Errorf("demora = %d, want 15", demora)
+		}
+	})
+
+	t.Run("otro día no se proyecta", func(t *testing.T) {
+		manana := turnoA(1, 0, 30, models.EstadoEnEspera)
+		manana.Fecha = fechaPrueba.AddDate(0, 0, 1)
+
+		p := s.CalcularAtraso([]models.Turno{manana})
+		if p.AtrasoMinutos != 0 || len(p.Estimaciones) != 0 {
+			t.Errorf("proyección = %+v, want vacía", p)
+		}
+	})
+}
+
+func TestObtenerProximoTurno(t *testing.T) {
+	s := setupService(t)
+
+	tests := []struct {
+		name   string
+		turnos []models.Turno
+		want   int64
+	}{
+		{
+			name:   "sin turnos",
+			turnos: nil,
+		},
+		{
+			name: "el primero que puede llegar",
+			turnos: []models.Turno{
+				turnoA(1, -60, 30, models.EstadoAtendido),
+				turnoA(2, -40, 30, models.EstadoPendiente), // pasó la tolerancia
+				turnoA(3, -10, 30, models.EstadoConfirmado),
+				turnoA(4, 20, 30, models.EstadoPendiente),
+			},
+			want: 3,
+		},
+		{
+			name: "la sala de espera tiene prioridad",
+			turnos: []models.Turno{
+				turnoA(1, -10, 30, models.EstadoConfirmado),
+				turnoA(2, 20, 30, models.EstadoEnEspera),
+			},
+			want: 2,
+		},
+		{
+			name: "cancelados y ausentes no cuentan",
+			turnos: []models.Turno{
+				turnoA(1, 10, 30, models.EstadoCancelado),
+				turnoA(2, 20, 30, models.EstadoAusente),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.ObtenerProximoTurno(tt.turnos)
+			if tt.want == 0 {
+				if got != nil {
+					t.Errorf("ObtenerProximoTurno() = turno %d, want nil", got.ID)
+				}
+				return
+			}
+			if got == nil || got.ID != tt.want {
+				t.Errorf("ObtenerProximoTurno() = %+v, want turno %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObtenerTurnoActual(t *testing.T) {
+	s := setupService(t)
+
+	libre := []models.Turno{turnoA(1, -30, 30, models.EstadoAtendido), turnoA(2, 0, 30, models.EstadoEnEspera)}
+	if got := s.ObtenerTurnoActual(libre); got != nil {
+		t.Errorf("ObtenerTurnoActual() = turno %d, want nil", got.ID)
+	}
+
+	anterior := turnoA(1, -30, 30, models.EstadoEnConsulta)
+	anterior.HoraInicio = minutos(-30)
+	actual := turnoA(2, 0, 30, models.EstadoEnConsulta)
+	actual.HoraInicio = minutos(-5)
+	if got := s.ObtenerTurnoActual([]models.Turno{anterior, actual}); got == nil || got.ID != 2 {
+		t.Errorf("ObtenerTurnoActual() = %+v, want turno 2", got)
+	}
+}
+
+func TestHorarioProgramadoEnZonaDelReloj(t *testing.T) {
+	s := setupService(t)
+
+	// 09:30 en Buenos Aires son las 12:30 UTC, sin importar la zona de la
+	// máquina que corre el test.
+	got := s.horarioProgramado(models.Turno{Fecha: fechaPrueba, Hora: models.NuevaHora(9, 30)})
+	want := time.Date(2025, 3, 10, 12, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("horarioProgramado() = %v, want %v", got, want)
+	}
+}
+
+func TestUsarLicencia(t *testing.T) {
+	s := setupService(t)
+	s.UsarLicencia(func(models.Feature) bool { return false })
+
+	principal := crearTurno(t, s, models.NuevaHora(9, 0))
+	otro := &models.Turno{PacienteID: principal.PacienteID, ProfesionalID: 2, Fecha: fechaPrueba, Hora: models.NuevaHora(11, 0), Duracion: 30}
+	var noIncluida *models.FeatureNoIncluidaError
+	if err := s.CrearTurno(otro); !errors.As(err, &noIncluida) || noIncluida.Feature != models.FeatureMultiProfesional {
+		t.Errorf("CrearTurno() de otro profesional error = %v, want *FeatureNoIncluidaError", err)
+	}
+	principal.ProfesionalID = 2
+	if err := s.ActualizarTurno(principal); !errors.As(err, &noIncluida) {
+		t.Errorf("ActualizarTurno() a otro profesional error = %v, want *FeatureNoIncluidaError", err)
+	}
+
+	// Sin mensajería la reprogramación se aplica sin encolar avisos.
+	paciente, _ := s.pacienteRepo.ObtenerPorID(principal.PacienteID)
+	paciente.Telefono = "+54 9 11 1234-5678"
+	if err := s.ActualizarPaciente(paciente); err != nil {
+		t.Fatalf("ActualizarPaciente() failed: %v", err)
+	}
+	plan, err := s.ReprogramarTurnos(models.SolicitudReprogramacion{
+		Desde:        fechaPrueba,
+		Estrategia:   models.ReprogramarAlDia,
+		FechaDestino: fechaPrueba.AddDate(0, 0, 1),
+	})
+	if err != nil || !plan.Aplicado || plan.MensajesEncolados != 0 {
+		t.Errorf("ReprogramarTurnos() = %+v, %v; want aplicado sin mensajes", plan, err)
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"yoyaku/internal/agenda"
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+// Prefijo es la ruta base de la API.
+const Prefijo = "/api/v1"
+
+//go:embed openapi.json
+var openAPI []byte
+
+var errNoEncontrado = errors.New("no encontrado")
+
+// Handler expone por HTTP/JSON las mismas operaciones que los bindings de la
+// aplicación, para integrar yoyaku con otras herramientas del consultorio.
+// Todas las rutas, salvo la descripción OpenAPI, requieren un token de API en
+// el header Authorization.
+type Handler struct {
+	turnoRepo       *db.TurnoRepo
+	pacienteRepo    *db.PacienteRepo
+	profesionalRepo *db.ProfesionalRepo
+	tokenRepo       *db.TokenAPIRepo
+	agendaSvc       *agenda.Service
+	habilitada      atomic.Bool
+}
+
+func NewHandler(database *db.DB, agendaSvc *agenda.Service) *Handler {
+	return &Handler{
+		turnoRepo:       db.NewTurnoRepo(database),
+		pacienteRepo:    db.NewPacienteRepo(database),
+		profesionalRepo: db.NewProfesionalRepo(database),
+		tokenRepo:       db.NewTokenAPIRepo(database),
+		agendaSvc:       agendaSvc,
+	}
+}
+
+// Habilitar activa o desactiva la API sin reiniciar el servidor. Mientras
+// está desactivada todas las rutas responden 404.
+func (h *Handler) Habilitar(habilitada bool) {
+	h.habilitada.Store(habilitada)
+}
+
+// Registrar agrega las rutas de la API al mux del servidor.
+func (h *Handler) Registrar(handle func(patron string, handler http.Handler)) {
+	rutas := map[string]func(http.ResponseWriter, *http.Request) (interface{}, error){
+		"GET /sala-espera/{fecha}":   h.salaDeEspera,
+		"GET /agenda/{fecha}":        h.agendaDelDia,
+		"POST /turnos":               h.crearTurno,
+		"GET /turnos/{id}":           h.obtenerTurno,
+		"PUT /turnos/{id}":           h.actualizarTurno,
+		"DELETE /turnos/{id}":        h.eliminarTurno,
+		"PUT /turnos/{id}/estado":    h.cambiarEstado,
+		"GET /pacientes":             h.buscarPacientes,
+		"POST /pacientes":            h.crearPaciente,
+		"GET /pacientes/{id}":        h.obtenerPaciente,
+		"PUT /pacientes/{id}":        h.actualizarPaciente,
+		"DELETE /pacientes/{id}":     h.eliminarPaciente,
+		"GET /pacientes/{id}/turnos": h.historialPaciente,
+	}
+
+	for patron, fn := range rutas {
+		metodo, ruta, _ := strings.Cut(patron, " ")
+		handle(metodo+" "+Prefijo+ruta, h.autenticado(fn))
+	}
+
+	handle("GET "+Prefijo+"/openapi.json", h.habilitado(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})))
+}
+
+func (h *Handler) habilitado(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.habilitada.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// autenticado valida el token y serializa la respuesta o el error como JSON.
+func (h *Handler) autenticado(fn func(http.ResponseWriter, *http.Request) (interface{}, error)) http.Handler {
+	return h.habilitado(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="yoyaku"`)
+			escribirError(w, http.StatusUnauthorized, "falta el token de API")
+			return
+		}
+
+		t, err := h.tokenRepo.Validar(strings.TrimSpace(token))
+		if err != nil {
+			escribirError(w, http.StatusInternalServerError, "error validando token")
+			return
+		}
+		if t == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="yoyaku", error="invalid_token"`)
+			escribirError(w, http.StatusUnauthorized, "token de API inválido")
+			return
+		}
+
+		resultado, err := fn(w, r)
+		if err != nil {
+			escribirErrorServicio(w, r, err)
+			return
+		}
+
+		if resultado == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		status := http.StatusOK
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		escribirJSON(w, status, resultado)
+	}))
+}
+
+// escribirErrorServicio responde con el código que corresponde a cada tipo
+// de error conocido. El resto son errores internos, como los de la base de
+// datos: se registran y al cliente sólo le llega un mensaje genérico.
+func escribirErrorServicio(w http.ResponseWriter, r *http.Request, err error) {
+	var errSolicitud *errorSolicitud
+	var diaCerrado *agenda.DiaCerradoError
+	var noIncluida *models.FeatureNoIncluidaError
+	switch {
+	case errors.As(err, &errSolicitud):
+		escribirError(w, http.StatusBadRequest, errSolicitud.Error())
+	case errors.As(err, &noIncluida):
+		escribirError(w, http.StatusForbidden, noIncluida.Error())
+	case errors.As(err, &diaCerrado):
+		escribirError(w, http.StatusConflict, diaCerrado.Error())
+	case errors.Is(err, errNoEncontrado):
+		escribirError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("API %s %s: %v", r.Method, r.URL.Path, err)
+		escribirError(w, http.StatusInternalServerError, "error interno del servidor")
+	}
+}
+
+// errorSolicitud es un error en los datos enviados por el cliente (400).
+type errorSolicitud struct {
+	mensaje string
+}
+
+func (e *errorSolicitud) Error() string {
+	return e.mensaje
+}
+
+func solicitudInvalida(formato string, args ...interface{}) error {
+	return &errorSolicitud{mensaje: fmt.Sprintf(formato, args...)}
+}
+
+func escribirJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func escribirError(w http.ResponseWriter, status int, mensaje string) {
+	escribirJSON(w, status, map[string]string{"error": mensaje})
+}
+
+func leerJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return solicitudInvalida("JSON inválido: %v", err)
+	}
+	return nil
+}
+
+func leerID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, solicitudInvalida("id inválido: %s", r.PathValue("id"))
+	}
+	return id, nil
+}
+
+func parsearFecha(s string) (time.Time, error) {
+	fecha, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, solicitudInvalida("fecha inválida %q, se espera AAAA-MM-DD", s)
+	}
+	return fecha, nil
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/agenda"
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+type clienteAPI struct {
+	t     *testing.T
+	url   string
+	token string
+}
+
+func setupAPI(t *testing.T) (*clienteAPI, *Handler, *db.DB) {
+	t.Helper()
+
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	token, err := db.NewTokenAPIRepo(database).Crear("integración")
+	if err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	agendaSvc := agenda.NewService(db.NewTurnoRepo(database), db.NewPacienteRepo(database), db.NewCierreRepo(database), db.NewConfigRepo(database))
+	h := NewHandler(database, agendaSvc)
+	h.Habilitar(true)
+
+	mux := http.NewServeMux()
+	h.Registrar(mux.Handle)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return &clienteAPI{t: t, url: srv.URL + Prefijo, token: token.Token}, h, database
+}
+
+func (c *clienteAPI) pedir(metodo, ruta, cuerpo string, destino interface{}) int {
+	c.t.Helper()
+
+	var body io.Reader
+	if cuerpo != "" {
+		body = strings.NewReader(cuerpo)
+	}
+	req, err := http.NewRequest(metodo, c.url+ruta, body)
+	if err != nil {
+		c.t.Fatalf("NewRequest() failed: %v", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s failed: %v", metodo, ruta, err)
+	}
+	defer resp.Body.Close()
+
+	if destino != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(destino); err != nil {
+			c.t.Fatalf("%s %s: JSON inválido: %v", metodo, ruta, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAutenticacion(t *testing.T) {
+	c, h, _ := setupAPI(t)
+
+	if status := c.pedir("GET", "/openapi.json", "", nil); status != http.StatusOK {
+		t.Errorf("openapi.json status = %d, want 200", status)
+	}
+
+	token := c.token
+	c.token = ""
+	if status := c.pedir("GET", "/pacientes", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("sin token status = %d, want 401", status)
+	}
+	if status := c.pedir("GET", "/openapi.json", "", nil); status != http.StatusOK {
+		t.Errorf("openapi.json sin token status = %d, want 200", status)
+	}
+
+	c.token = "yyk_invalido"
+	if status := c.pedir("GET", "/pacientes", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("token inválido status = %d, want 401", status)
+	}
+
+	c.token = token
+	if status := c.pedir("GET", "/pacientes", "", nil); status != http.StatusOK {
+		t.Errorf("token válido status = %d, want 200", status)
+	}
+
+	h.Habilitar(false)
+	if status := c.pedir("GET", "/pacientes", "", nil); status != http.StatusNotFound {
+		t.Errorf("API deshabilitada status = %d, want 404", status)
+	}
+}
+
+func TestTurnos(t *testing.T) {
+	c, _, database := setupAPI(t)
+
+	var paciente models.Paciente
+	if status := c.pedir("POST", "/pacientes", `{"nombre":"María González","telefono":"11 1234-5678"}`, &paciente); status != http.StatusCreated {
+		t.Fatalf("POST /pacientes status = %d, want 201", status)
+	}
+
+	if status := c.pedir("POST", "/turnos", `{"pacienteId":`+strconv.FormatInt(paciente.ID, 10)+`,"fecha":"10/03/2025","hora":"10:00"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("fecha inválida status = %d, want 400", status)
+	}
+	if status := c.pedir("POST", "/turnos", `{"pacienteId":999,"fecha":"2025-03-10","hora":"10:00"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("paciente inexistente status = %d, want 400", status)
+	}
+	if status := c.pedir("POST", "/turnos", `{"pacienteId":`+strconv.FormatInt(paciente.ID, 10)+`,"fecha":"2025-03-24","hora":"10:00"}`, nil); status != http.StatusConflict {
+		t.Errorf("turno en feriado status = %d, want 409", status)
+	}
+
+	var turno models.Turno
+	cuerpo := `{"pacienteId":` + strconv.FormatInt(paciente.ID, 10) + `,"fecha":"2025-03-10","hora":"10:00","motivo":"Control"}`
+	if status := c.pedir("POST", "/turnos", cuerpo, &turno); status != http.StatusCreated {
+		t.Fatalf("POST /turnos status = %d, want 201", status)
+	}
+	if turno.Estado != models.EstadoPendiente || turno.Duracion != 30 || turno.ProfesionalID != models.ProfesionalPrincipalID {
+		t.Errorf("turno creado = %+v, want valores por defecto", turno)
+	}
+
+	var agendaDia models.AgendaDia
+	if status := c.pedir("GET", "/agenda/2025-03-10", "", &agendaDia); status != http.StatusOK {
+		t.Fatalf("GET /agenda status = %d, want 200", status)
+	}
+	if agendaDia.TotalTurnos != 1 {
+		t.Errorf("agenda TotalTurnos = %d, want 1", agendaDia.TotalTurnos)
+	}
+
+	// El estado sólo cambia por su ruta, que registra el no-show una vez.
+	ruta := "/turnos/" + strconv.FormatInt(turno.ID, 10)
+	if status := c.pedir("POST", "/turnos", strings.Replace(cuerpo, "}", `,"estado":"ausente"}`, 1), nil); status != http.StatusBadRequest {
+		t.Errorf("POST con estado status = %d, want 400", status)
+	}
+	if status := c.pedir("PUT", ruta, strings.Replace(cuerpo, "}", `,"estado":"ausente"}`, 1), nil); status != http.StatusBadRequest {
+		t.Errorf("PUT con estado status = %d, want 400", status)
+	}
+	if status := c.pedir("PUT", ruta+"/estado", `{"estado":"desconocido"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("estado inválido status = %d, want 400", status)
+	}
+	if status := c.pedir("PUT", ruta+"/estado", `{"estado":"ausente"}`, &turno); status != http.StatusOK {
+		t.Fatalf("PUT estado status = %d, want 200", status)
+	}
+	if status := c.pedir("PUT", ruta+"/estado", `{"estado":"ausente"}`, &turno); status != http.StatusOK {
+		t.Fatalf("PUT estado repetido status = %d, want 200", status)
+	}
+	if turno.Estado != models.EstadoAusente {
+		t.Errorf("estado = %v, want ausente", turno.Estado)
+	}
+	noShows, err := db.NewPacienteRepo(database).ListarNoShows(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListarNoShows() failed: %v", err)
+	}
+	if len(noShows) != 1 {
+		t.Errorf("no-shows = %d, want 1 (mismas reglas que la aplicación)", len(noShows))
+	}
+
+	var historial []models.Turno
+	if status := c.pedir("GET", "/pacientes/"+strconv.FormatInt(paciente.ID, 10)+"/turnos", "", &historial); status != http.StatusOK {
+		t.Fatalf("GET historial status = %d, want 200", status)
+	}
+	if len(historial) != 1 {
+		t.Errorf("historial = %d turnos, want 1", len(historial))
+	}
+
+	if status := c.pedir("DELETE", ruta, "", nil); status != http.StatusNoContent {
+		t.Errorf("DELETE status = %d, want 204", status)
+	}
+	if status := c.pedir("GET", ruta, "", nil); status != http.StatusNotFound {
+		t.Errorf("GET eliminado status = %d, want 404", status)
+	}
+}
+
+func TestBuscarPacientes(t *testing.T) {
+	c, _, _ := setupAPI(t)
+
+	for _, nombre := range []string{"Ana Pérez", "Juan Pérez", "Carlos Gómez"} {
+		if status := c.pedir("POST", "/pacientes", `{"nombre":"`+nombre+`"}`, nil); status != http.StatusCreated {
+			t.Fatalf("POST /pacientes status = %d, want 201", status)
+		}
+	}
+	if status := c.pedir("POST", "/pacientes", `{"nombre":"  "}`, nil); status != http.StatusBadRequest {
+		t.Errorf("nombre vacío status = %d, want 400", status)
+	}
+
+	var resultado models.ResultadoBusquedaPacientes
+	if status := c.pedir("GET", "/pacientes?q=perez&porPagina=1", "", &resultado); status != http.StatusOK {
+		t.Fatalf("GET /pacientes status = %d, want 200", status)
+	}
+	if resultado.Total != 2 || len(resultado.Pacientes) != 1 {
+		t.Errorf("resultado = total %d, %d pacientes; want total 2, 1 paciente", resultado.Total, len(resultado.Pacientes))
+	}
+
+	if status := c.pedir("GET", "/pacientes?porPagina=100000000", "", &resultado); status != http.StatusOK {
+		t.Fatalf("GET /pacientes status = %d, want 200", status)
+	}
+	if resultado.PorPagina != maxPorPagina {
+		t.Errorf("porPagina = %d, want %d", resultado.PorPagina, maxPorPagina)
+	}
+
+	if status := c.pedir("GET", "/pacientes?pagina=x", "", nil); status != http.StatusBadRequest {
+		t.Errorf("pagina inválida status = %d, want 400", status)
+	}
+}
+
+func TestErrores(t *testing.T) {
+	c, h, database := setupAPI(t)
+
+	var paciente models.Paciente
+	if status := c.pedir("POST", "/pacientes", `{"nombre":"María González"}`, &paciente); status != http.StatusCreated {
+		t.Fatalf("POST /pacientes status = %d, want 201", status)
+	}
+
+	cuerpo := `{"pacienteId":` + strconv.FormatInt(paciente.ID, 10) + `,"profesionalId":2,"fecha":"2025-03-10","hora":"10:00"}`
+	if status := c.pedir("POST", "/turnos", cuerpo, nil); status != http.StatusBadRequest {
+		t.Errorf("profesional inexistente status = %d, want 400", status)
+	}
+	profesional := &models.Profesional{Nombre: "Dra. López", Activo: true}
+	if err := db.NewProfesionalRepo(database).Crear(profesional); err != nil || profesional.ID != 2 {
+		t.Fatalf("Crear profesional = %d, %v; want id 2", profesional.ID, err)
+	}
+	h.agendaSvc.UsarLicencia(func(models.Feature) bool { return false })
+	if status := c.pedir("POST", "/turnos", cuerpo, nil); status != http.StatusForbidden {
+		t.Errorf("función no incluida status = %d, want 403", status)
+	}
+
+	// Los errores internos no exponen el detalle de la base.
+	if _, err := database.Conn().Exec(`DROP TABLE turnos`); err != nil {
+		t.Fatalf("DROP TABLE failed: %v", err)
+	}
+	req, _ := http.NewRequest("GET", c.url+"/turnos/1", nil)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /turnos/1 failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var cuerpoError struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&cuerpoError)
+	if resp.StatusCode != http.StatusInternalServerError || cuerpoError.Error != "error interno del servidor" {
+		t.Errorf("error interno = %d %q, want 500 con mensaje genérico", resp.StatusCode, cuerpoError.Error)
+	}
+
+	// Una falla al buscar el paciente no se informa como paciente inexistente.
+	if _, err := database.Conn().Exec(`DROP TABLE pacientes`); err != nil {
+		t.Fatalf("DROP TABLE failed: %v", err)
+	}
+	if status := c.pedir("POST", "/turnos", cuerpo, nil); status != http.StatusInternalServerError {
+		t.Errorf("falla al buscar el paciente status = %d, want 500", status)
+	}
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"yoyaku/internal/models"
+)
+
+// turnoEntrada es el cuerpo aceptado al crear o modificar un turno. La fecha
+// va como AAAA-MM-DD en lugar del timestamp que usa models.Turno. El estado
+// sólo se acepta para rechazarlo con un mensaje claro: se cambia con
+// PUT /turnos/{id}/estado, que aplica las reglas de la sala de espera.
+type turnoEntrada struct {
+	PacienteID    int64              `json:"pacienteId"`
+	ProfesionalID int64              `json:"profesionalId"`
+	Fecha         string             `json:"fecha"`
+	Hora          string             `json:"hora"`
+	Duracion      int                `json:"duracion"`
+	Motivo        string             `json:"motivo"`
+	Estado        models.EstadoTurno `json:"estado"`
+	Notas         string             `json:"notas"`
+}
+
+type pacienteEntrada struct {
+	Nombre   string `json:"nombre"`
+	Telefono string `json:"telefono"`
+	Email    string `json:"email"`
+	Notas    string `json:"notas"`
+}
+
+type estadoEntrada struct {
+	Estado models.EstadoTurno `json:"estado"`
+}
+
+// maxPorPagina limita los pacientes devueltos en una página de búsqueda.
+const maxPorPagina = 100
+
+var estadosValidos = map[models.EstadoTurno]bool{
+	models.EstadoConfirmado: true,
+	models.EstadoPendiente:  true,
+	models.EstadoEnEspera:   true,
+	models.EstadoEnConsulta: true,
+	models.EstadoAtendido:   true,
+	models.EstadoAusente:    true,
+	models.EstadoCancelado:  true,
+}
+
+func (h *Handler) agendaDelDia(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	fecha, err := parsearFecha(r.PathValue("fecha"))
+	if err != nil {
+		return nil, err
+	}
+	return h.agendaSvc.ObtenerAgendaDelDia(fecha)
+}
+
+func (h *Handler) salaDeEspera(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	fecha, err := parsearFecha(r.PathValue("fecha"))
+	if err != nil {
+		return nil, err
+	}
+	return h.agendaSvc.SalaDeEspera(fecha)
+}
+
+func (h *Handler) obtenerTurno(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	return h.buscarTurno(id)
+}
+
+func (h *Handler) crearTurno(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var entrada turnoEntrada
+	if err := leerJSON(r, &entrada); err != nil {
+		return nil, err
+	}
+
+	turno := &models.Turno{}
+	if err := h.aplicarTurno(turno, entrada); err != nil {
+		return nil, err
+	}
+	if err := h.agendaSvc.CrearTurno(turno); err != nil {
+		return nil, err
+	}
+	return h.buscarTurno(turno.ID)
+}
+
+func (h *Handler) actualizarTurno(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	var entrada turnoEntrada
+	if err := leerJSON(r, &entrada); err != nil {
+		return nil, err
+	}
+
+	turno, err := h.buscarTurno(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.aplicarTurno(turno, entrada); err != nil {
+		return nil, err
+	}
+	if err := h.agendaSvc.ActualizarTurno(turno); err != nil {
+		return nil, err
+	}
+	return h.buscarTurno(id)
+}
+
+func (h *Handler) eliminarTurno(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.buscarTurno(id); err != nil {
+		return nil, err
+	}
+	return nil, h.agendaSvc.EliminarTurno(id)
+}
+
+func (h *Handler) cambiarEstado(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	var entrada estadoEntrada
+	if err := leerJSON(r, &entrada); err != nil {
+		return nil, err
+	}
+	if !estadosValidos[entrada.Estado] {
+		return nil, solicitudInvalida("estado inválido: %q", entrada.Estado)
+	}
+	if _, err := h.buscarTurno(id); err != nil {
+		return nil, err
+	}
+	if err := h.agendaSvc.CambiarEstado(id, entrada.Estado); err != nil {
+		return nil, err
+	}
+	return h.buscarTurno(id)
+}
+
+func (h *Handler) buscarPacientes(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	consulta := r.URL.Query()
+	pagina, err := enteroOpcional(consulta.Get("pagina"), 1)
+	if err != nil {
+		return nil, err
+	}
+	porPagina, err := enteroOpcional(consulta.Get("porPagina"), 20)
+	if err != nil {
+		return nil, err
+	}
+	porPagina = min(porPagina, maxPorPagina)
+	return h.pacienteRepo.BuscarPaginado(consulta.Get("q"), pagina, porPagina)
+}
+
+func (h *Handler) obtenerPaciente(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	return h.buscarPaciente(id)
+}
+
+func (h *Handler) crearPaciente(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	var entrada pacienteEntrada
+	if err := leerJSON(r, &entrada); err != nil {
+		return nil, err
+	}
+
+	paciente := &models.Paciente{}
+	if err := aplicarPaciente(paciente, entrada); err != nil {
+		return nil, err
+	}
+	if err := h.agendaSvc.CrearPaciente(paciente); err != nil {
+		return nil, err
+	}
+	return h.buscarPaciente(paciente.ID)
+}
+
+func (h *Handler) actualizarPaciente(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	var entrada pacienteEntrada
+	if err := leerJSON(r, &entrada); err != nil {
+		return nil, err
+	}
+
+	paciente, err := h.buscarPaciente(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := aplicarPaciente(paciente, entrada); err != nil {
+		return nil, err
+	}
+	if err := h.agendaSvc.ActualizarPaciente(paciente); err != nil {
+		return nil, err
+	}
+	return h.buscarPaciente(id)
+}
+
+func (h *Handler) eliminarPaciente(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.buscarPaciente(id); err != nil {
+		return nil, err
+	}
+	return nil, h.agendaSvc.EliminarPaciente(id)
+}
+
+func (h *Handler) historialPaciente(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	id, err := leerID(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.buscarPaciente(id); err != nil {
+		return nil, err
+	}
+	turnos, err := h.turnoRepo.ListarPorPaciente(id)
+	if err != nil {
+		return nil, err
+	}
+	if turnos == nil {
+		turnos = []models.Turno{}
+	}
+	return turnos, nil
+}
+
+func (h *Handler) buscarTurno(id int64) (*models.Turno, error) {
+	turno, err := h.turnoRepo.ObtenerPorID(id)
+	if err != nil {
+		return nil, err
+	}
+	if turno == nil {
+		return nil, fmt.Errorf("turno %d %w", id, errNoEncontrado)
+	}
+	return turno, nil
+}
+
+func (h *Handler) buscarPaciente(id int64) (*models.Paciente, error) {
+	paciente, err := h.pacienteRepo.ObtenerPorID(id)
+	if err != nil {
+		return nil, err
+	}
+	if paciente == nil {
+		return nil, fmt.Errorf("paciente %d %w", id, errNoEncontrado)
+	}
+	return paciente, nil
+}
+
+// aplicarTurno valida la entrada y la copia sobre el turno. Los campos
+// opcionales vacíos conservan el valor actual o toman el valor por defecto.
+func (h *Handler) aplicarTurno(turno *models.Turno, entrada turnoEntrada) error {
+	if entrada.PacienteID <= 0 {
+		return solicitudInvalida("pacienteId es obligatorio")
+	}
+	if _, err := h.buscarPaciente(entrada.PacienteID); err != nil {
+		if errors.Is(err, errNoEncontrado) {
+			return solicitudInvalida("el paciente %d no existe", entrada.PacienteID)
+		}
+		return err
+	}
+	if entrada.ProfesionalID != 0 {
+		profesional, err := h.profesionalRepo.ObtenerPorID(entrada.ProfesionalID)
+		if err != nil {
+			return err
+		}
+		if profesional == nil {
+			return solicitudInvalida("el profesional %d no existe", entrada.ProfesionalID)
+		}
+	}
+	fecha, err := parsearFecha(entrada.Fecha)
+	if err != nil {
+		return err
+	}
+	hora, err := models.ParsearHora(entrada.Hora)
+	if err != nil {
+		return solicitudInvalida("%v", err)
+	}
+	if entrada.Duracion < 0 {
+		return solicitudInvalida("la duración no puede ser negativa")
+	}
+	if entrada.Estado != "" {
+		return solicitudInvalida("el estado se cambia con PUT /turnos/{id}/estado")
+	}
+
+	turno.PacienteID = entrada.PacienteID
+	turno.Fecha = fecha
+	turno.Hora = hora
+	turno.Motivo = entrada.Motivo
+	turno.Notas = entrada.Notas
+	if entrada.ProfesionalID != 0 {
+		turno.ProfesionalID = entrada.ProfesionalID
+	}
+	if entrada.Duracion != 0 {
+		turno.Duracion = entrada.Duracion
+	} else if turno.Duracion == 0 {
+		turno.Duracion = 30
+	}
+	if turno.Estado == "" {
+		turno.Estado = models.EstadoPendiente
+	}
+	turno.Paciente = nil
+	return nil
+}
+
+func aplicarPaciente(paciente *models.Paciente, entrada pacienteEntrada) error {
+	nombre := strings.TrimSpace(entrada.Nombre)
+	if nombre == "" {
+		return solicitudInvalida("el nombre es obligatorio")
+	}
+	paciente.Nombre = nombre
+	paciente.Telefono = strings.TrimSpace(entrada.Telefono)
+	paciente.Email = strings.TrimSpace(entrada.Email)
+	paciente.Notas = entrada.Notas
+	return nil
+}
+
+func enteroOpcional(valor string, porDefecto int) (int, error) {
+	if valor == "" {
+		return porDefecto, nil
+	}
+	n, err := strconv.Atoi(valor)
+	if err != nil || n <= 0 {
+		return 0, solicitudInvalida("valor numérico inválido: %q", valor)
+	}
+	return n, nil
+}
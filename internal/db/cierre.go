@@ -0,0 +1,117 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+type CierreRepo struct {
+	db *DB
+}
+
+func NewCierreRepo(db *DB) *CierreRepo {
+	return &CierreRepo{db: db}
+}
+
+func (r *CierreRepo) Crear(cierre *models.Cierre) error {
+	query := `
+		INSERT INTO cierres (profesional_id, desde, hasta, motivo)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, created_at
+	`
+
+	return r.db.ejecutor().QueryRow(
+		query,
+		cierre.ProfesionalID,
+		cierre.Desde.Format(formatoFecha),
+		cierre.Hasta.Format(formatoFecha),
+		cierre.Motivo,
+	).Scan(&cierre.ID, &cierre.CreatedAt)
+}
+
+func (r *CierreRepo) ObtenerPorID(id int64) (*models.Cierre, error) {
+	query := `SELECT ` + columnasCierre + ` FROM cierres WHERE id = ?`
+
+	cierre, err := scanCierre(r.db.ejecutor().QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return cierre, nil
+}
+
+// ListarPorRango devuelve los cierres que se superponen con el rango de
+// desde a hasta. Una fecha cero deja ese extremo del rango abierto.
+func (r *CierreRepo) ListarPorRango(desde, hasta time.Time) ([]models.Cierre, error) {
+	query := `SELECT ` + columnasCierre + `
+		FROM cierres
+		WHERE desde <= ? AND hasta >= ?
+		ORDER BY desde, id
+	`
+
+	rows, err := r.db.ejecutor().Query(query, formatoHasta(hasta), formatoDesde(desde))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanRows(rows)
+}
+
+// ListarPorProfesional devuelve los cierres que alcanzan al profesional en el
+// rango: los propios y los de todo el consultorio.
+func (r *CierreRepo) ListarPorProfesional(profesionalID int64, desde, hasta time.Time) ([]models.Cierre, error) {
+	query := `SELECT ` + columnasCierre + `
+		FROM cierres
+		WHERE profesional_id IN (0, ?) AND desde <= ? AND hasta >= ?
+		ORDER BY desde, id
+	`
+
+	rows, err := r.db.ejecutor().Query(query, profesionalID, formatoHasta(hasta), formatoDesde(desde))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanRows(rows)
+}
+
+func (r *CierreRepo) Eliminar(id int64) error {
+	query := `DELETE FROM cierres WHERE id = ?`
+	_, err := r.db.ejecutor().Exec(query, id)
+	return err
+}
+
+func (r *CierreRepo) scanRows(rows *sql.Rows) ([]models.Cierre, error) {
+	var cierres []models.Cierre
+
+	for rows.Next() {
+		cierre, err := scanCierre(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error escaneando cierre: %w", err)
+		}
+		cierres = append(cierres, *cierre)
+	}
+
+	return cierres, rows.Err()
+}
+
+const columnasCierre = `id, profesional_id, desde, hasta, motivo, created_at`
+
+func scanCierre(fila escaner) (*models.Cierre, error) {
+	cierre := &models.Cierre{}
+	err := fila.Scan(
+		&cierre.ID, &cierre.ProfesionalID, columnaFecha{&cierre.Desde}, columnaFecha{&cierre.Hasta},
+		&cierre.Motivo, &cierre.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cierre, nil
+}
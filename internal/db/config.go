@@ -1,9 +1,18 @@
 package db
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"yoyaku/internal/models"
 )
 
+// mensajeReprogramacionPorDefecto se usa como DEFAULT de la columna, así
+// que no debe contener comillas simples.
+const mensajeReprogramacionPorDefecto = "Hola {nombre}, por un imprevisto tuvimos que mover su turno del {fecha_anterior} a las {hora_anterior}. El nuevo turno es el {fecha} a las {hora}. Por favor responda \"CONFIRMAR\" o \"CANCELAR\"."
+
 type ConfigRepo struct {
 	db *DB
 }
@@ -12,17 +21,32 @@ func NewConfigRepo(db *DB) *ConfigRepo {
 	return &ConfigRepo{db: db}
 }
 
+// Obtener devuelve la configuración del consultorio. HorarioAtencion se
+// arma a partir del horario laboral, que es el que usa la agenda.
 func (r *ConfigRepo) Obtener() (*models.Configuracion, error) {
+	config, err := r.obtenerGeneral()
+	if err != nil {
+		return nil, err
+	}
+	horario, err := r.ObtenerHorarioLaboral()
+	if err != nil {
+		return nil, err
+	}
+	config.HorarioAtencion = horario.Descripcion()
+	return config, nil
+}
+
+func (r *ConfigRepo) obtenerGeneral() (*models.Configuracion, error) {
 	query := `
 		SELECT id, nombre_consultorio, nombre_medico, telefono_consultorio, 
 		       direccion, mensaje_confirmacion, mensaje_recordatorio, 
-		       mensaje_demora, horario_atencion, updated_at
+		       mensaje_demora, mensaje_reprogramacion, zona_horaria, updated_at
 		FROM configuracion 
 		WHERE id = 1
 	`
 
 	config := &models.Configuracion{}
-	err := r.db.Conn().QueryRow(query).Scan(
+	err := r.db.ejecutor().QueryRow(query).Scan(
 		&config.ID,
 		&config.NombreConsultorio,
 		&config.NombreMedico,
@@ -31,28 +55,41 @@ func (r *ConfigRepo) Obtener() (*models.Configuracion, error) {
 		&config.MensajeConfirmacion,
 		&config.MensajeRecordatorio,
 		&config.MensajeDemora,
-		&config.HorarioAtencion,
+		&config.MensajeReprogramacion,
+		&config.ZonaHoraria,
 		&config.UpdatedAt,
 	)
 	if err != nil {
 		// Si no existe, crear configuración por defecto
 		config = &models.Configuracion{
-			ID:                  1,
-			NombreConsultorio:   "Consultorio Médico",
-			MensajeConfirmacion: "Hola {nombre}, le confirmamos su turno para el {fecha} a las {hora}. Por favor responda \"CONFIRMAR\" o \"CANCELAR\".",
-			MensajeRecordatorio: "Hola {nombre}, le recordamos su turno mañana {fecha} a las {hora}.",
-			MensajeDemora:       "Hola {nombre}, le informamos que el consultorio tiene {minutos} minutos de demora. Su turno será atendido lo antes posible.",
-			HorarioAtencion:     "Lunes a Viernes de 9:00 a 18:00",
+			ID:                    1,
+			NombreConsultorio:     "Consultorio Médico",
+			MensajeConfirmacion:   "Hola {nombre}, le confirmamos su turno para el {fecha} a las {hora}. Por favor responda \"CONFIRMAR\" o \"CANCELAR\".",
+			MensajeRecordatorio:   "Hola {nombre}, le recordamos su turno mañana {fecha} a las {hora}.",
+			MensajeDemora:         "Hola {nombre}, le informamos que el consultorio tiene {minutos} minutos de demora. Su turno será atendido lo antes posible.",
+			MensajeReprogramacion: mensajeReprogramacionPorDefecto,
+			ZonaHoraria:           models.ZonaHorariaPorDefecto,
 		}
-		// Insertar en la base de datos
+		// Insertar en la base de datos sin pisar las columnas que se
+		// configuran por separado, como el horario laboral
 		insertQuery := `
-			INSERT OR REPLACE INTO configuracion 
+			INSERT INTO configuracion 
 			(id, nombre_consultorio, nombre_medico, telefono_consultorio, 
 			 direccion, mensaje_confirmacion, mensaje_recordatorio, 
-			 mensaje_demora, horario_atencion)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 mensaje_demora, mensaje_reprogramacion, zona_horaria)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				nombre_consultorio = excluded.nombre_consultorio,
+				nombre_medico = excluded.nombre_medico,
+				telefono_consultorio = excluded.telefono_consultorio,
+				direccion = excluded.direccion,
+				mensaje_confirmacion = excluded.mensaje_confirmacion,
+				mensaje_recordatorio = excluded.mensaje_recordatorio,
+				mensaje_demora = excluded.mensaje_demora,
+				mensaje_reprogramacion = excluded.mensaje_reprogramacion,
+				zona_horaria = excluded.zona_horaria
 		`
-		_, insertErr := r.db.Conn().Exec(insertQuery,
+		_, insertErr := r.db.ejecutor().Exec(insertQuery,
 			config.ID,
 			config.NombreConsultorio,
 			config.NombreMedico,
@@ -61,7 +98,8 @@ func (r *ConfigRepo) Obtener() (*models.Configuracion, error) {
 			config.MensajeConfirmacion,
 			config.MensajeRecordatorio,
 			config.MensajeDemora,
-			config.HorarioAtencion,
+			config.MensajeReprogramacion,
+			config.ZonaHoraria,
 		)
 		if insertErr != nil {
 			return nil, insertErr
@@ -72,7 +110,19 @@ func (r *ConfigRepo) Obtener() (*models.Configuracion, error) {
 	return config, nil
 }
 
+// Guardar actualiza la configuración general. El horario de atención se
+// guarda con GuardarHorarioLaboral.
 func (r *ConfigRepo) Guardar(config *models.Configuracion) error {
+	if config.ZonaHoraria == "" {
+		config.ZonaHoraria = models.ZonaHorariaPorDefecto
+	}
+	if config.MensajeReprogramacion == "" {
+		config.MensajeReprogramacion = mensajeReprogramacionPorDefecto
+	}
+	if _, err := time.LoadLocation(config.ZonaHoraria); err != nil {
+		return fmt.Errorf("zona horaria inválida %q: %w", config.ZonaHoraria, err)
+	}
+
 	query := `
 		UPDATE configuracion SET
 			nombre_consultorio = ?,
@@ -82,12 +132,13 @@ func (r *ConfigRepo) Guardar(config *models.Configuracion) error {
 			mensaje_confirmacion = ?,
 			mensaje_recordatorio = ?,
 			mensaje_demora = ?,
-			horario_atencion = ?,
+			mensaje_reprogramacion = ?,
+			zona_horaria = ?,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = 1
 	`
 
-	_, err := r.db.Conn().Exec(
+	_, err := r.db.ejecutor().Exec(
 		query,
 		config.NombreConsultorio,
 		config.NombreMedico,
@@ -96,8 +147,126 @@ func (r *ConfigRepo) Guardar(config *models.Configuracion) error {
 		config.MensajeConfirmacion,
 		config.MensajeRecordatorio,
 		config.MensajeDemora,
-		config.HorarioAtencion,
+		config.MensajeReprogramacion,
+		config.ZonaHoraria,
 	)
 
 	return err
 }
+
+// ObtenerZonaHoraria devuelve la zona horaria configurada del consultorio.
+func (r *ConfigRepo) ObtenerZonaHoraria() (*time.Location, error) {
+	var nombre string
+	err := r.db.ejecutor().QueryRow(`SELECT zona_horaria FROM configuracion WHERE id = 1`).Scan(&nombre)
+	if err != nil {
+		return nil, err
+	}
+
+	zona, err := time.LoadLocation(nombre)
+	if err != nil {
+		return nil, fmt.Errorf("zona horaria inválida %q: %w", nombre, err)
+	}
+	return zona, nil
+}
+
+func (r *ConfigRepo) ObtenerServidor() (*models.ConfiguracionServidor, error) {
+	query := `SELECT servidor_habilitado, servidor_puerto, api_habilitada FROM configuracion WHERE id = 1`
+
+	config := &models.ConfiguracionServidor{}
+	err := r.db.ejecutor().QueryRow(query).Scan(&config.Habilitado, &config.Puerto, &config.APIHabilitada)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (r *ConfigRepo) GuardarServidor(config *models.ConfiguracionServidor) error {
+	query := `
+		UPDATE configuracion SET
+			servidor_habilitado = ?,
+			servidor_puerto = ?,
+			api_habilitada = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = 1
+	`
+
+	_, err := r.db.ejecutor().Exec(query, config.Habilitado, config.Puerto, config.APIHabilitada)
+	return err
+}
+
+func (r *ConfigRepo) ObtenerPuesto() (*models.ConfiguracionPuesto, error) {
+	query := `SELECT modo_puesto, anfitrion_direccion, clave_puesto FROM configuracion WHERE id = 1`
+
+	config := &models.ConfiguracionPuesto{}
+	err := r.db.ejecutor().QueryRow(query).Scan(&config.Modo, &config.DireccionAnfitrion, &config.Clave)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (r *ConfigRepo) GuardarPuesto(config *models.ConfiguracionPuesto) error {
+	query := `
+		UPDATE configuracion SET
+			modo_puesto = ?,
+			anfitrion_direccion = ?,
+			clave_puesto = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = 1
+	`
+
+	_, err := r.db.ejecutor().Exec(query, config.Modo, config.DireccionAnfitrion, config.Clave)
+	return err
+}
+
+func (r *ConfigRepo) ObtenerHorarioLaboral() (*models.HorarioLaboral, error) {
+	query := `SELECT hora_apertura, hora_cierre, dias_atencion FROM configuracion WHERE id = 1`
+
+	horario := &models.HorarioLaboral{}
+	var dias string
+	err := r.db.ejecutor().QueryRow(query).Scan(&horario.Apertura, &horario.Cierre, &dias)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range strings.Split(dias, ",") {
+		if d = strings.TrimSpace(d); d == "" {
+			continue
+		}
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 0 || n > 6 {
+			return nil, fmt.Errorf("día de atención inválido en la base de datos: %q", d)
+		}
+		horario.Dias = append(horario.Dias, time.Weekday(n))
+	}
+
+	return horario, nil
+}
+
+func (r *ConfigRepo) GuardarHorarioLaboral(horario *models.HorarioLaboral) error {
+	if !horario.Apertura.Valida() || !horario.Cierre.Valida() || horario.Cierre <= horario.Apertura {
+		return fmt.Errorf("el horario de cierre debe ser posterior al de apertura")
+	}
+
+	dias := make([]string, len(horario.Dias))
+	for i, d := range horario.Dias {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("día de atención inválido: %d", d)
+		}
+		dias[i] = strconv.Itoa(int(d))
+	}
+
+	query := `
+		UPDATE configuracion SET
+			hora_apertura = ?,
+			hora_cierre = ?,
+			dias_atencion = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = 1
+	`
+
+	_, err := r.db.ejecutor().Exec(query, horario.Apertura, horario.Cierre, strings.Join(dias, ","))
+	return err
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+func TestConfigRepo_ZonaHoraria(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewConfigRepo(database)
+	zona, err := repo.ObtenerZonaHoraria()
+	if err != nil {
+		t.Fatalf("ObtenerZonaHoraria() failed: %v", err)
+	}
+	if zona.String() != models.ZonaHorariaPorDefecto {
+		t.Errorf("zona por defecto = %s, want %s", zona, models.ZonaHorariaPorDefecto)
+	}
+
+	config, err := repo.Obtener()
+	if err != nil {
+		t.Fatalf("Obtener() failed: %v", err)
+	}
+	config.ZonaHoraria = "Marte/Olympus"
+	if err := repo.Guardar(config); err == nil {
+		t.Error("Guardar() con zona inválida should fail")
+	}
+
+	config.ZonaHoraria = "America/Montevideo"
+	if err := repo.Guardar(config); err != nil {
+		t.Fatalf("Guardar() failed: %v", err)
+	}
+	if zona, _ := repo.ObtenerZonaHoraria(); zona.String() != "America/Montevideo" {
+		t.Errorf("zona = %s, want America/Montevideo", zona)
+	}
+}
+
+func TestConfigRepo_HorarioLaboral(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewConfigRepo(database)
+	horario, err := repo.ObtenerHorarioLaboral()
+	if err != nil {
+		t.Fatalf("ObtenerHorarioLaboral() failed: %v", err)
+	}
+	if horario.Apertura != models.NuevaHora(9, 0) || horario.Cierre != models.NuevaHora(18, 0) || len(horario.Dias) != 5 {
+		t.Errorf("horario por defecto = %+v, want lunes a viernes de 9 a 18", horario)
+	}
+
+	horario = &models.HorarioLaboral{Apertura: models.NuevaHora(14, 0), Cierre: models.NuevaHora(8, 0)}
+	if err := repo.GuardarHorarioLaboral(horario); err == nil {
+		t.Error("GuardarHorarioLaboral() con cierre anterior a la apertura should fail")
+	}
+
+	horario = &models.HorarioLaboral{
+		Apertura: models.NuevaHora(8, 30),
+		Cierre:   models.NuevaHora(13, 0),
+		Dias:     []time.Weekday{time.Monday, time.Saturday},
+	}
+	if err := repo.GuardarHorarioLaboral(horario); err != nil {
+		t.Fatalf("GuardarHorarioLaboral() failed: %v", err)
+	}
+	guardado, err := repo.ObtenerHorarioLaboral()
+	if err != nil {
+		t.Fatalf("ObtenerHorarioLaboral() failed: %v", err)
+	}
+	if guardado.Apertura != horario.Apertura || guardado.Cierre != horario.Cierre || len(guardado.Dias) != 2 || guardado.Dias[1] != time.Saturday {
+		t.Errorf("horario guardado = %+v, want %+v", guardado, horario)
+	}
+
+	// La descripción sale siempre del horario laboral; el texto recibido al
+	// guardar la configuración se ignora.
+	config, err := repo.Obtener()
+	if err != nil {
+		t.Fatalf("Obtener() failed: %v", err)
+	}
+	config.HorarioAtencion = "Todos los días"
+	if err := repo.Guardar(config); err != nil {
+		t.Fatalf("Guardar() failed: %v", err)
+	}
+	config, err = repo.Obtener()
+	if err != nil {
+		t.Fatalf("Obtener() failed: %v", err)
+	}
+	if want := "Lunes y Sábado de 8:30 a 13:00"; config.HorarioAtencion != want {
+		t.Errorf("HorarioAtencion = %q, want %q", config.HorarioAtencion, want)
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 
+	"yoyaku/internal/models"
+	"yoyaku/internal/telefono"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -15,6 +18,15 @@ var schemaFS embed.FS
 
 type DB struct {
 	conn *sql.DB
+	tx   *sql.Tx
+}
+
+// ejecutor es lo que comparten *sql.DB y *sql.Tx, para que los repositorios
+// funcionen igual dentro y fuera de una transacción.
+type ejecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 func NewDB(dataDir string) (*DB, error) {
@@ -23,7 +35,9 @@ func NewDB(dataDir string) (*DB, error) {
 	}
 
 	dbPath := filepath.Join(dataDir, "yoyaku.db")
-	conn, err := sql.Open("sqlite", dbPath)
+	// busy_timeout evita errores "database is locked" cuando una transacción
+	// mantiene la base ocupada mientras otra conexión intenta escribir.
+	conn, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("error abriendo base de datos: %w", err)
 	}
@@ -51,9 +65,158 @@ func (d *DB) migrate() error {
 		return fmt.Errorf("error ejecutando schema: %w", err)
 	}
 
+	for _, c := range columnasAgregadas {
+		if err := d.agregarColumna(c); err != nil {
+			return err
+		}
+	}
+
+	if _, err := d.conn.Exec(postMigracion); err != nil {
+		return fmt.Errorf("error ejecutando post-migración: %w", err)
+	}
+
+	if err := d.reindexarTelefonos(); err != nil {
+		return fmt.Errorf("error reindexando teléfonos: %w", err)
+	}
+
 	return nil
 }
 
+// reindexarTelefonos corrige los teléfonos del índice de búsqueda que no
+// quedaron sólo con dígitos, como los indexados por versiones anteriores
+// o los cargados fuera de PacienteRepo.
+func (d *DB) reindexarTelefonos() error {
+	rows, err := d.conn.Query(`
+		SELECT p.id, p.telefono, f.telefono
+		FROM pacientes p JOIN pacientes_fts f ON f.rowid = p.id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	corregir := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var tel, indexado string
+		if err := rows.Scan(&id, &tel, &indexado); err != nil {
+			return err
+		}
+		if telefono.Digitos(tel) != indexado {
+			corregir[id] = tel
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if len(corregir) == 0 {
+		return nil
+	}
+	return d.Transaccion(func(tx *DB) error {
+		for id, tel := range corregir {
+			if err := indexarTelefono(tx, id, tel); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// columna es una columna agregada a una tabla existente. schema.sql sólo
+// crea tablas nuevas, así que las bases creadas con versiones anteriores
+// reciben las columnas nuevas con ALTER TABLE.
+type columna struct {
+	tabla      string
+	nombre     string
+	definicion string
+}
+
+var columnasAgregadas = []columna{
+	{"turnos", "profesional_id", "INTEGER NOT NULL DEFAULT 1"},
+	{"configuracion", "servidor_habilitado", "BOOLEAN DEFAULT 0"},
+	{"configuracion", "servidor_puerto", "INTEGER DEFAULT 8737"},
+	{"configuracion", "api_habilitada", "BOOLEAN DEFAULT 0"},
+	{"turnos", "hora_llegada", "DATETIME"},
+	{"turnos", "hora_inicio", "DATETIME"},
+	{"turnos", "hora_fin", "DATETIME"},
+	{"configuracion", "modo_puesto", "TEXT NOT NULL DEFAULT 'independiente'"},
+	{"configuracion", "anfitrion_direccion", "TEXT NOT NULL DEFAULT ''"},
+	{"configuracion", "clave_puesto", "TEXT NOT NULL DEFAULT ''"},
+	{"configuracion", "zona_horaria", "TEXT NOT NULL DEFAULT '" + models.ZonaHorariaPorDefecto + "'"},
+	{"configuracion", "hora_apertura", "TEXT NOT NULL DEFAULT '09:00'"},
+	{"configuracion", "hora_cierre", "TEXT NOT NULL DEFAULT '18:00'"},
+	{"configuracion", "dias_atencion", "TEXT NOT NULL DEFAULT '1,2,3,4,5'"},
+	{"configuracion", "mensaje_reprogramacion", "TEXT NOT NULL DEFAULT '" + mensajeReprogramacionPorDefecto + "'"},
+	{"licencias", "activacion", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// postMigracion se ejecuta después de agregar las columnas, para índices y
+// datos que dependen de ellas.
+const postMigracion = `
+	CREATE INDEX IF NOT EXISTS idx_turnos_profesional_fecha ON turnos(profesional_id, fecha);
+`
+
+func (d *DB) agregarColumna(c columna) error {
+	rows, err := d.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", c.tabla))
+	if err != nil {
+		return fmt.Errorf("error leyendo columnas de %s: %w", c.tabla, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var nombre, tipo string
+		var valorDefecto sql.NullString
+		if err := rows.Scan(&cid, &nombre, &tipo, &notNull, &valorDefecto, &pk); err != nil {
+			return err
+		}
+		if nombre == c.nombre {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = d.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.tabla, c.nombre, c.definicion))
+	if err != nil {
+		return fmt.Errorf("error agregando columna %s.%s: %w", c.tabla, c.nombre, err)
+	}
+	return nil
+}
+
+// Transaccion ejecuta fn con un DB ligado a una transacción: los repositorios
+// creados a partir de él escriben dentro de ella. Si fn devuelve un error se
+// revierten todos los cambios. Las llamadas anidadas reutilizan la
+// transacción en curso.
+func (d *DB) Transaccion(fn func(tx *DB) error) error {
+	if d.tx != nil {
+		return fn(d)
+	}
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("error iniciando transacción: %w", err)
+	}
+
+	if err := fn(&DB{conn: d.conn, tx: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *DB) ejecutor() ejecutor {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.conn
+}
+
 func (d *DB) Close() error {
 	return d.conn.Close()
 }
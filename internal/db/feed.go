@@ -0,0 +1,97 @@
+package db
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"yoyaku/internal/models"
+)
+
+type FeedRepo struct {
+	db *DB
+}
+
+func NewFeedRepo(db *DB) *FeedRepo {
+	return &FeedRepo{db: db}
+}
+
+// Crear genera un token aleatorio para el feed y lo guarda.
+func (r *FeedRepo) Crear(feed *models.FeedCalendario) error {
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("error generando token: %w", err)
+	}
+	feed.Token = hex.EncodeToString(token)
+
+	query := `
+		INSERT INTO feeds_calendario (profesional_id, token, descripcion, redactar)
+		VALUES (?, ?, ?, ?)
+		RETURNING id, created_at
+	`
+
+	return r.db.ejecutor().QueryRow(
+		query,
+		feed.ProfesionalID,
+		feed.Token,
+		feed.Descripcion,
+		feed.Redactar,
+	).Scan(&feed.ID, &feed.CreatedAt)
+}
+
+func (r *FeedRepo) ObtenerPorToken(token string) (*models.FeedCalendario, error) {
+	query := `
+		SELECT id, profesional_id, token, descripcion, redactar, created_at
+		FROM feeds_calendario
+		WHERE token = ?
+	`
+
+	feed := &models.FeedCalendario{}
+	var descripcion sql.NullString
+	err := r.db.ejecutor().QueryRow(query, token).Scan(
+		&feed.ID, &feed.ProfesionalID, &feed.Token, &descripcion, &feed.Redactar, &feed.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	feed.Descripcion = descripcion.String
+
+	return feed, nil
+}
+
+func (r *FeedRepo) ListarTodos() ([]models.FeedCalendario, error) {
+	query := `
+		SELECT id, profesional_id, token, descripcion, redactar, created_at
+		FROM feeds_calendario
+		ORDER BY id
+	`
+
+	rows, err := r.db.ejecutor().Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []models.FeedCalendario
+	for rows.Next() {
+		var feed models.FeedCalendario
+		var descripcion sql.NullString
+		if err := rows.Scan(&feed.ID, &feed.ProfesionalID, &feed.Token, &descripcion, &feed.Redactar, &feed.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error escaneando feed: %w", err)
+		}
+		feed.Descripcion = descripcion.String
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, rows.Err()
+}
+
+func (r *FeedRepo) Eliminar(id int64) error {
+	query := `DELETE FROM feeds_calendario WHERE id = ?`
+	_, err := r.db.ejecutor().Exec(query, id)
+	return err
+}
@@ -18,8 +18,8 @@ func (r *LicenseRepo) Obtener() (*models.Licencia, error) {
 	var licencia models.Licencia
 	var fechaActivacion, fechaExpiracion sql.NullTime
 
-	err := r.db.conn.QueryRow(`
-		SELECT id, license_key, fecha_activacion, fecha_expiracion, activa, version
+	err := r.db.ejecutor().QueryRow(`
+		SELECT id, license_key, fecha_activacion, fecha_expiracion, activa, version, activacion
 		FROM licencias
 		WHERE id = 1
 	`).Scan(
@@ -29,6 +29,7 @@ func (r *LicenseRepo) Obtener() (*models.Licencia, error) {
 		&fechaExpiracion,
 		&licencia.Activa,
 		&licencia.Version,
+		&licencia.Activacion,
 	)
 
 	if err == sql.ErrNoRows {
@@ -50,25 +51,204 @@ func (r *LicenseRepo) Obtener() (*models.Licencia, error) {
 }
 
 func (r *LicenseRepo) Guardar(licencia *models.Licencia) error {
-	_, err := r.db.conn.Exec(`
-		INSERT INTO licencias (id, license_key, fecha_activacion, fecha_expiracion, activa, version)
-		VALUES (1, ?, ?, ?, ?, ?)
+	_, err := r.db.ejecutor().Exec(`
+		INSERT INTO licencias (id, license_key, fecha_activacion, fecha_expiracion, activa, version, activacion)
+		VALUES (1, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			license_key = excluded.license_key,
 			fecha_activacion = excluded.fecha_activacion,
 			fecha_expiracion = excluded.fecha_expiracion,
 			activa = excluded.activa,
-			version = excluded.version
+			version = excluded.version,
+			activacion = excluded.activacion
 	`,
 		licencia.LicenseKey,
 		licencia.FechaActivacion,
 		licencia.FechaExpiracion,
 		licencia.Activa,
 		licencia.Version,
+		licencia.Activacion,
 	)
 	return err
 }
 
+// Aplicar guarda licencia como la vigente y registra aplicacion en el
+// historial, en una misma transacción.
+func (r *LicenseRepo) Aplicar(licencia *models.Licencia, aplicacion *models.LicenciaAplicada) error {
+	return r.db.Transaccion(func(tx *DB) error {
+		if err := NewLicenseRepo(tx).Guardar(licencia); err != nil {
+			return err
+		}
+		return tx.ejecutor().QueryRow(`
+			INSERT INTO licencias_aplicadas (licencia_id, license_key, cliente_id, edicion, meses, expiracion_anterior, expiracion_nueva, aplicada_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING id
+		`,
+			aplicacion.LicenciaID,
+			aplicacion.LicenseKey,
+			aplicacion.ClienteID,
+			aplicacion.Edicion,
+			aplicacion.Meses,
+			aplicacion.ExpiracionAnterior,
+			aplicacion.ExpiracionNueva,
+			aplicacion.AplicadaAt,
+		).Scan(&aplicacion.ID)
+	})
+}
+
+const columnasAplicacion = `id, licencia_id, license_key, cliente_id, edicion, meses, expiracion_anterior, expiracion_nueva, aplicada_at`
+
+// ObtenerAplicacion devuelve la aplicación de la licencia licenciaID, o nil
+// si nunca se aplicó.
+func (r *LicenseRepo) ObtenerAplicacion(licenciaID string) (*models.LicenciaAplicada, error) {
+	aplicacion, err := scanAplicacion(r.db.ejecutor().QueryRow(
+		`SELECT `+columnasAplicacion+` FROM licencias_aplicadas WHERE licencia_id = ?`, licenciaID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return aplicacion, err
+}
+
+// ListarAplicaciones devuelve el historial de licencias aplicadas, de la más
+// reciente a la más antigua.
+func (r *LicenseRepo) ListarAplicaciones() ([]models.LicenciaAplicada, error) {
+	rows, err := r.db.ejecutor().Query(
+		`SELECT ` + columnasAplicacion + ` FROM licencias_aplicadas ORDER BY aplicada_at DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aplicaciones []models.LicenciaAplicada
+	for rows.Next() {
+		aplicacion, err := scanAplicacion(rows)
+		if err != nil {
+			return nil, err
+		}
+		aplicaciones = append(aplicaciones, *aplicacion)
+	}
+	return aplicaciones, rows.Err()
+}
+
+func scanAplicacion(fila escaner) (*models.LicenciaAplicada, error) {
+	var a models.LicenciaAplicada
+	var anterior sql.NullTime
+	err := fila.Scan(&a.ID, &a.LicenciaID, &a.LicenseKey, &a.ClienteID, &a.Edicion, &a.Meses,
+		&anterior, &a.ExpiracionNueva, &a.AplicadaAt)
+	if err != nil {
+		return nil, err
+	}
+	if anterior.Valid {
+		a.ExpiracionAnterior = &anterior.Time
+	}
+	return &a, nil
+}
+
+// ObtenerPrueba devuelve la marca de inicio del período de prueba, o "" si
+// todavía no se guardó.
+func (r *LicenseRepo) ObtenerPrueba() (string, error) {
+	var marca string
+	err := r.db.ejecutor().QueryRow(`SELECT marca FROM prueba WHERE id = 1`).Scan(&marca)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return marca, err
+}
+
+func (r *LicenseRepo) GuardarPrueba(marca string) error {
+	_, err := r.db.ejecutor().Exec(`
+		INSERT INTO prueba (id, marca) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET marca = excluded.marca
+	`, marca)
+	return err
+}
+
+// ObtenerUltimoUso devuelve la marca del último uso registrado, o "" si
+// todavía no se guardó.
+func (r *LicenseRepo) ObtenerUltimoUso() (string, error) {
+	var marca string
+	err := r.db.ejecutor().QueryRow(`SELECT marca FROM ultimo_uso WHERE id = 1`).Scan(&marca)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return marca, err
+}
+
+func (r *LicenseRepo) GuardarUltimoUso(marca string) error {
+	_, err := r.db.ejecutor().Exec(`
+		INSERT INTO ultimo_uso (id, marca) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET marca = excluded.marca
+	`, marca)
+	return err
+}
+
+// PrimerRegistro devuelve cuándo se cargó el paciente o turno más antiguo, o
+// cero si todavía no hay ninguno.
+func (r *LicenseRepo) PrimerRegistro() (time.Time, error) {
+	var primero sql.NullString
+	err := r.db.ejecutor().QueryRow(`
+		SELECT MIN(created_at) FROM (
+			SELECT created_at FROM pacientes
+			UNION ALL
+			SELECT created_at FROM turnos
+		)
+	`).Scan(&primero)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return leerMarcaTiempo(primero)
+}
+
+// UltimoRegistro devuelve cuándo se creó o modificó por última vez un
+// paciente o turno, o cero si todavía no hay ninguno.
+func (r *LicenseRepo) UltimoRegistro() (time.Time, error) {
+	var ultimo sql.NullString
+	err := r.db.ejecutor().QueryRow(`
+		SELECT MAX(marca) FROM (
+			SELECT MAX(created_at, updated_at) AS marca FROM pacientes
+			UNION ALL
+			SELECT MAX(created_at, updated_at) FROM turnos
+		)
+	`).Scan(&ultimo)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return leerMarcaTiempo(ultimo)
+}
+
+// leerMarcaTiempo interpreta una marca de tiempo leída como texto, como las
+// que devuelve MIN o MAX sobre columnas DATETIME.
+func leerMarcaTiempo(marca sql.NullString) (time.Time, error) {
+	if !marca.Valid {
+		return time.Time{}, nil
+	}
+	if len(marca.String) >= len(formatoMarcaTiempo) {
+		if t, err := time.Parse(formatoMarcaTiempo, marca.String[:len(formatoMarcaTiempo)]); err == nil {
+			return t, nil
+		}
+	}
+	return time.Parse(time.RFC3339Nano, marca.String)
+}
+
+// ObtenerRevocaciones devuelve la última lista de revocación importada, o ""
+// si no se importó ninguna.
+func (r *LicenseRepo) ObtenerRevocaciones() (string, error) {
+	var lista string
+	err := r.db.ejecutor().QueryRow(`SELECT lista FROM revocaciones WHERE id = 1`).Scan(&lista)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return lista, err
+}
+
+func (r *LicenseRepo) GuardarRevocaciones(lista string) error {
+	_, err := r.db.ejecutor().Exec(`
+		INSERT INTO revocaciones (id, lista) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET lista = excluded.lista
+	`, lista)
+	return err
+}
+
 func (r *LicenseRepo) TieneLicenciaActiva() (bool, error) {
 	licencia, err := r.Obtener()
 	if err != nil {
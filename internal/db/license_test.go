@@ -185,6 +185,135 @@ func TestLicenseRepo_Guardar(t *testing.T) {
 	})
 }
 
+func TestLicenseRepo_Aplicar(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewLicenseRepo(db)
+
+	primera := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	renovacion := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	aplicaciones := []struct {
+		licencia   *models.Licencia
+		aplicacion *models.LicenciaAplicada
+	}{
+		{
+			&models.Licencia{LicenseKey: "YOY1.A.A", FechaActivacion: primera, FechaExpiracion: renovacion, Activa: true},
+			&models.LicenciaAplicada{LicenciaID: "lic-a", LicenseKey: "YOY1.A.A", ClienteID: "CLI-0001", Edicion: models.EdicionBasica, Meses: 12, ExpiracionNueva: renovacion, AplicadaAt: primera},
+		},
+		{
+			&models.Licencia{LicenseKey: "YOY1.B.B", FechaActivacion: primera, FechaExpiracion: renovacion.AddDate(1, 0, 0), Activa: true},
+			&models.LicenciaAplicada{LicenciaID: "lic-b", LicenseKey: "YOY1.B.B", ClienteID: "CLI-0001", Edicion: models.EdicionPro, Meses: 12, ExpiracionAnterior: &renovacion, ExpiracionNueva: renovacion.AddDate(1, 0, 0), AplicadaAt: renovacion},
+		},
+	}
+	for _, a := range aplicaciones {
+		if err := repo.Aplicar(a.licencia, a.aplicacion); err != nil {
+			t.Fatalf("Aplicar(%s) failed: %v", a.aplicacion.LicenciaID, err)
+		}
+		if a.aplicacion.ID == 0 {
+			t.Error("Aplicar() should set the ID")
+		}
+	}
+
+	licencia, err := repo.Obtener()
+	if err != nil || licencia.LicenseKey != "YOY1.B.B" {
+		t.Errorf("Obtener() = %+v, %v; want la última aplicada", licencia, err)
+	}
+
+	aplicada, err := repo.ObtenerAplicacion("lic-b")
+	if err != nil || aplicada == nil {
+		t.Fatalf("ObtenerAplicacion() = %v, %v", aplicada, err)
+	}
+	if aplicada.ExpiracionAnterior == nil || !aplicada.ExpiracionAnterior.Equal(renovacion) || aplicada.Edicion != models.EdicionPro {
+		t.Errorf("ObtenerAplicacion() = %+v, want renovación desde %v", aplicada, renovacion)
+	}
+	if aplicada, err := repo.ObtenerAplicacion("lic-x"); err != nil || aplicada != nil {
+		t.Errorf("ObtenerAplicacion(inexistente) = %v, %v; want nil", aplicada, err)
+	}
+
+	historial, err := repo.ListarAplicaciones()
+	if err != nil || len(historial) != 2 || historial[0].LicenciaID != "lic-b" || historial[1].ExpiracionAnterior != nil {
+		t.Errorf("ListarAplicaciones() = %+v, %v; want lic-b y lic-a", historial, err)
+	}
+
+	// Una licencia no se registra dos veces, y el fallo no toca la vigente.
+	repetida := *aplicaciones[0].aplicacion
+	if err := repo.Aplicar(aplicaciones[0].licencia, &repetida); err == nil {
+		t.Error("Aplicar() of an applied license should fail")
+	}
+	if licencia, _ := repo.Obtener(); licencia.LicenseKey != "YOY1.B.B" {
+		t.Errorf("Obtener() after failed Aplicar = %v, want YOY1.B.B", licencia.LicenseKey)
+	}
+}
+
+func TestLicenseRepo_Prueba(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewLicenseRepo(db)
+
+	if marca, err := repo.ObtenerPrueba(); err != nil || marca != "" {
+		t.Errorf("ObtenerPrueba() = %q, %v; want vacía", marca, err)
+	}
+	for _, want := range []string{"2025-03-10T15:00:00Z.AAAA", "2025-03-01T09:00:00Z.BBBB"} {
+		if err := repo.GuardarPrueba(want); err != nil {
+			t.Fatalf("GuardarPrueba() failed: %v", err)
+		}
+		if marca, err := repo.ObtenerPrueba(); err != nil || marca != want {
+			t.Errorf("ObtenerPrueba() = %q, %v; want %q", marca, err, want)
+		}
+	}
+}
+
+func TestLicenseRepo_Registros(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewLicenseRepo(db)
+
+	if primero, err := repo.PrimerRegistro(); err != nil || !primero.IsZero() {
+		t.Errorf("PrimerRegistro() = %v, %v; want cero sin datos", primero, err)
+	}
+	if ultimo, err := repo.UltimoRegistro(); err != nil || !ultimo.IsZero() {
+		t.Errorf("UltimoRegistro() = %v, %v; want cero sin datos", ultimo, err)
+	}
+	for _, fechas := range [][2]string{
+		{"2025-05-02 10:00:00", "2025-07-20 09:30:00"},
+		{"2025-03-10 15:00:00", "2025-03-10 15:00:00"},
+	} {
+		if _, err := db.Conn().Exec(`INSERT INTO pacientes (nombre, telefono, created_at, updated_at) VALUES ('Ana', '1155550000', ?, ?)`, fechas[0], fechas[1]); err != nil {
+			t.Fatalf("insert paciente: %v", err)
+		}
+	}
+	want := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	if primero, err := repo.PrimerRegistro(); err != nil || !primero.Equal(want) {
+		t.Errorf("PrimerRegistro() = %v, %v; want %v", primero, err, want)
+	}
+	want = time.Date(2025, 7, 20, 9, 30, 0, 0, time.UTC)
+	if ultimo, err := repo.UltimoRegistro(); err != nil || !ultimo.Equal(want) {
+		t.Errorf("UltimoRegistro() = %v, %v; want %v", ultimo, err, want)
+	}
+}
+
+func TestLicenseRepo_UltimoUso(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewLicenseRepo(db)
+
+	if marca, err := repo.ObtenerUltimoUso(); err != nil || marca != "" {
+		t.Errorf("ObtenerUltimoUso() = %q, %v; want vacía", marca, err)
+	}
+	for _, want := range []string{"2025-03-10T15:00:00Z.AAAA", "2025-03-10T15:15:00Z.BBBB"} {
+		if err := repo.GuardarUltimoUso(want); err != nil {
+			t.Fatalf("GuardarUltimoUso() failed: %v", err)
+		}
+		if marca, err := repo.ObtenerUltimoUso(); err != nil || marca != want {
+			t.Errorf("ObtenerUltimoUso() = %q, %v; want %q", marca, err, want)
+		}
+	}
+}
+
 func TestLicenseRepo_TieneLicenciaActiva(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"yoyaku/internal/models"
+)
+
+type MensajeRepo struct {
+	db *DB
+}
+
+func NewMensajeRepo(db *DB) *MensajeRepo {
+	return &MensajeRepo{db: db}
+}
+
+func (r *MensajeRepo) Crear(mensaje *models.Mensaje) error {
+	query := `
+		INSERT INTO mensajes (paciente_id, turno_id, telefono, texto, estado)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at
+	`
+
+	if mensaje.Estado == "" {
+		mensaje.Estado = models.MensajePendiente
+	}
+
+	return r.db.ejecutor().QueryRow(
+		query,
+		mensaje.PacienteID,
+		mensaje.TurnoID,
+		mensaje.Telefono,
+		mensaje.Texto,
+		string(mensaje.Estado),
+	).Scan(&mensaje.ID, &mensaje.CreatedAt)
+}
+
+// ListarPorEstado devuelve los mensajes en el estado indicado, los más
+// antiguos primero.
+func (r *MensajeRepo) ListarPorEstado(estado models.EstadoMensaje) ([]models.Mensaje, error) {
+	query := `
+		SELECT id, paciente_id, turno_id, telefono, texto, estado, enviado_at, created_at
+		FROM mensajes
+		WHERE estado = ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.ejecutor().Query(query, string(estado))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mensajes []models.Mensaje
+	for rows.Next() {
+		var m models.Mensaje
+		var turnoID sql.NullInt64
+		var enviado sql.NullTime
+		err := rows.Scan(&m.ID, &m.PacienteID, &turnoID, &m.Telefono, &m.Texto, &m.Estado, &enviado, &m.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error escaneando mensaje: %w", err)
+		}
+		m.TurnoID = turnoID.Int64
+		m.EnviadoAt = horarioOpcional(enviado)
+		mensajes = append(mensajes, m)
+	}
+
+	return mensajes, rows.Err()
+}
+
+// CambiarEstado marca el mensaje como enviado o descartado. Al marcarlo
+// enviado se registra el momento.
+func (r *MensajeRepo) CambiarEstado(id int64, estado models.EstadoMensaje) error {
+	switch estado {
+	case models.MensajePendiente, models.MensajeEnviado, models.MensajeDescartado:
+	default:
+		return fmt.Errorf("estado de mensaje inválido: %q", estado)
+	}
+
+	query := `
+		UPDATE mensajes
+		SET estado = ?, enviado_at = CASE WHEN ? = 'enviado' THEN CURRENT_TIMESTAMP ELSE enviado_at END
+		WHERE id = ?
+	`
+	_, err := r.db.ejecutor().Exec(query, string(estado), string(estado), id)
+	return err
+}
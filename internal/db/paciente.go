@@ -2,10 +2,13 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"strings"
 	"time"
+	"unicode"
 
 	"yoyaku/internal/models"
+	"yoyaku/internal/telefono"
 )
 
 type PacienteRepo struct {
@@ -23,20 +26,48 @@ func (r *PacienteRepo) Crear(paciente *models.Paciente) error {
 		RETURNING id, created_at, updated_at
 	`
 
-	return r.db.Conn().QueryRow(
-		query,
-		paciente.Nombre,
-		paciente.Telefono,
-		paciente.Email,
-		paciente.Notas,
-	).Scan(&paciente.ID, &paciente.CreatedAt, &paciente.UpdatedAt)
+	return r.db.Transaccion(func(tx *DB) error {
+		err := tx.ejecutor().QueryRow(
+			query,
+			paciente.Nombre,
+			paciente.Telefono,
+			paciente.Email,
+			paciente.Notas,
+		).Scan(&paciente.ID, &paciente.CreatedAt, &paciente.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return indexarTelefono(tx, paciente.ID, paciente.Telefono)
+	})
+}
+
+// indexarTelefono guarda en el índice de búsqueda sólo los dígitos del
+// teléfono, la misma forma con la que se busca. Los triggers sólo quitan los
+// separadores más comunes.
+func indexarTelefono(d *DB, pacienteID int64, tel string) error {
+	_, err := d.ejecutor().Exec(`UPDATE pacientes_fts SET telefono = ? WHERE rowid = ?`, telefono.Digitos(tel), pacienteID)
+	return err
+}
+
+// CrearLote crea todos los pacientes en una única transacción: si alguno
+// falla no se guarda ninguno.
+func (r *PacienteRepo) CrearLote(pacientes []*models.Paciente) error {
+	return r.db.Transaccion(func(tx *DB) error {
+		repo := NewPacienteRepo(tx)
+		for _, paciente := range pacientes {
+			if err := repo.Crear(paciente); err != nil {
+				return fmt.Errorf("error creando paciente %q: %w", paciente.Nombre, err)
+			}
+		}
+		return nil
+	})
 }
 
 func (r *PacienteRepo) ObtenerPorID(id int64) (*models.Paciente, error) {
 	query := `SELECT id, nombre, telefono, email, notas, created_at, updated_at FROM pacientes WHERE id = ?`
 
 	paciente := &models.Paciente{}
-	err := r.db.Conn().QueryRow(query, id).Scan(
+	err := r.db.ejecutor().QueryRow(query, id).Scan(
 		&paciente.ID, &paciente.Nombre, &paciente.Telefono, &paciente.Email,
 		&paciente.Notas, &paciente.CreatedAt, &paciente.UpdatedAt,
 	)
@@ -50,29 +81,86 @@ func (r *PacienteRepo) ObtenerPorID(id int64) (*models.Paciente, error) {
 	return paciente, nil
 }
 
-func (r *PacienteRepo) Buscar(termino string) ([]models.Paciente, error) {
-	query := `
-		SELECT id, nombre, telefono, email, notas, created_at, updated_at 
-		FROM pacientes 
-		WHERE nombre LIKE ? OR telefono LIKE ?
-		ORDER BY nombre
-		LIMIT 20
-	`
+const porPaginaBusqueda = 20
 
-	terminoBusqueda := "%" + strings.ToLower(termino) + "%"
-	rows, err := r.db.Conn().Query(query, terminoBusqueda, terminoBusqueda)
+// Buscar devuelve la primera página de resultados de BuscarPaginado.
+func (r *PacienteRepo) Buscar(termino string) ([]models.Paciente, error) {
+	resultado, err := r.BuscarPaginado(termino, 1, porPaginaBusqueda)
 	if err != nil {
 		return nil, err
 	}
+	return resultado.Pacientes, nil
+}
+
+// BuscarPaginado busca pacientes en el índice FTS5 ignorando mayúsculas y
+// acentos. Si el término sólo contiene dígitos y signos se compara contra los
+// dígitos del teléfono, sin importar el formato con el que fue cargado.
+func (r *PacienteRepo) BuscarPaginado(termino string, pagina, porPagina int) (*models.ResultadoBusquedaPacientes, error) {
+	if pagina < 1 {
+		pagina = 1
+	}
+	if porPagina < 1 {
+		porPagina = porPaginaBusqueda
+	}
+
+	var filtro, orden string
+	var args []interface{}
+
+	tokens := tokensBusqueda(termino)
+	switch {
+	case len(tokens) == 0:
+		filtro = "1 = 1"
+		orden = "p.nombre"
+	case esBusquedaTelefono(termino):
+		filtro = "f.telefono LIKE ?"
+		orden = "p.nombre"
+		args = append(args, "%"+telefono.Digitos(termino)+"%")
+	default:
+		filtro = "pacientes_fts MATCH ?"
+		orden = "bm25(pacientes_fts), p.nombre"
+		args = append(args, expresionMatch(tokens))
+	}
+
+	from := `
+		FROM pacientes_fts f
+		JOIN pacientes p ON p.id = f.rowid
+		WHERE ` + filtro
+
+	resultado := &models.ResultadoBusquedaPacientes{
+		Pacientes: []models.Paciente{},
+		Pagina:    pagina,
+		PorPagina: porPagina,
+	}
+
+	if err := r.db.ejecutor().QueryRow("SELECT COUNT(*) "+from, args...).Scan(&resultado.Total); err != nil {
+		return nil, fmt.Errorf("error contando pacientes: %w", err)
+	}
+
+	query := `SELECT p.id, p.nombre, p.telefono, p.email, p.notas, p.created_at, p.updated_at ` +
+		from + ` ORDER BY ` + orden + ` LIMIT ? OFFSET ?`
+	args = append(args, porPagina, (pagina-1)*porPagina)
+
+	rows, err := r.db.ejecutor().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error buscando pacientes: %w", err)
+	}
 	defer rows.Close()
 
-	return r.scanRows(rows)
+	pacientes, err := r.scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	if pacientes != nil {
+		resultado.Pacientes = pacientes
+	}
+
+	return resultado, nil
 }
 
 func (r *PacienteRepo) ListarTodos() ([]models.Paciente, error) {
 	query := `SELECT id, nombre, telefono, email, notas, created_at, updated_at FROM pacientes ORDER BY nombre`
 
-	rows, err := r.db.Conn().Query(query)
+	rows, err := r.db.ejecutor().Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -87,20 +175,25 @@ func (r *PacienteRepo) Actualizar(paciente *models.Paciente) error {
 		SET nombre = ?, telefono = ?, email = ?, notas = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
-	_, err := r.db.Conn().Exec(
-		query,
-		paciente.Nombre,
-		paciente.Telefono,
-		paciente.Email,
-		paciente.Notas,
-		paciente.ID,
-	)
-	return err
+	return r.db.Transaccion(func(tx *DB) error {
+		_, err := tx.ejecutor().Exec(
+			query,
+			paciente.Nombre,
+			paciente.Telefono,
+			paciente.Email,
+			paciente.Notas,
+			paciente.ID,
+		)
+		if err != nil {
+			return err
+		}
+		return indexarTelefono(tx, paciente.ID, paciente.Telefono)
+	})
 }
 
 func (r *PacienteRepo) Eliminar(id int64) error {
 	query := `DELETE FROM pacientes WHERE id = ?`
-	_, err := r.db.Conn().Exec(query, id)
+	_, err := r.db.ejecutor().Exec(query, id)
 	return err
 }
 
@@ -111,7 +204,7 @@ func (r *PacienteRepo) TieneNoShowReciente(pacienteID int64, meses int) (bool, e
 	`
 
 	var count int
-	err := r.db.Conn().QueryRow(query, pacienteID).Scan(&count)
+	err := r.db.ejecutor().QueryRow(query, pacienteID).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -124,10 +217,38 @@ func (r *PacienteRepo) RegistrarNoShow(pacienteID, turnoID int64, fecha time.Tim
 		INSERT INTO historial_no_shows (paciente_id, turno_id, fecha)
 		VALUES (?, ?, ?)
 	`
-	_, err := r.db.Conn().Exec(query, pacienteID, turnoID, fecha.Format("2006-01-02"))
+	_, err := r.db.ejecutor().Exec(query, pacienteID, turnoID, fecha.Format(formatoFecha))
 	return err
 }
 
+// ListarNoShows devuelve el historial de ausencias entre desde y hasta
+// inclusive. Una fecha cero deja ese extremo del rango abierto.
+func (r *PacienteRepo) ListarNoShows(desde, hasta time.Time) ([]models.HistorialNoShow, error) {
+	query := `
+		SELECT id, paciente_id, turno_id, fecha, created_at
+		FROM historial_no_shows
+		WHERE fecha BETWEEN ? AND ?
+		ORDER BY fecha, id
+	`
+
+	rows, err := r.db.ejecutor().Query(query, formatoDesde(desde), formatoHasta(hasta))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var historial []models.HistorialNoShow
+	for rows.Next() {
+		var h models.HistorialNoShow
+		if err := rows.Scan(&h.ID, &h.PacienteID, &h.TurnoID, columnaFecha{&h.Fecha}, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error escaneando no-show: %w", err)
+		}
+		historial = append(historial, h)
+	}
+
+	return historial, rows.Err()
+}
+
 func (r *PacienteRepo) scanRows(rows *sql.Rows) ([]models.Paciente, error) {
 	var pacientes []models.Paciente
 
@@ -145,3 +266,36 @@ func (r *PacienteRepo) scanRows(rows *sql.Rows) ([]models.Paciente, error) {
 
 	return pacientes, rows.Err()
 }
+
+// tokensBusqueda separa el término en palabras, descartando signos de
+// puntuación que no forman parte del índice.
+func tokensBusqueda(termino string) []string {
+	return strings.FieldsFunc(termino, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// esBusquedaTelefono indica si el término parece un teléfono: tiene dígitos y
+// ninguna letra.
+func esBusquedaTelefono(termino string) bool {
+	tieneDigitos := false
+	for _, r := range termino {
+		if unicode.IsLetter(r) {
+			return false
+		}
+		if unicode.IsDigit(r) {
+			tieneDigitos = true
+		}
+	}
+	return tieneDigitos
+}
+
+// expresionMatch arma una consulta FTS5 que exige todas las palabras como
+// prefijo sobre nombre y email.
+func expresionMatch(tokens []string) string {
+	partes := make([]string, len(tokens))
+	for i, token := range tokens {
+		partes[i] = `"` + strings.ReplaceAll(token, `"`, `""`) + `"*`
+	}
+	return "{nombre email} : (" + strings.Join(partes, " ") + ")"
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"testing"
+
+	"yoyaku/internal/models"
+)
+
+func crearPacientesDePrueba(t *testing.T, repo *PacienteRepo) []models.Paciente {
+	t.Helper()
+
+	pacientes := []models.Paciente{
+		{Nombre: "María González", Telefono: "+54 11 1234-5678", Email: "maria@email.com"},
+		{Nombre: "Juan Pérez", Telefono: "(011) 4433.2211"},
+		{Nombre: "Mariano Gómez", Telefono: "11 3456 7890"},
+	}
+	for i := range pacientes {
+		if err := repo.Crear(&pacientes[i]); err != nil {
+			t.Fatalf("Crear() failed: %v", err)
+		}
+	}
+	return pacientes
+}
+
+func TestPacienteRepo_BuscarPaginado(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPacienteRepo(db)
+	crearPacientesDePrueba(t, repo)
+
+	tests := []struct {
+		name      string
+		termino   string
+		wantTotal int
+		wantFirst string
+	}{
+		{"Sin acentos", "Gonzalez", 1, "María González"},
+		{"Con acentos y mayúsculas", "PÉREZ", 1, "Juan Pérez"},
+		{"Prefijo", "mari", 2, ""},
+		{"Varias palabras", "maria gonz", 1, "María González"},
+		{"Teléfono con otro formato", "1234 5678", 1, "María González"},
+		{"Teléfono parcial", "4433-22", 1, "Juan Pérez"},
+		{"Email", "maria@email", 1, "María González"},
+		{"Término vacío", "  ", 3, "Juan Pérez"},
+		{"Sólo signos", "\"*", 3, "Juan Pérez"},
+		{"Sin resultados", "Rodríguez", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resultado, err := repo.BuscarPaginado(tt.termino, 1, 10)
+			if err != nil {
+				t.Fatalf("BuscarPaginado() error = %v", err)
+			}
+			if resultado.Total != tt.wantTotal {
+				t.Errorf("Total = %d, want %d", resultado.Total, tt.wantTotal)
+			}
+			if len(resultado.Pacientes) != tt.wantTotal {
+				t.Errorf("len(Pacientes) = %d, want %d", len(resultado.Pacientes), tt.wantTotal)
+			}
+			if tt.wantFirst != "" && len(resultado.Pacientes) > 0 && resultado.Pacientes[0].Nombre != tt.wantFirst {
+				t.Errorf("Primer resultado = %s, want %s", resultado.Pacientes[0].Nombre, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestPacienteRepo_BuscarPaginado_Paginas(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPacienteRepo(db)
+	crearPacientesDePrueba(t, repo)
+
+	primera, err := repo.BuscarPaginado("", 1, 2)
+	if err != nil {
+		t.Fatalf("BuscarPaginado() error = %v", err)
+	}
+	segunda, err := repo.BuscarPaginado("", 2, 2)
+	if err != nil {
+		t.Fatalf("BuscarPaginado() error = %v", err)
+	}
+
+	if primera.Total != 3 || segunda.Total != 3 {
+		t.Errorf("Total = %d/%d, want 3", primera.Total, segunda.Total)
+	}
+	if len(primera.Pacientes) != 2 || len(segunda.Pacientes) != 1 {
+		t.Errorf("Páginas de %d y %d pacientes, want 2 y 1", len(primera.Pacientes), len(segunda.Pacientes))
+	}
+}
+
+func TestPacienteRepo_IndiceSincronizado(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPacienteRepo(db)
+	pacientes := crearPacientesDePrueba(t, repo)
+
+	juan := pacientes[1]
+	juan.Nombre = "Juan Ibáñez"
+	if err := repo.Actualizar(&juan); err != nil {
+		t.Fatalf("Actualizar() failed: %v", err)
+	}
+
+	encontrados, err := repo.Buscar("ibanez")
+	if err != nil {
+		t.Fatalf("Buscar() error = %v", err)
+	}
+	if len(encontrados) != 1 {
+		t.Errorf("Buscar(ibanez) = %d resultados, want 1", len(encontrados))
+	}
+
+	encontrados, err = repo.Buscar("perez")
+	if err != nil {
+		t.Fatalf("Buscar() error = %v", err)
+	}
+	if len(encontrados) != 0 {
+		t.Errorf("Buscar(perez) después de actualizar = %d resultados, want 0", len(encontrados))
+	}
+
+	if err := repo.Eliminar(pacientes[0].ID); err != nil {
+		t.Fatalf("Eliminar() failed: %v", err)
+	}
+	encontrados, err = repo.Buscar("gonzalez")
+	if err != nil {
+		t.Fatalf("Buscar() error = %v", err)
+	}
+	if len(encontrados) != 0 {
+		t.Errorf("Buscar(gonzalez) después de eliminar = %d resultados, want 0", len(encontrados))
+	}
+}
+
+func TestPacienteRepo_BuscarTelefonoConSeparadores(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewPacienteRepo(db)
+	paciente := models.Paciente{Nombre: "Ana López", Telefono: "011/4433_2211"}
+	if err := repo.Crear(&paciente); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+	buscar := func() int {
+		t.Helper()
+		encontrados, err := repo.Buscar("4433 2211")
+		if err != nil {
+			t.Fatalf("Buscar() error = %v", err)
+		}
+		return len(encontrados)
+	}
+	if n := buscar(); n != 1 {
+		t.Errorf("Buscar(4433 2211) = %d resultados, want 1", n)
+	}
+
+	// Un teléfono modificado fuera del repositorio queda indexado por los
+	// triggers y se corrige al migrar.
+	if _, err := db.Conn().Exec(`UPDATE pacientes SET telefono = '011/4433#2211' WHERE id = ?`, paciente.ID); err != nil {
+		t.Fatalf("UPDATE failed: %v", err)
+	}
+	if n := buscar(); n != 0 {
+		t.Fatalf("Buscar() antes de migrar = %d resultados, want 0", n)
+	}
+	if err := db.migrate(); err != nil {
+		t.Fatalf("migrate() failed: %v", err)
+	}
+	if n := buscar(); n != 1 {
+		t.Errorf("Buscar() después de migrar = %d resultados, want 1", n)
+	}
+}
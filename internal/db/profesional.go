@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"yoyaku/internal/models"
+)
+
+type ProfesionalRepo struct {
+	db *DB
+}
+
+func NewProfesionalRepo(db *DB) *ProfesionalRepo {
+	return &ProfesionalRepo{db: db}
+}
+
+func (r *ProfesionalRepo) Crear(profesional *models.Profesional) error {
+	query := `
+		INSERT INTO profesionales (nombre, especialidad, activo)
+		VALUES (?, ?, ?)
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.ejecutor().QueryRow(
+		query,
+		profesional.Nombre,
+		profesional.Especialidad,
+		profesional.Activo,
+	).Scan(&profesional.ID, &profesional.CreatedAt, &profesional.UpdatedAt)
+}
+
+func (r *ProfesionalRepo) ObtenerPorID(id int64) (*models.Profesional, error) {
+	query := `SELECT id, nombre, especialidad, activo, created_at, updated_at FROM profesionales WHERE id = ?`
+
+	profesional := &models.Profesional{}
+	var especialidad sql.NullString
+	err := r.db.ejecutor().QueryRow(query, id).Scan(
+		&profesional.ID, &profesional.Nombre, &especialidad, &profesional.Activo,
+		&profesional.CreatedAt, &profesional.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	profesional.Especialidad = especialidad.String
+
+	return profesional, nil
+}
+
+func (r *ProfesionalRepo) ListarTodos() ([]models.Profesional, error) {
+	query := `SELECT id, nombre, especialidad, activo, created_at, updated_at FROM profesionales ORDER BY id`
+
+	rows, err := r.db.ejecutor().Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profesionales []models.Profesional
+	for rows.Next() {
+		var p models.Profesional
+		var especialidad sql.NullString
+		if err := rows.Scan(&p.ID, &p.Nombre, &especialidad, &p.Activo, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error escaneando profesional: %w", err)
+		}
+		p.Especialidad = especialidad.String
+		profesionales = append(profesionales, p)
+	}
+
+	return profesionales, rows.Err()
+}
+
+func (r *ProfesionalRepo) Actualizar(profesional *models.Profesional) error {
+	query := `
+		UPDATE profesionales
+		SET nombre = ?, especialidad = ?, activo = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	_, err := r.db.ejecutor().Exec(
+		query,
+		profesional.Nombre,
+		profesional.Especialidad,
+		profesional.Activo,
+		profesional.ID,
+	)
+	return err
+}
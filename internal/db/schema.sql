@@ -9,6 +9,46 @@ CREATE TABLE IF NOT EXISTS pacientes (
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Índice de búsqueda de pacientes. Los nombres se indexan sin acentos y los
+-- teléfonos sólo con sus dígitos; los triggers lo mantienen sincronizado y
+-- PacienteRepo reemplaza el teléfono por telefono.Digitos, porque acá sólo
+-- se quitan los separadores más comunes.
+CREATE VIRTUAL TABLE IF NOT EXISTS pacientes_fts USING fts5(
+    nombre,
+    email,
+    telefono UNINDEXED,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS pacientes_fts_insert AFTER INSERT ON pacientes BEGIN
+    INSERT INTO pacientes_fts (rowid, nombre, email, telefono)
+    VALUES (
+        new.id, new.nombre, COALESCE(new.email, ''),
+        replace(replace(replace(replace(replace(replace(new.telefono, ' ', ''), '-', ''), '+', ''), '(', ''), ')', ''), '.', '')
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS pacientes_fts_update AFTER UPDATE ON pacientes BEGIN
+    DELETE FROM pacientes_fts WHERE rowid = old.id;
+    INSERT INTO pacientes_fts (rowid, nombre, email, telefono)
+    VALUES (
+        new.id, new.nombre, COALESCE(new.email, ''),
+        replace(replace(replace(replace(replace(replace(new.telefono, ' ', ''), '-', ''), '+', ''), '(', ''), ')', ''), '.', '')
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS pacientes_fts_delete AFTER DELETE ON pacientes BEGIN
+    DELETE FROM pacientes_fts WHERE rowid = old.id;
+END;
+
+-- Indexar pacientes existentes antes de la creación del índice
+INSERT INTO pacientes_fts (rowid, nombre, email, telefono)
+SELECT
+    id, nombre, COALESCE(email, ''),
+    replace(replace(replace(replace(replace(replace(telefono, ' ', ''), '-', ''), '+', ''), '(', ''), ')', ''), '.', '')
+FROM pacientes
+WHERE id NOT IN (SELECT rowid FROM pacientes_fts);
+
 -- Tabla de turnos
 CREATE TABLE IF NOT EXISTS turnos (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -45,6 +85,8 @@ CREATE TABLE IF NOT EXISTS configuracion (
     mensaje_confirmacion TEXT DEFAULT 'Hola {nombre}, le confirmamos su turno para el {fecha} a las {hora}. Por favor responda "CONFIRMAR" o "CANCELAR".',
     mensaje_recordatorio TEXT DEFAULT 'Hola {nombre}, le recordamos su turno mañana {fecha} a las {hora}.',
     mensaje_demora TEXT DEFAULT 'Hola {nombre}, le informamos que el consultorio tiene {minutos} minutos de demora. Su turno será atendido lo antes posible.',
+    -- Sin uso: el horario se configura en hora_apertura, hora_cierre y
+    -- dias_atencion, y la descripción se arma a partir de ellas.
     horario_atencion TEXT DEFAULT 'Lunes a Viernes de 9:00 a 18:00',
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -62,9 +104,106 @@ CREATE TABLE IF NOT EXISTS licencias (
     version TEXT DEFAULT '1.0.0'
 );
 
+-- Historial de licencias aplicadas: una misma licencia no se aplica dos veces
+CREATE TABLE IF NOT EXISTS licencias_aplicadas (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    licencia_id TEXT NOT NULL UNIQUE,
+    license_key TEXT NOT NULL,
+    cliente_id TEXT NOT NULL DEFAULT '',
+    edicion TEXT NOT NULL DEFAULT '',
+    meses INTEGER NOT NULL DEFAULT 0,
+    expiracion_anterior DATETIME,
+    expiracion_nueva DATETIME NOT NULL,
+    aplicada_at DATETIME NOT NULL
+);
+
+-- Inicio del período de prueba, firmado para detectar cambios. Una copia se
+-- guarda también fuera de la base de datos.
+CREATE TABLE IF NOT EXISTS prueba (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    marca TEXT NOT NULL
+);
+
+-- Última vez que se usó la aplicación, firmada como la marca de prueba, para
+-- detectar que el reloj del sistema se atrasó
+CREATE TABLE IF NOT EXISTS ultimo_uso (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    marca TEXT NOT NULL
+);
+
+-- Última lista de revocación importada, tal como la firmó el emisor
+CREATE TABLE IF NOT EXISTS revocaciones (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    lista TEXT NOT NULL
+);
+
+-- Tabla de profesionales. El profesional 1 es el principal y recibe los
+-- turnos que no indican otro.
+CREATE TABLE IF NOT EXISTS profesionales (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL,
+    especialidad TEXT,
+    activo BOOLEAN DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO profesionales (id, nombre)
+SELECT 1, COALESCE(NULLIF(nombre_medico, ''), 'Profesional principal') FROM configuracion WHERE id = 1;
+
+-- Feeds de calendario: cada token da acceso de sólo lectura a la agenda de
+-- un profesional
+CREATE TABLE IF NOT EXISTS feeds_calendario (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    profesional_id INTEGER NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    descripcion TEXT,
+    redactar BOOLEAN DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (profesional_id) REFERENCES profesionales(id) ON DELETE CASCADE
+);
+
+-- Tokens de la API local. Sólo se guarda el hash; el token se muestra una
+-- única vez al crearlo.
+CREATE TABLE IF NOT EXISTS tokens_api (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    nombre TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    prefijo TEXT NOT NULL,
+    ultimo_uso DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Cierres: períodos sin turnos. profesional_id 0 es un cierre de todo el
+-- consultorio.
+CREATE TABLE IF NOT EXISTS cierres (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    profesional_id INTEGER NOT NULL DEFAULT 0,
+    desde DATE NOT NULL,
+    hasta DATE NOT NULL,
+    motivo TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CHECK (desde <= hasta)
+);
+
+-- Mensajes a pacientes en cola para enviar por WhatsApp
+CREATE TABLE IF NOT EXISTS mensajes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    paciente_id INTEGER NOT NULL,
+    turno_id INTEGER,
+    telefono TEXT NOT NULL,
+    texto TEXT NOT NULL,
+    estado TEXT NOT NULL DEFAULT 'pendiente',
+    enviado_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (paciente_id) REFERENCES pacientes(id) ON DELETE CASCADE
+);
+
 -- Índices para búsquedas frecuentes
 CREATE INDEX IF NOT EXISTS idx_turnos_fecha ON turnos(fecha);
 CREATE INDEX IF NOT EXISTS idx_turnos_paciente ON turnos(paciente_id);
 CREATE INDEX IF NOT EXISTS idx_turnos_estado ON turnos(estado);
 CREATE INDEX IF NOT EXISTS idx_historial_paciente ON historial_no_shows(paciente_id);
 CREATE INDEX IF NOT EXISTS idx_historial_fecha ON historial_no_shows(fecha);
+CREATE INDEX IF NOT EXISTS idx_cierres_rango ON cierres(desde, hasta);
+CREATE INDEX IF NOT EXISTS idx_mensajes_estado ON mensajes(estado);
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"yoyaku/internal/models"
+)
+
+// prefijoTokenAPI identifica los tokens de yoyaku en logs y configuraciones.
+const prefijoTokenAPI = "yyk_"
+
+type TokenAPIRepo struct {
+	db *DB
+}
+
+func NewTokenAPIRepo(db *DB) *TokenAPIRepo {
+	return &TokenAPIRepo{db: db}
+}
+
+// Crear genera un token nuevo. El token en claro sólo queda en el campo
+// Token del resultado; en la base se guarda su hash.
+func (r *TokenAPIRepo) Crear(nombre string) (*models.TokenAPI, error) {
+	aleatorio := make([]byte, 24)
+	if _, err := rand.Read(aleatorio); err != nil {
+		return nil, fmt.Errorf("error generando token: %w", err)
+	}
+
+	token := &models.TokenAPI{
+		Nombre: nombre,
+		Token:  prefijoTokenAPI + hex.EncodeToString(aleatorio),
+	}
+	token.Prefijo = token.Token[:len(prefijoTokenAPI)+6]
+
+	query := `
+		INSERT INTO tokens_api (nombre, token_hash, prefijo)
+		VALUES (?, ?, ?)
+		RETURNING id, created_at
+	`
+	err := r.db.ejecutor().QueryRow(query, nombre, hashToken(token.Token), token.Prefijo).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Validar devuelve el token correspondiente o nil si no existe, y registra
+// su uso.
+func (r *TokenAPIRepo) Validar(token string) (*models.TokenAPI, error) {
+	query := `
+		UPDATE tokens_api SET ultimo_uso = CURRENT_TIMESTAMP
+		WHERE token_hash = ?
+		RETURNING id, nombre, prefijo, ultimo_uso, created_at
+	`
+
+	t := &models.TokenAPI{}
+	var ultimoUso sql.NullTime
+	err := r.db.ejecutor().QueryRow(query, hashToken(token)).
+		Scan(&t.ID, &t.Nombre, &t.Prefijo, &ultimoUso, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if ultimoUso.Valid {
+		t.UltimoUso = &ultimoUso.Time
+	}
+
+	return t, nil
+}
+
+func (r *TokenAPIRepo) ListarTodos() ([]models.TokenAPI, error) {
+	query := `SELECT id, nombre, prefijo, ultimo_uso, created_at FROM tokens_api ORDER BY id`
+
+	rows, err := r.db.ejecutor().Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.TokenAPI
+	for rows.Next() {
+		var t models.TokenAPI
+		var ultimoUso sql.NullTime
+		if err := rows.Scan(&t.ID, &t.Nombre, &t.Prefijo, &ultimoUso, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error escaneando token: %w", err)
+		}
+		if ultimoUso.Valid {
+			t.UltimoUso = &ultimoUso.Time
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *TokenAPIRepo) Eliminar(id int64) error {
+	query := `DELETE FROM tokens_api WHERE id = ?`
+	_, err := r.db.ejecutor().Exec(query, id)
+	return err
+}
+
+func hashToken(token string) string {
+	suma := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(suma[:])
+}
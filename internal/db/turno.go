@@ -18,15 +18,20 @@ func NewTurnoRepo(db *DB) *TurnoRepo {
 
 func (r *TurnoRepo) Crear(turno *models.Turno) error {
 	query := `
-		INSERT INTO turnos (paciente_id, fecha, hora, duracion, motivo, estado, notas)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO turnos (paciente_id, profesional_id, fecha, hora, duracion, motivo, estado, notas)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING id, created_at, updated_at
 	`
 
-	return r.db.Conn().QueryRow(
+	if turno.ProfesionalID == 0 {
+		turno.ProfesionalID = models.ProfesionalPrincipalID
+	}
+
+	return r.db.ejecutor().QueryRow(
 		query,
 		turno.PacienteID,
-		turno.Fecha.Format("2006-01-02"),
+		turno.ProfesionalID,
+		turno.Fecha.Format(formatoFecha),
 		turno.Hora,
 		turno.Duracion,
 		turno.Motivo,
@@ -36,22 +41,13 @@ func (r *TurnoRepo) Crear(turno *models.Turno) error {
 }
 
 func (r *TurnoRepo) ObtenerPorID(id int64) (*models.Turno, error) {
-	query := `
-		SELECT t.id, t.paciente_id, t.fecha, t.hora, t.duracion, t.motivo, t.estado, t.notas, t.created_at, t.updated_at,
-		       p.id, p.nombre, p.telefono, p.email, p.notas, p.created_at, p.updated_at
+	query := `SELECT ` + columnasTurno + `
 		FROM turnos t
 		JOIN pacientes p ON t.paciente_id = p.id
 		WHERE t.id = ?
 	`
 
-	turno := &models.Turno{}
-	paciente := &models.Paciente{}
-	var fechaStr string
-
-	err := r.db.Conn().QueryRow(query, id).Scan(
-		&turno.ID, &turno.PacienteID, &fechaStr, &turno.Hora, &turno.Duracion, &turno.Motivo, &turno.Estado, &turno.Notas, &turno.CreatedAt, &turno.UpdatedAt,
-		&paciente.ID, &paciente.Nombre, &paciente.Telefono, &paciente.Email, &paciente.Notas, &paciente.CreatedAt, &paciente.UpdatedAt,
-	)
+	turno, err := scanTurno(r.db.ejecutor().QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -59,23 +55,56 @@ func (r *TurnoRepo) ObtenerPorID(id int64) (*models.Turno, error) {
 		return nil, err
 	}
 
-	turno.Fecha, _ = time.Parse("2006-01-02", fechaStr)
-	turno.Paciente = paciente
-
 	return turno, nil
 }
 
 func (r *TurnoRepo) ListarPorFecha(fecha time.Time) ([]models.Turno, error) {
-	query := `
-		SELECT t.id, t.paciente_id, t.fecha, t.hora, t.duracion, t.motivo, t.estado, t.notas, t.created_at, t.updated_at,
-		       p.id, p.nombre, p.telefono, p.email, p.notas, p.created_at, p.updated_at
+	query := `SELECT ` + columnasTurno + `
 		FROM turnos t
 		JOIN pacientes p ON t.paciente_id = p.id
 		WHERE t.fecha = ?
 		ORDER BY t.hora
 	`
 
-	rows, err := r.db.Conn().Query(query, fecha.Format("2006-01-02"))
+	rows, err := r.db.ejecutor().Query(query, fecha.Format(formatoFecha))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanRows(rows)
+}
+
+// ListarPorRango devuelve los turnos entre desde y hasta inclusive. Una fecha
+// cero deja ese extremo del rango abierto.
+func (r *TurnoRepo) ListarPorRango(desde, hasta time.Time) ([]models.Turno, error) {
+	query := `SELECT ` + columnasTurno + `
+		FROM turnos t
+		JOIN pacientes p ON t.paciente_id = p.id
+		WHERE t.fecha BETWEEN ? AND ?
+		ORDER BY t.fecha, t.hora
+	`
+
+	rows, err := r.db.ejecutor().Query(query, formatoDesde(desde), formatoHasta(hasta))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanRows(rows)
+}
+
+// ListarPorProfesional devuelve los turnos de un profesional entre desde y
+// hasta inclusive. Una fecha cero deja ese extremo del rango abierto.
+func (r *TurnoRepo) ListarPorProfesional(profesionalID int64, desde, hasta time.Time) ([]models.Turno, error) {
+	query := `SELECT ` + columnasTurno + `
+		FROM turnos t
+		JOIN pacientes p ON t.paciente_id = p.id
+		WHERE t.profesional_id = ? AND t.fecha BETWEEN ? AND ?
+		ORDER BY t.fecha, t.hora
+	`
+
+	rows, err := r.db.ejecutor().Query(query, profesionalID, formatoDesde(desde), formatoHasta(hasta))
 	if err != nil {
 		return nil, err
 	}
@@ -85,16 +114,14 @@ func (r *TurnoRepo) ListarPorFecha(fecha time.Time) ([]models.Turno, error) {
 }
 
 func (r *TurnoRepo) ListarPorPaciente(pacienteID int64) ([]models.Turno, error) {
-	query := `
-		SELECT t.id, t.paciente_id, t.fecha, t.hora, t.duracion, t.motivo, t.estado, t.notas, t.created_at, t.updated_at,
-		       p.id, p.nombre, p.telefono, p.email, p.notas, p.created_at, p.updated_at
+	query := `SELECT ` + columnasTurno + `
 		FROM turnos t
 		JOIN pacientes p ON t.paciente_id = p.id
 		WHERE t.paciente_id = ?
 		ORDER BY t.fecha DESC, t.hora DESC
 	`
 
-	rows, err := r.db.Conn().Query(query, pacienteID)
+	rows, err := r.db.ejecutor().Query(query, pacienteID)
 	if err != nil {
 		return nil, err
 	}
@@ -105,33 +132,121 @@ func (r *TurnoRepo) ListarPorPaciente(pacienteID int64) ([]models.Turno, error)
 
 func (r *TurnoRepo) ActualizarEstado(id int64, estado models.EstadoTurno) error {
 	query := `UPDATE turnos SET estado = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
-	_, err := r.db.Conn().Exec(query, string(estado), id)
+	_, err := r.db.ejecutor().Exec(query, string(estado), id)
 	return err
 }
 
+// ActualizarAsistencia guarda el estado junto con los horarios reales de
+// llegada, inicio y fin de la consulta.
+func (r *TurnoRepo) ActualizarAsistencia(turno *models.Turno) error {
+	query := `
+		UPDATE turnos
+		SET estado = ?, hora_llegada = ?, hora_inicio = ?, hora_fin = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	_, err := r.db.ejecutor().Exec(
+		query,
+		string(turno.Estado),
+		valorHorario(turno.HoraLlegada),
+		valorHorario(turno.HoraInicio),
+		valorHorario(turno.HoraFin),
+		turno.ID,
+	)
+	return err
+}
+
+// MarcarAusente guarda la asistencia del turno, ya marcado como ausente, y
+// registra el no-show del paciente en una única transacción. Si el turno ya
+// estaba ausente no modifica nada, para no contar dos veces la misma falta.
+func (r *TurnoRepo) MarcarAusente(turno *models.Turno, fecha time.Time) error {
+	return r.db.Transaccion(func(tx *DB) error {
+		query := `
+			UPDATE turnos
+			SET estado = ?, hora_llegada = ?, hora_inicio = ?, hora_fin = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND estado <> ?
+		`
+		resultado, err := tx.ejecutor().Exec(
+			query,
+			string(models.EstadoAusente),
+			valorHorario(turno.HoraLlegada),
+			valorHorario(turno.HoraInicio),
+			valorHorario(turno.HoraFin),
+			turno.ID,
+			string(models.EstadoAusente),
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := resultado.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return NewPacienteRepo(tx).RegistrarNoShow(turno.PacienteID, turno.ID, fecha)
+	})
+}
+
+// Actualizar guarda los datos del turno salvo el estado y los horarios de
+// asistencia, que sólo cambian con ActualizarAsistencia y MarcarAusente.
 func (r *TurnoRepo) Actualizar(turno *models.Turno) error {
 	query := `
 		UPDATE turnos 
-		SET paciente_id = ?, fecha = ?, hora = ?, duracion = ?, motivo = ?, estado = ?, notas = ?, updated_at = CURRENT_TIMESTAMP
+		SET paciente_id = ?, profesional_id = ?, fecha = ?, hora = ?, duracion = ?, motivo = ?, notas = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
-	_, err := r.db.Conn().Exec(
+	if turno.ProfesionalID == 0 {
+		turno.ProfesionalID = models.ProfesionalPrincipalID
+	}
+
+	_, err := r.db.ejecutor().Exec(
 		query,
 		turno.PacienteID,
-		turno.Fecha.Format("2006-01-02"),
+		turno.ProfesionalID,
+		turno.Fecha.Format(formatoFecha),
 		turno.Hora,
 		turno.Duracion,
 		turno.Motivo,
-		string(turno.Estado),
 		turno.Notas,
 		turno.ID,
 	)
 	return err
 }
 
+// Reprogramar mueve cada turno a su nueva fecha y hora, lo deja pendiente
+// de confirmar y encola los mensajes de aviso, todo en una única
+// transacción. Si algún turno dejó de estar pendiente o confirmado desde que
+// se armó el plan, no aplica ningún cambio.
+func (r *TurnoRepo) Reprogramar(cambios []models.CambioTurno, mensajes []*models.Mensaje) error {
+	return r.db.Transaccion(func(tx *DB) error {
+		query := `
+			UPDATE turnos
+			SET fecha = ?, hora = ?, estado = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND estado IN (?, ?)
+		`
+		for _, cambio := range cambios {
+			resultado, err := tx.ejecutor().Exec(query, cambio.Fecha.Format(formatoFecha), cambio.Hora, string(models.EstadoPendiente), cambio.Turno.ID,
+				string(models.EstadoPendiente), string(models.EstadoConfirmado))
+			if err != nil {
+				return fmt.Errorf("error reprogramando turno %d: %w", cambio.Turno.ID, err)
+			}
+			if n, err := resultado.RowsAffected(); err != nil {
+				return fmt.Errorf("error reprogramando turno %d: %w", cambio.Turno.ID, err)
+			} else if n == 0 {
+				return fmt.Errorf("el turno %d ya no está pendiente ni confirmado; vuelva a armar la reprogramación", cambio.Turno.ID)
+			}
+		}
+
+		mensajeRepo := NewMensajeRepo(tx)
+		for _, mensaje := range mensajes {
+			if err := mensajeRepo.Crear(mensaje); err != nil {
+				return fmt.Errorf("error encolando mensaje: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
 func (r *TurnoRepo) Eliminar(id int64) error {
 	query := `DELETE FROM turnos WHERE id = ?`
-	_, err := r.db.Conn().Exec(query, id)
+	_, err := r.db.ejecutor().Exec(query, id)
 	return err
 }
 
@@ -139,22 +254,108 @@ func (r *TurnoRepo) scanRows(rows *sql.Rows) ([]models.Turno, error) {
 	var turnos []models.Turno
 
 	for rows.Next() {
-		turno := models.Turno{}
-		paciente := models.Paciente{}
-		var fechaStr string
-
-		err := rows.Scan(
-			&turno.ID, &turno.PacienteID, &fechaStr, &turno.Hora, &turno.Duracion, &turno.Motivo, &turno.Estado, &turno.Notas, &turno.CreatedAt, &turno.UpdatedAt,
-			&paciente.ID, &paciente.Nombre, &paciente.Telefono, &paciente.Email, &paciente.Notas, &paciente.CreatedAt, &paciente.UpdatedAt,
-		)
+		turno, err := scanTurno(rows)
 		if err != nil {
 			return nil, fmt.Errorf("error escaneando turno: %w", err)
 		}
-
-		turno.Fecha, _ = time.Parse("2006-01-02", fechaStr)
-		turno.Paciente = &paciente
-		turnos = append(turnos, turno)
+		turnos = append(turnos, *turno)
 	}
 
 	return turnos, rows.Err()
 }
+
+// columnasTurno son las columnas que espera scanTurno, en orden.
+const columnasTurno = `
+		t.id, t.paciente_id, t.profesional_id, t.fecha, t.hora, t.duracion, t.motivo, t.estado, t.notas,
+		t.hora_llegada, t.hora_inicio, t.hora_fin, t.created_at, t.updated_at,
+		p.id, p.nombre, p.telefono, p.email, p.notas, p.created_at, p.updated_at`
+
+type escaner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTurno(fila escaner) (*models.Turno, error) {
+	turno := &models.Turno{}
+	paciente := &models.Paciente{}
+	var llegada, inicio, fin sql.NullTime
+	err := fila.Scan(
+		&turno.ID, &turno.PacienteID, &turno.ProfesionalID, columnaFecha{&turno.Fecha}, &turno.Hora, &turno.Duracion, &turno.Motivo, &turno.Estado, &turno.Notas,
+		&llegada, &inicio, &fin, &turno.CreatedAt, &turno.UpdatedAt,
+		&paciente.ID, &paciente.Nombre, &paciente.Telefono, &paciente.Email, &paciente.Notas, &paciente.CreatedAt, &paciente.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	turno.HoraLlegada = horarioOpcional(llegada)
+	turno.HoraInicio = horarioOpcional(inicio)
+	turno.HoraFin = horarioOpcional(fin)
+	turno.Paciente = paciente
+	return turno, nil
+}
+
+func horarioOpcional(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// valorHorario guarda los horarios en UTC con el mismo formato que
+// CURRENT_TIMESTAMP, así todas las marcas de tiempo de la base son
+// comparables entre sí.
+func valorHorario(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(formatoMarcaTiempo)
+}
+
+const (
+	formatoFecha       = "2006-01-02"
+	formatoMarcaTiempo = "2006-01-02 15:04:05"
+)
+
+// columnaFecha escanea una columna DATE. El driver sólo convierte a time.Time los
+// valores con formato válido; cualquier otro texto es un dato corrupto y se
+// informa como error en lugar de dejar la fecha en cero.
+type columnaFecha struct {
+	destino *time.Time
+}
+
+func (f columnaFecha) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*f.destino = time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+		return nil
+	case string:
+		return f.parsear(v)
+	case []byte:
+		return f.parsear(string(v))
+	default:
+		return fmt.Errorf("fecha inválida en la base de datos: %v", src)
+	}
+}
+
+func (f columnaFecha) parsear(s string) error {
+	t, err := time.Parse(formatoFecha, s)
+	if err != nil {
+		return fmt.Errorf("fecha inválida en la base de datos: %q", s)
+	}
+	*f.destino = t
+	return nil
+}
+
+func formatoDesde(desde time.Time) string {
+	if desde.IsZero() {
+		return "0001-01-01"
+	}
+	return desde.Format(formatoFecha)
+}
+
+func formatoHasta(hasta time.Time) string {
+	if hasta.IsZero() {
+		return "9999-12-31"
+	}
+	return hasta.Format(formatoFecha)
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+func crearTurnoPrueba(t *testing.T, database *DB) *models.Turno {
+	t.Helper()
+
+	paciente := &models.Paciente{Nombre: "Juan Pérez"}
+	if err := NewPacienteRepo(database).Crear(paciente); err != nil {
+		t.Fatalf("Crear paciente failed: %v", err)
+	}
+	turno := &models.Turno{
+		PacienteID: paciente.ID,
+		Fecha:      time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		Hora:       models.NuevaHora(9, 30),
+		Duracion:   30,
+		Estado:     models.EstadoPendiente,
+	}
+	if err := NewTurnoRepo(database).Crear(turno); err != nil {
+		t.Fatalf("Crear turno failed: %v", err)
+	}
+	return turno
+}
+
+func TestTurnoRepo_DatosMalformados(t *testing.T) {
+	tests := map[string]string{
+		"fecha": `UPDATE turnos SET fecha = '05/03/2024'`,
+		"hora":  `UPDATE turnos SET hora = '9.30hs'`,
+	}
+
+	for columna, update := range tests {
+		t.Run(columna, func(t *testing.T) {
+			database, cleanup := setupTestDB(t)
+			defer cleanup()
+
+			turno := crearTurnoPrueba(t, database)
+			if _, err := database.conn.Exec(update); err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+
+			_, err := NewTurnoRepo(database).ObtenerPorID(turno.ID)
+			if err == nil || !strings.Contains(err.Error(), columna+" inválida") {
+				t.Errorf("ObtenerPorID() error = %v, want %s inválida", err, columna)
+			}
+		})
+	}
+}
+
+func TestTurnoRepo_HorariosEnUTC(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	turno := crearTurnoPrueba(t, database)
+	zona := time.FixedZone("UTC-3", -3*60*60)
+	llegada := time.Date(2024, 3, 5, 9, 20, 0, 0, zona)
+	turno.Estado = models.EstadoEnEspera
+	turno.HoraLlegada = &llegada
+
+	repo := NewTurnoRepo(database)
+	if err := repo.ActualizarAsistencia(turno); err != nil {
+		t.Fatalf("ActualizarAsistencia() failed: %v", err)
+	}
+
+	var guardado string
+	if err := database.conn.QueryRow(`SELECT CAST(hora_llegada AS TEXT) FROM turnos`).Scan(&guardado); err != nil {
+		t.Fatalf("select failed: %v", err)
+	}
+	if guardado != "2024-03-05 12:20:00" {
+		t.Errorf("hora_llegada guardada = %q, want 2024-03-05 12:20:00", guardado)
+	}
+
+	leido, err := repo.ObtenerPorID(turno.ID)
+	if err != nil {
+		t.Fatalf("ObtenerPorID() failed: %v", err)
+	}
+	if leido.HoraLlegada == nil || !leido.HoraLlegada.Equal(llegada) {
+		t.Errorf("HoraLlegada = %v, want %v", leido.HoraLlegada, llegada)
+	}
+	if leido.Hora != models.NuevaHora(9, 30) || leido.Fecha.Format("2006-01-02") != "2024-03-05" {
+		t.Errorf("Fecha/Hora = %s %s, want 2024-03-05 09:30", leido.Fecha.Format("2006-01-02"), leido.Hora)
+	}
+}
+
+func TestTurnoRepo_Reprogramar(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	turno := crearTurnoPrueba(t, database)
+	nuevaFecha := time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)
+	cambios := []models.CambioTurno{{Turno: *turno, Fecha: nuevaFecha, Hora: models.NuevaHora(11, 0)}}
+	mensajes := []*models.Mensaje{{PacienteID: turno.PacienteID, TurnoID: turno.ID, Telefono: "1112345678", Texto: "Su turno se movió"}}
+
+	if err := NewTurnoRepo(database).Reprogramar(cambios, mensajes); err != nil {
+		t.Fatalf("Reprogramar() failed: %v", err)
+	}
+
+	leido, err := NewTurnoRepo(database).ObtenerPorID(turno.ID)
+	if err != nil {
+		t.Fatalf("ObtenerPorID() failed: %v", err)
+	}
+	if !leido.Fecha.Equal(nuevaFecha) || leido.Hora != models.NuevaHora(11, 0) {
+		t.Errorf("turno = %s %s, want 2024-03-06 11:00", leido.Fecha.Format("2006-01-02"), leido.Hora)
+	}
+
+	mensajeRepo := NewMensajeRepo(database)
+	pendientes, err := mensajeRepo.ListarPorEstado(models.MensajePendiente)
+	if err != nil {
+		t.Fatalf("ListarPorEstado() failed: %v", err)
+	}
+	if len(pendientes) != 1 || pendientes[0].Texto != "Su turno se movió" {
+		t.Fatalf("pendientes = %+v, want el mensaje encolado", pendientes)
+	}
+
+	if err := mensajeRepo.CambiarEstado(pendientes[0].ID, models.MensajeEnviado); err != nil {
+		t.Fatalf("CambiarEstado() failed: %v", err)
+	}
+	enviados, _ := mensajeRepo.ListarPorEstado(models.MensajeEnviado)
+	if len(enviados) != 1 || enviados[0].EnviadoAt == nil {
+		t.Errorf("enviados = %+v, want el mensaje con fecha de envío", enviados)
+	}
+}
+
+func TestTurnoRepo_ReprogramarEstadoCambiado(t *testing.T) {
+	database, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewTurnoRepo(database)
+	turno := crearTurnoPrueba(t, database)
+	turno.Estado = models.EstadoAtendido
+	if err := repo.ActualizarAsistencia(turno); err != nil {
+		t.Fatalf("ActualizarAsistencia() failed: %v", err)
+	}
+
+	// Un turno atendido después de armar el plan no se mueve ni se avisa.
+	cambios := []models.CambioTurno{{Turno: *turno, Fecha: time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), Hora: models.NuevaHora(11, 0)}}
+	mensajes := []*models.Mensaje{{PacienteID: turno.PacienteID, TurnoID: turno.ID, Telefono: "1112345678", Texto: "Su turno se movió"}}
+	if err := repo.Reprogramar(cambios, mensajes); err == nil {
+		t.Fatal("Reprogramar() de un turno atendido should fail")
+	}
+
+	leido, err := repo.ObtenerPorID(turno.ID)
+	if err != nil {
+		t.Fatalf("ObtenerPorID() failed: %v", err)
+	}
+	if leido.Estado != models.EstadoAtendido || !leido.Fecha.Equal(turno.Fecha) {
+		t.Errorf("turno = %s %s, want sin cambios", leido.Estado, leido.Fecha.Format("2006-01-02"))
+	}
+	if pendientes, _ := NewMensajeRepo(database).ListarPorEstado(models.MensajePendiente); len(pendientes) != 0 {
+		t.Errorf("pendientes = %d, want 0", len(pendientes))
+	}
+}
@@ -0,0 +1,275 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/ical"
+	"yoyaku/internal/models"
+)
+
+type Tipo string
+
+const (
+	TipoPacientes Tipo = "pacientes"
+	TipoTurnos    Tipo = "turnos"
+	TipoNoShows   Tipo = "no_shows"
+)
+
+type Formato string
+
+const (
+	FormatoCSV  Formato = "csv"
+	FormatoJSON Formato = "json"
+	FormatoICS  Formato = "ics"
+)
+
+// NoShow es una ausencia del historial con los datos del paciente, para que
+// el archivo exportado se entienda sin la base de datos.
+type NoShow struct {
+	ID               int64     `json:"id"`
+	Fecha            time.Time `json:"fecha"`
+	TurnoID          int64     `json:"turnoId"`
+	PacienteID       int64     `json:"pacienteId"`
+	PacienteNombre   string    `json:"pacienteNombre"`
+	PacienteTelefono string    `json:"pacienteTelefono"`
+}
+
+type Service struct {
+	turnoRepo    *db.TurnoRepo
+	pacienteRepo *db.PacienteRepo
+	tieneFeature func(models.Feature) bool
+}
+
+func NewService(turnoRepo *db.TurnoRepo, pacienteRepo *db.PacienteRepo) *Service {
+	return &Service{
+		turnoRepo:    turnoRepo,
+		pacienteRepo: pacienteRepo,
+	}
+}
+
+// UsarLicencia indica qué funciones habilita la licencia. Hasta que se
+// llama, el servicio las habilita todas.
+func (s *Service) UsarLicencia(tieneFeature func(models.Feature) bool) {
+	s.tieneFeature = tieneFeature
+}
+
+// ExportarArchivo escribe la exportación en la ruta indicada y devuelve la
+// cantidad de registros exportados.
+func (s *Service) ExportarArchivo(ruta string, tipo Tipo, formato Formato, desde, hasta time.Time) (int, error) {
+	return escribirArchivo(ruta, func(w io.Writer) (int, error) {
+		return s.Exportar(w, tipo, formato, desde, hasta)
+	})
+}
+
+// ExportarICSArchivo escribe la agenda del rango como archivo .ics.
+func (s *Service) ExportarICSArchivo(ruta string, desde, hasta time.Time, opciones ical.Opciones) (int, error) {
+	return escribirArchivo(ruta, func(w io.Writer) (int, error) {
+		return s.ExportarICS(w, desde, hasta, opciones)
+	})
+}
+
+// ExportarICS escribe los turnos del rango como calendario iCalendar. Los
+// turnos cancelados se incluyen con STATUS:CANCELLED para que los
+// calendarios que ya los tenían los den de baja.
+func (s *Service) ExportarICS(w io.Writer, desde, hasta time.Time, opciones ical.Opciones) (int, error) {
+	turnos, err := s.turnoRepo.ListarPorRango(desde, hasta)
+	if err != nil {
+		return 0, err
+	}
+	if err := ical.Generar(w, turnos, opciones); err != nil {
+		return 0, err
+	}
+	return len(turnos), nil
+}
+
+func escribirArchivo(ruta string, exportar func(w io.Writer) (int, error)) (int, error) {
+	f, err := os.Create(ruta)
+	if err != nil {
+		return 0, fmt.Errorf("error creando archivo: %w", err)
+	}
+
+	n, err := exportar(f)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("error cerrando archivo: %w", cerr)
+	}
+	if err != nil {
+		os.Remove(ruta)
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Exportar escribe los registros del tipo pedido en el formato indicado. El
+// rango de fechas se aplica a turnos y no-shows; una fecha cero deja ese
+// extremo abierto. Los pacientes se exportan siempre completos. El reporte de
+// no-shows requiere una licencia con reportes; los datos propios se pueden
+// exportar con cualquier licencia.
+func (s *Service) Exportar(w io.Writer, tipo Tipo, formato Formato, desde, hasta time.Time) (int, error) {
+	if formato != FormatoCSV && formato != FormatoJSON {
+		return 0, fmt.Errorf("formato de exportación inválido: %s", formato)
+	}
+	if tipo == TipoNoShows && s.tieneFeature != nil && !s.tieneFeature(models.FeatureReportes) {
+		return 0, &models.FeatureNoIncluidaError{Feature: models.FeatureReportes}
+	}
+
+	switch tipo {
+	case TipoPacientes:
+		pacientes, err := s.pacienteRepo.ListarTodos()
+		if err != nil {
+			return 0, err
+		}
+		if pacientes == nil {
+			pacientes = []models.Paciente{}
+		}
+		return len(pacientes), escribir(w, formato, pacientes, pacientesCSV(pacientes))
+	case TipoTurnos:
+		turnos, err := s.turnoRepo.ListarPorRango(desde, hasta)
+		if err != nil {
+			return 0, err
+		}
+		if turnos == nil {
+			turnos = []models.Turno{}
+		}
+		return len(turnos), escribir(w, formato, turnos, turnosCSV(turnos))
+	case TipoNoShows:
+		noShows, err := s.noShows(desde, hasta)
+		if err != nil {
+			return 0, err
+		}
+		return len(noShows), escribir(w, formato, noShows, noShowsCSV(noShows))
+	default:
+		return 0, fmt.Errorf("tipo de exportación inválido: %s", tipo)
+	}
+}
+
+// NombreArchivo sugiere un nombre de archivo para la exportación.
+func NombreArchivo(tipo Tipo, formato Formato, desde, hasta time.Time) string {
+	nombre := "yoyaku_" + string(tipo)
+	if tipo != TipoPacientes {
+		if !desde.IsZero() {
+			nombre += "_" + desde.Format("2006-01-02")
+		}
+		if !hasta.IsZero() {
+			nombre += "_" + hasta.Format("2006-01-02")
+		}
+	}
+	return nombre + "." + string(formato)
+}
+
+// ParsearRango interpreta fechas en formato AAAA-MM-DD; un texto vacío
+// devuelve la fecha cero.
+func ParsearRango(desde, hasta string) (time.Time, time.Time, error) {
+	var d, h time.Time
+	var err error
+	if desde != "" {
+		if d, err = time.Parse("2006-01-02", desde); err != nil {
+			return d, h, fmt.Errorf("fecha desde inválida: %w", err)
+		}
+	}
+	if hasta != "" {
+		if h, err = time.Parse("2006-01-02", hasta); err != nil {
+			return d, h, fmt.Errorf("fecha hasta inválida: %w", err)
+		}
+	}
+	if !d.IsZero() && !h.IsZero() && h.Before(d) {
+		return d, h, fmt.Errorf("la fecha hasta es anterior a la fecha desde")
+	}
+	return d, h, nil
+}
+
+func (s *Service) noShows(desde, hasta time.Time) ([]NoShow, error) {
+	historial, err := s.pacienteRepo.ListarNoShows(desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+
+	pacientes, err := s.pacienteRepo.ListarTodos()
+	if err != nil {
+		return nil, err
+	}
+	porID := make(map[int64]models.Paciente, len(pacientes))
+	for _, p := range pacientes {
+		porID[p.ID] = p
+	}
+
+	noShows := make([]NoShow, len(historial))
+	for i, h := range historial {
+		paciente := porID[h.PacienteID]
+		noShows[i] = NoShow{
+			ID:               h.ID,
+			Fecha:            h.Fecha,
+			TurnoID:          h.TurnoID,
+			PacienteID:       h.PacienteID,
+			PacienteNombre:   paciente.Nombre,
+			PacienteTelefono: paciente.Telefono,
+		}
+	}
+	return noShows, nil
+}
+
+func escribir(w io.Writer, formato Formato, registros interface{}, filas [][]string) error {
+	if formato == FormatoJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(registros)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(filas); err != nil {
+		return fmt.Errorf("error escribiendo CSV: %w", err)
+	}
+	return nil
+}
+
+func pacientesCSV(pacientes []models.Paciente) [][]string {
+	filas := [][]string{{"id", "nombre", "telefono", "email", "notas", "creado", "actualizado"}}
+	for _, p := range pacientes {
+		filas = append(filas, []string{
+			id(p.ID), p.Nombre, p.Telefono, p.Email, p.Notas,
+			p.CreatedAt.Format(time.RFC3339), p.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return filas
+}
+
+func turnosCSV(turnos []models.Turno) [][]string {
+	filas := [][]string{{
+		"id", "fecha", "hora", "duracion", "estado", "motivo", "notas",
+		"paciente_id", "paciente_nombre", "paciente_telefono", "paciente_email",
+	}}
+	for _, t := range turnos {
+		var paciente models.Paciente
+		if t.Paciente != nil {
+			paciente = *t.Paciente
+		}
+		filas = append(filas, []string{
+			id(t.ID), t.Fecha.Format("2006-01-02"), t.Hora.String(), strconv.Itoa(t.Duracion),
+			string(t.Estado), t.Motivo, t.Notas,
+			id(t.PacienteID), paciente.Nombre, paciente.Telefono, paciente.Email,
+		})
+	}
+	return filas
+}
+
+func noShowsCSV(noShows []NoShow) [][]string {
+	filas := [][]string{{"id", "fecha", "turno_id", "paciente_id", "paciente_nombre", "paciente_telefono"}}
+	for _, n := range noShows {
+		filas = append(filas, []string{
+			id(n.ID), n.Fecha.Format("2006-01-02"), id(n.TurnoID),
+			id(n.PacienteID), n.PacienteNombre, n.PacienteTelefono,
+		})
+	}
+	return filas
+}
+
+func id(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+func setupService(t *testing.T) *Service {
+	t.Helper()
+
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	turnoRepo := db.NewTurnoRepo(database)
+	pacienteRepo := db.NewPacienteRepo(database)
+
+	paciente := &models.Paciente{Nombre: "María González", Telefono: "+54 11 1234-5678"}
+	if err := pacienteRepo.Crear(paciente); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	for _, dia := range []int{1, 15, 28} {
+		turno := &models.Turno{
+			PacienteID: paciente.ID,
+			Fecha:      time.Date(2025, 3, dia, 0, 0, 0, 0, time.UTC),
+			Hora:       models.NuevaHora(10, 0),
+			Duracion:   30,
+			Motivo:     "Control, con coma",
+			Estado:     models.EstadoAusente,
+		}
+		if err := turnoRepo.Crear(turno); err != nil {
+			t.Fatalf("Crear() failed: %v", err)
+		}
+		if err := pacienteRepo.RegistrarNoShow(paciente.ID, turno.ID, turno.Fecha); err != nil {
+			t.Fatalf("RegistrarNoShow() failed: %v", err)
+		}
+	}
+
+	return NewService(turnoRepo, pacienteRepo)
+}
+
+func TestExportarTurnosCSV(t *testing.T) {
+	service := setupService(t)
+	desde, hasta, _ := ParsearRango("2025-03-10", "2025-03-31")
+
+	var buf bytes.Buffer
+	n, err := service.Exportar(&buf, TipoTurnos, FormatoCSV, desde, hasta)
+	if err != nil {
+		t.Fatalf("Exportar() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Exportar() = %d registros, want 2", n)
+	}
+
+	filas, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV inválido: %v", err)
+	}
+	if len(filas) != 3 {
+		t.Fatalf("len(filas) = %d, want 3", len(filas))
+	}
+	if filas[1][1] != "2025-03-15" || filas[1][5] != "Control, con coma" || filas[1][8] != "María González" {
+		t.Errorf("Fila exportada = %v", filas[1])
+	}
+}
+
+func TestExportarNoShowsJSON(t *testing.T) {
+	service := setupService(t)
+
+	var buf bytes.Buffer
+	n, err := service.Exportar(&buf, TipoNoShows, FormatoJSON, time.Time{}, time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Exportar() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Exportar() = %d registros, want 2", n)
+	}
+
+	var noShows []NoShow
+	if err := json.Unmarshal(buf.Bytes(), &noShows); err != nil {
+		t.Fatalf("JSON inválido: %v", err)
+	}
+	if len(noShows) != 2 || noShows[0].PacienteNombre != "María González" {
+		t.Errorf("noShows = %+v", noShows)
+	}
+}
+
+func TestExportarErrores(t *testing.T) {
+	service := setupService(t)
+
+	var buf bytes.Buffer
+	if _, err := service.Exportar(&buf, TipoPacientes, "xml", time.Time{}, time.Time{}); err == nil {
+		t.Error("Exportar() con formato inválido should fail")
+	}
+	if _, err := service.Exportar(&buf, "recetas", FormatoCSV, time.Time{}, time.Time{}); err == nil {
+		t.Error("Exportar() con tipo inválido should fail")
+	}
+	service.UsarLicencia(func(f models.Feature) bool { return f != models.FeatureReportes })
+	if _, err := service.Exportar(&buf, TipoNoShows, FormatoCSV, time.Time{}, time.Time{}); err == nil {
+		t.Error("Exportar() de no-shows sin reportes should fail")
+	}
+	if _, err := service.Exportar(&buf, TipoPacientes, FormatoCSV, time.Time{}, time.Time{}); err != nil {
+		t.Errorf("Exportar() de pacientes sin reportes error = %v", err)
+	}
+	if _, _, err := ParsearRango("2025-03-10", "2025-03-01"); err == nil {
+		t.Error("ParsearRango() con rango invertido should fail")
+	}
+}
@@ -0,0 +1,240 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"yoyaku/internal/ical"
+	"yoyaku/internal/models"
+)
+
+// CalDAV mínimo de sólo lectura (RFC 4791). Cada token expone:
+//
+//	/caldav/{token}/                 principal y calendar-home-set
+//	/caldav/{token}/agenda/          el calendario del profesional
+//	/caldav/{token}/agenda/{id}.ics  un turno
+//
+// Alcanza para que los calendarios de iOS, macOS y DAVx⁵ se suscriban; no se
+// implementan escrituras, sync-collection ni filtros de REPORT.
+
+const (
+	nombreColeccion = "agenda"
+	tamanoMaximoXML = 1 << 20
+)
+
+func (h *Handler) servirCalDAV(w http.ResponseWriter, r *http.Request) {
+	agenda, err := h.agenda(r.PathValue("token"))
+	if err != nil {
+		http.Error(w, "error obteniendo la agenda", http.StatusInternalServerError)
+		return
+	}
+	if agenda == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	resto := strings.Trim(r.PathValue("resto"), "/")
+	base := RutaCalDAV(agenda.feed.Token)
+
+	w.Header().Set("DAV", "1, calendar-access")
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PROPFIND, REPORT")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		h.getCalDAV(w, r, agenda, resto)
+	case "PROPFIND":
+		h.propfind(w, r, agenda, base, resto)
+	case "REPORT":
+		h.report(w, r, agenda, base, resto)
+	default:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PROPFIND, REPORT")
+		http.Error(w, "calendario de sólo lectura", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) getCalDAV(w http.ResponseWriter, r *http.Request, agenda *agendaFeed, resto string) {
+	var turnos []models.Turno
+	switch {
+	case resto == nombreColeccion:
+		turnos = agenda.turnos
+	case strings.HasPrefix(resto, nombreColeccion+"/"):
+		turno := agenda.turno(strings.TrimPrefix(resto, nombreColeccion+"/"))
+		if turno == nil {
+			http.NotFound(w, r)
+			return
+		}
+		turnos = []models.Turno{*turno}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Generar(&buf, turnos, agenda.opciones()); err != nil {
+		http.Error(w, "error generando la agenda", http.StatusInternalServerError)
+		return
+	}
+	escribirCalendario(w, r, buf.Bytes())
+}
+
+func (h *Handler) propfind(w http.ResponseWriter, r *http.Request, agenda *agendaFeed, base, resto string) {
+	profundidad := r.Header.Get("Depth")
+	ms := &multistatus{}
+
+	switch {
+	case resto == "":
+		ms.agregar(base, propsPrincipal(agenda, base))
+		if profundidad != "0" {
+			ms.agregar(base+nombreColeccion+"/", propsCalendario(agenda, base))
+		}
+	case resto == nombreColeccion:
+		ms.agregar(base+nombreColeccion+"/", propsCalendario(agenda, base))
+		if profundidad != "0" {
+			for _, turno := range agenda.turnos {
+				ms.agregar(hrefTurno(base, turno.ID), propsTurno(turno, ""))
+			}
+		}
+	default:
+		turno := agenda.turno(strings.TrimPrefix(resto, nombreColeccion+"/"))
+		if turno == nil || !strings.HasPrefix(resto, nombreColeccion+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		ms.agregar(hrefTurno(base, turno.ID), propsTurno(*turno, ""))
+	}
+
+	ms.escribir(w)
+}
+
+// report responde calendar-query devolviendo todos los turnos del feed, y
+// calendar-multiget devolviendo los pedidos.
+func (h *Handler) report(w http.ResponseWriter, r *http.Request, agenda *agendaFeed, base, resto string) {
+	if resto != nombreColeccion {
+		http.NotFound(w, r)
+		return
+	}
+
+	var pedido struct {
+		XMLName xml.Name
+		Hrefs   []string `xml:"DAV: href"`
+	}
+	cuerpo, err := io.ReadAll(io.LimitReader(r.Body, tamanoMaximoXML))
+	if err != nil || xml.Unmarshal(cuerpo, &pedido) != nil {
+		http.Error(w, "REPORT inválido", http.StatusBadRequest)
+		return
+	}
+
+	turnos := agenda.turnos
+	if pedido.XMLName.Local == "calendar-multiget" {
+		turnos = nil
+		for _, href := range pedido.Hrefs {
+			nombre := href[strings.LastIndex(href, "/")+1:]
+			if turno := agenda.turno(nombre); turno != nil {
+				turnos = append(turnos, *turno)
+			}
+		}
+	}
+
+	ms := &multistatus{}
+	for _, turno := range turnos {
+		var buf bytes.Buffer
+		if err := ical.Generar(&buf, []models.Turno{turno}, agenda.opciones()); err != nil {
+			http.Error(w, "error generando la agenda", http.StatusInternalServerError)
+			return
+		}
+		ms.agregar(hrefTurno(base, turno.ID), propsTurno(turno, buf.String()))
+	}
+
+	ms.escribir(w)
+}
+
+// turno busca el turno correspondiente a un recurso "{id}.ics".
+func (a *agendaFeed) turno(nombre string) *models.Turno {
+	id, err := strconv.ParseInt(strings.TrimSuffix(nombre, ".ics"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	for i := range a.turnos {
+		if a.turnos[i].ID == id {
+			return &a.turnos[i]
+		}
+	}
+	return nil
+}
+
+// ctag cambia cada vez que cambia algún turno del feed, para que los
+// clientes sepan cuándo volver a sincronizar.
+func (a *agendaFeed) ctag() string {
+	var b strings.Builder
+	for _, turno := range a.turnos {
+		fmt.Fprintf(&b, "%s;", etagTurno(turno))
+	}
+	return etagContenido([]byte(b.String()))
+}
+
+func etagTurno(turno models.Turno) string {
+	return fmt.Sprintf(`"%d-%d"`, turno.ID, turno.UpdatedAt.UnixNano())
+}
+
+func hrefTurno(base string, id int64) string {
+	return fmt.Sprintf("%s%s/%d.ics", base, nombreColeccion, id)
+}
+
+func propsPrincipal(agenda *agendaFeed, base string) string {
+	return `<d:resourcetype><d:collection/><d:principal/></d:resourcetype>` +
+		`<d:displayname>` + escaparXML(agenda.profesional.Nombre) + `</d:displayname>` +
+		`<d:current-user-principal><d:href>` + escaparXML(base) + `</d:href></d:current-user-principal>` +
+		`<d:principal-URL><d:href>` + escaparXML(base) + `</d:href></d:principal-URL>` +
+		`<c:calendar-home-set><d:href>` + escaparXML(base) + `</d:href></c:calendar-home-set>`
+}
+
+func propsCalendario(agenda *agendaFeed, base string) string {
+	return `<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>` +
+		`<d:displayname>` + escaparXML(agenda.profesional.Nombre) + `</d:displayname>` +
+		`<d:current-user-principal><d:href>` + escaparXML(base) + `</d:href></d:current-user-principal>` +
+		`<c:supported-calendar-component-set><c:comp name="VEVENT"/></c:supported-calendar-component-set>` +
+		`<d:current-user-privilege-set><d:privilege><d:read/></d:privilege></d:current-user-privilege-set>` +
+		`<cs:getctag>` + escaparXML(agenda.ctag()) + `</cs:getctag>`
+}
+
+func propsTurno(turno models.Turno, datos string) string {
+	props := `<d:resourcetype/>` +
+		`<d:getcontenttype>text/calendar; charset=utf-8; component=vevent</d:getcontenttype>` +
+		`<d:getetag>` + escaparXML(etagTurno(turno)) + `</d:getetag>`
+	if datos != "" {
+		props += `<c:calendar-data>` + escaparXML(datos) + `</c:calendar-data>`
+	}
+	return props
+}
+
+type multistatus struct {
+	respuestas strings.Builder
+}
+
+func (m *multistatus) agregar(href, props string) {
+	fmt.Fprintf(&m.respuestas,
+		`<d:response><d:href>%s</d:href><d:propstat><d:prop>%s</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`,
+		escaparXML(href), props)
+}
+
+func (m *multistatus) escribir(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, xml.Header)
+	io.WriteString(w, `<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+	io.WriteString(w, m.respuestas.String())
+	io.WriteString(w, `</d:multistatus>`)
+}
+
+func escaparXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/ical"
+	"yoyaku/internal/models"
+)
+
+const (
+	// diasAnteriores y diasPosteriores delimitan los turnos publicados en
+	// el feed respecto de hoy.
+	diasAnteriores  = 30
+	diasPosteriores = 365
+
+	intervaloActualizacion = 15 * time.Minute
+)
+
+// Handler publica la agenda de cada profesional como feed .ics y como
+// calendario CalDAV de sólo lectura. El acceso se controla con el token del
+// feed, que forma parte de la URL.
+type Handler struct {
+	feedRepo        *db.FeedRepo
+	turnoRepo       *db.TurnoRepo
+	profesionalRepo *db.ProfesionalRepo
+	configRepo      *db.ConfigRepo
+}
+
+func NewHandler(database *db.DB) *Handler {
+	return &Handler{
+		feedRepo:        db.NewFeedRepo(database),
+		turnoRepo:       db.NewTurnoRepo(database),
+		profesionalRepo: db.NewProfesionalRepo(database),
+		configRepo:      db.NewConfigRepo(database),
+	}
+}
+
+// RutaICS es la ruta del feed .ics de un token.
+func RutaICS(token string) string {
+	return "/calendario/" + token + ".ics"
+}
+
+// RutaCalDAV es la ruta de la cuenta CalDAV de un token.
+func RutaCalDAV(token string) string {
+	return "/caldav/" + token + "/"
+}
+
+// Registrar agrega las rutas del feed al mux del servidor.
+func (h *Handler) Registrar(handle func(patron string, handler http.Handler)) {
+	handle("GET /calendario/{archivo}", http.HandlerFunc(h.servirICS))
+	handle("/caldav/{token}/{resto...}", http.HandlerFunc(h.servirCalDAV))
+}
+
+func (h *Handler) servirICS(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("archivo"), ".ics")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	agenda, err := h.agenda(token)
+	if err != nil {
+		http.Error(w, "error obteniendo la agenda", http.StatusInternalServerError)
+		return
+	}
+	if agenda == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Generar(&buf, agenda.turnos, agenda.opciones()); err != nil {
+		http.Error(w, "error generando la agenda", http.StatusInternalServerError)
+		return
+	}
+
+	escribirCalendario(w, r, buf.Bytes())
+}
+
+// agendaFeed son los turnos publicados por un feed.
+type agendaFeed struct {
+	feed        *models.FeedCalendario
+	profesional *models.Profesional
+	turnos      []models.Turno
+	zona        *time.Location
+}
+
+func (a *agendaFeed) opciones() ical.Opciones {
+	return ical.Opciones{
+		NombreCalendario:       a.profesional.Nombre,
+		Redactar:               a.feed.Redactar,
+		Zona:                   a.zona,
+		IntervaloActualizacion: intervaloActualizacion,
+	}
+}
+
+// agenda busca el feed del token y sus turnos. Devuelve nil si el token no
+// existe.
+func (h *Handler) agenda(token string) (*agendaFeed, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	feed, err := h.feedRepo.ObtenerPorToken(token)
+	if err != nil || feed == nil {
+		return nil, err
+	}
+
+	profesional, err := h.profesionalRepo.ObtenerPorID(feed.ProfesionalID)
+	if err != nil || profesional == nil {
+		return nil, err
+	}
+
+	zona, err := h.configRepo.ObtenerZonaHoraria()
+	if err != nil {
+		return nil, err
+	}
+
+	hoy := time.Now().In(zona)
+	turnos, err := h.turnoRepo.ListarPorProfesional(
+		feed.ProfesionalID,
+		hoy.AddDate(0, 0, -diasAnteriores),
+		hoy.AddDate(0, 0, diasPosteriores),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &agendaFeed{feed: feed, profesional: profesional, turnos: turnos, zona: zona}, nil
+}
+
+// escribirCalendario responde con el calendario y un ETag calculado sobre el
+// contenido, para que los clientes que ya lo tienen reciban 304.
+func escribirCalendario(w http.ResponseWriter, r *http.Request, contenido []byte) {
+	etag := etagContenido(contenido)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(contenido)
+}
+
+func etagContenido(contenido []byte) string {
+	suma := sha256.Sum256(contenido)
+	return `"` + hex.EncodeToString(suma[:8]) + `"`
+}
@@ -0,0 +1,155 @@
+package feed
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+func setupServidor(t *testing.T) (*httptest.Server, *models.FeedCalendario, *models.Turno) {
+	t.Helper()
+
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	paciente := &models.Paciente{Nombre: "María González", Telefono: "11 1234-5678"}
+	if err := db.NewPacienteRepo(database).Crear(paciente); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	turnoRepo := db.NewTurnoRepo(database)
+	turno := &models.Turno{
+		PacienteID: paciente.ID,
+		Fecha:      time.Now(),
+		Hora:       models.NuevaHora(10, 0),
+		Duracion:   30,
+		Motivo:     "Control",
+		Estado:     models.EstadoConfirmado,
+	}
+	if err := turnoRepo.Crear(turno); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	otro := &models.Profesional{Nombre: "Dra. López", Activo: true}
+	if err := db.NewProfesionalRepo(database).Crear(otro); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+	ajeno := &models.Turno{PacienteID: paciente.ID, ProfesionalID: otro.ID, Fecha: time.Now(), Hora: models.NuevaHora(11, 0), Estado: models.EstadoConfirmado}
+	if err := turnoRepo.Crear(ajeno); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	f := &models.FeedCalendario{ProfesionalID: models.ProfesionalPrincipalID, Redactar: true}
+	if err := db.NewFeedRepo(database).Crear(f); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	NewHandler(database).Registrar(mux.Handle)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv, f, turno
+}
+
+func pedir(t *testing.T, metodo, url, cuerpo string, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(metodo, url, strings.NewReader(cuerpo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", metodo, url, err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, string(b)
+}
+
+func TestFeedICS(t *testing.T) {
+	srv, f, turno := setupServidor(t)
+
+	resp, cuerpo := pedir(t, http.MethodGet, srv.URL+RutaICS(f.Token), "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET feed = %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/calendar") {
+		t.Errorf("Content-Type = %s", resp.Header.Get("Content-Type"))
+	}
+	if strings.Count(cuerpo, "BEGIN:VEVENT") != 1 || !strings.Contains(cuerpo, "UID:turno-"+strconv.FormatInt(turno.ID, 10)) {
+		t.Errorf("El feed debe tener sólo el turno del profesional:\n%s", cuerpo)
+	}
+	if strings.Contains(cuerpo, "María") {
+		t.Error("El feed redactado incluye el nombre del paciente")
+	}
+	if !strings.Contains(cuerpo, "REFRESH-INTERVAL") {
+		t.Error("El feed no sugiere intervalo de actualización")
+	}
+
+	resp, _ = pedir(t, http.MethodGet, srv.URL+RutaICS(f.Token), "", map[string]string{"If-None-Match": resp.Header.Get("ETag")})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET con ETag vigente = %d, want 304", resp.StatusCode)
+	}
+
+	resp, _ = pedir(t, http.MethodGet, srv.URL+RutaICS("token-invalido"), "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET con token inválido = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestCalDAV(t *testing.T) {
+	srv, f, turno := setupServidor(t)
+	base := srv.URL + RutaCalDAV(f.Token)
+
+	resp, cuerpo := pedir(t, "PROPFIND", base, "", map[string]string{"Depth": "1"})
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND principal = %d", resp.StatusCode)
+	}
+	if !strings.Contains(cuerpo, "calendar-home-set") || !strings.Contains(cuerpo, RutaCalDAV(f.Token)+"agenda/") {
+		t.Errorf("PROPFIND principal sin calendario:\n%s", cuerpo)
+	}
+
+	resp, cuerpo = pedir(t, "PROPFIND", base+"agenda/", "", map[string]string{"Depth": "1"})
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND calendario = %d", resp.StatusCode)
+	}
+	href := RutaCalDAV(f.Token) + "agenda/" + strconv.FormatInt(turno.ID, 10) + ".ics"
+	if !strings.Contains(cuerpo, href) || !strings.Contains(cuerpo, "getctag") {
+		t.Errorf("PROPFIND calendario sin el turno:\n%s", cuerpo)
+	}
+
+	multiget := `<?xml version="1.0"?><c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` +
+		`<d:prop><d:getetag/><c:calendar-data/></d:prop><d:href>` + href + `</d:href></c:calendar-multiget>`
+	resp, cuerpo = pedir(t, "REPORT", base+"agenda/", multiget, nil)
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("REPORT = %d", resp.StatusCode)
+	}
+	if !strings.Contains(cuerpo, "BEGIN:VCALENDAR") {
+		t.Errorf("REPORT sin calendar-data:\n%s", cuerpo)
+	}
+
+	resp, cuerpo = pedir(t, http.MethodGet, srv.URL+href, "", nil)
+	if resp.StatusCode != http.StatusOK || strings.Count(cuerpo, "BEGIN:VEVENT") != 1 {
+		t.Errorf("GET turno = %d:\n%s", resp.StatusCode, cuerpo)
+	}
+
+	resp, _ = pedir(t, http.MethodPut, srv.URL+href, "BEGIN:VCALENDAR", nil)
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("PUT = %d, want 405", resp.StatusCode)
+	}
+}
@@ -0,0 +1,111 @@
+// Package feriados calcula el calendario de feriados nacionales de Argentina
+// según la ley 27.399: los inamovibles, los que dependen de la Pascua y los
+// trasladables, que se mueven al lunes más cercano.
+package feriados
+
+import (
+	"sort"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+type fijo struct {
+	mes    time.Month
+	dia    int
+	nombre string
+}
+
+var inamovibles = []fijo{
+	{time.January, 1, "Año Nuevo"},
+	{time.March, 24, "Día Nacional de la Memoria por la Verdad y la Justicia"},
+	{time.April, 2, "Día del Veterano y de los Caídos en la Guerra de Malvinas"},
+	{time.May, 1, "Día del Trabajador"},
+	{time.May, 25, "Día de la Revolución de Mayo"},
+	{time.June, 20, "Paso a la Inmortalidad del General Manuel Belgrano"},
+	{time.July, 9, "Día de la Independencia"},
+	{time.December, 8, "Inmaculada Concepción de María"},
+	{time.December, 25, "Navidad"},
+}
+
+var trasladables = []fijo{
+	{time.June, 17, "Paso a la Inmortalidad del General Martín Miguel de Güemes"},
+	{time.August, 17, "Paso a la Inmortalidad del General José de San Martín"},
+	{time.October, 12, "Día del Respeto a la Diversidad Cultural"},
+	{time.November, 20, "Día de la Soberanía Nacional"},
+}
+
+// Argentina devuelve los feriados nacionales del año, ordenados por fecha.
+// Las fechas son medianoche UTC, como las de los turnos. No incluye los días
+// no laborables ni los feriados puente, que se fijan por decreto cada año.
+func Argentina(anio int) []models.Feriado {
+	var feriados []models.Feriado
+	for _, f := range inamovibles {
+		feriados = append(feriados, models.Feriado{Fecha: fecha(anio, f.mes, f.dia), Nombre: f.nombre})
+	}
+	for _, f := range trasladables {
+		feriados = append(feriados, models.Feriado{Fecha: trasladar(fecha(anio, f.mes, f.dia)), Nombre: f.nombre})
+	}
+
+	pascua := Pascua(anio)
+	feriados = append(feriados,
+		models.Feriado{Fecha: pascua.AddDate(0, 0, -48), Nombre: "Carnaval"},
+		models.Feriado{Fecha: pascua.AddDate(0, 0, -47), Nombre: "Carnaval"},
+		models.Feriado{Fecha: pascua.AddDate(0, 0, -2), Nombre: "Viernes Santo"},
+	)
+
+	sort.SliceStable(feriados, func(i, j int) bool {
+		return feriados[i].Fecha.Before(feriados[j].Fecha)
+	})
+	return feriados
+}
+
+// Buscar devuelve el feriado que cae en el día de f, o nil si es un día
+// hábil.
+func Buscar(f time.Time) *models.Feriado {
+	dia := fecha(f.Year(), f.Month(), f.Day())
+	for _, feriado := range Argentina(f.Year()) {
+		if feriado.Fecha.Equal(dia) {
+			return &feriado
+		}
+	}
+	return nil
+}
+
+// Pascua calcula el domingo de Pascua del calendario gregoriano con el
+// algoritmo de Meeus/Jones/Butcher.
+func Pascua(anio int) time.Time {
+	a := anio % 19
+	b, c := anio/100, anio%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	mes := (h + l - 7*m + 114) / 31
+	dia := (h+l-7*m+114)%31 + 1
+	return fecha(anio, time.Month(mes), dia)
+}
+
+// trasladar aplica la regla de los feriados trasladables: si caen martes o
+// miércoles pasan al lunes anterior, y si caen jueves o viernes al lunes
+// siguiente.
+func trasladar(f time.Time) time.Time {
+	switch f.Weekday() {
+	case time.Tuesday:
+		return f.AddDate(0, 0, -1)
+	case time.Wednesday:
+		return f.AddDate(0, 0, -2)
+	case time.Thursday:
+		return f.AddDate(0, 0, 4)
+	case time.Friday:
+		return f.AddDate(0, 0, 3)
+	}
+	return f
+}
+
+func fecha(anio int, mes time.Month, dia int) time.Time {
+	return time.Date(anio, mes, dia, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,63 @@
+package feriados
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPascua(t *testing.T) {
+	tests := map[int]string{
+		2019: "2019-04-21",
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+	}
+	for anio, want := range tests {
+		if got := Pascua(anio).Format("2006-01-02"); got != want {
+			t.Errorf("Pascua(%d) = %s, want %s", anio, got, want)
+		}
+	}
+}
+
+func TestArgentina(t *testing.T) {
+	tests := []struct {
+		fecha  string
+		nombre string
+	}{
+		{"2024-02-12", "Carnaval"},
+		{"2024-02-13", "Carnaval"},
+		{"2024-03-29", "Viernes Santo"},
+		// 20 de noviembre de 2024 fue miércoles: pasa al lunes anterior.
+		{"2024-11-18", "Día de la Soberanía Nacional"},
+		// 17 de junio de 2025 fue martes: pasa al lunes anterior.
+		{"2025-06-16", "Paso a la Inmortalidad del General Martín Miguel de Güemes"},
+		// 20 de noviembre de 2025 fue jueves: pasa al lunes siguiente.
+		{"2025-11-24", "Día de la Soberanía Nacional"},
+		// Los que caen en fin de semana no se trasladan.
+		{"2025-08-17", "Paso a la Inmortalidad del General José de San Martín"},
+		{"2025-07-09", "Día de la Independencia"},
+	}
+
+	for _, tt := range tests {
+		fecha, _ := time.Parse("2006-01-02", tt.fecha)
+		feriado := Buscar(fecha)
+		if feriado == nil {
+			t.Errorf("Buscar(%s) = nil, want %q", tt.fecha, tt.nombre)
+			continue
+		}
+		if feriado.Nombre != tt.nombre {
+			t.Errorf("Buscar(%s) = %q, want %q", tt.fecha, feriado.Nombre, tt.nombre)
+		}
+	}
+
+	for _, habil := range []string{"2024-11-20", "2025-06-17", "2025-11-20", "2025-03-10"} {
+		fecha, _ := time.Parse("2006-01-02", habil)
+		if feriado := Buscar(fecha); feriado != nil {
+			t.Errorf("Buscar(%s) = %q, want nil", habil, feriado.Nombre)
+		}
+	}
+
+	if n := len(Argentina(2025)); n != 16 {
+		t.Errorf("len(Argentina(2025)) = %d, want 16", n)
+	}
+}
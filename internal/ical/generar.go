@@ -0,0 +1,195 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"yoyaku/internal/models"
+)
+
+const (
+	prodID             = "-//yoyaku//Agenda//ES"
+	formatoUTC         = "20060102T150405Z"
+	duracionPorDefecto = 30
+	largoMaximoLinea   = 75
+)
+
+type Opciones struct {
+	// NombreCalendario se publica como X-WR-CALNAME.
+	NombreCalendario string
+	// Redactar reemplaza el nombre del paciente y omite teléfono y notas,
+	// para calendarios que se sincronizan con servicios de terceros.
+	Redactar bool
+	// Zona es la zona horaria en la que están expresados Fecha y Hora de los
+	// turnos. Si es nil se usa la zona local.
+	Zona *time.Location
+	// IntervaloActualizacion sugiere a los calendarios suscriptos cada
+	// cuánto volver a pedir el feed.
+	IntervaloActualizacion time.Duration
+}
+
+// UID devuelve el identificador estable de un turno, que se mantiene entre
+// exportaciones para que los calendarios actualicen el evento en lugar de
+// duplicarlo.
+func UID(turnoID int64) string {
+	return fmt.Sprintf("turno-%d@yoyaku", turnoID)
+}
+
+// Generar escribe un VCALENDAR (RFC 5545) con un VEVENT por turno.
+func Generar(w io.Writer, turnos []models.Turno, opciones Opciones) error {
+	zona := opciones.Zona
+	if zona == nil {
+		zona = time.Local
+	}
+
+	e := &escritor{w: bufio.NewWriter(w)}
+	e.linea("BEGIN:VCALENDAR")
+	e.linea("VERSION:2.0")
+	e.linea("PRODID:" + prodID)
+	e.linea("CALSCALE:GREGORIAN")
+	e.linea("METHOD:PUBLISH")
+	if opciones.NombreCalendario != "" {
+		e.linea("X-WR-CALNAME:" + escaparTexto(opciones.NombreCalendario))
+	}
+	if minutos := int(opciones.IntervaloActualizacion / time.Minute); minutos > 0 {
+		e.linea(fmt.Sprintf("REFRESH-INTERVAL;VALUE=DURATION:PT%dM", minutos))
+		e.linea(fmt.Sprintf("X-PUBLISHED-TTL:PT%dM", minutos))
+	}
+
+	for _, turno := range turnos {
+		inicio, fin, err := Horario(turno, zona)
+		if err != nil {
+			return fmt.Errorf("turno %d: %w", turno.ID, err)
+		}
+
+		modificado := turno.UpdatedAt
+		if modificado.IsZero() {
+			modificado = time.Now()
+		}
+
+		e.linea("BEGIN:VEVENT")
+		e.linea("UID:" + UID(turno.ID))
+		e.linea("DTSTAMP:" + modificado.UTC().Format(formatoUTC))
+		e.linea("LAST-MODIFIED:" + modificado.UTC().Format(formatoUTC))
+		e.linea("DTSTART:" + inicio.UTC().Format(formatoUTC))
+		e.linea("DTEND:" + fin.UTC().Format(formatoUTC))
+		e.linea("SUMMARY:" + escaparTexto(resumen(turno, opciones.Redactar)))
+		if descripcion := descripcion(turno, opciones.Redactar); descripcion != "" {
+			e.linea("DESCRIPTION:" + escaparTexto(descripcion))
+		}
+		e.linea("STATUS:" + Estado(turno.Estado))
+		e.linea("CATEGORIES:" + escaparTexto(string(turno.Estado)))
+		e.linea("TRANSP:OPAQUE")
+		e.linea("END:VEVENT")
+	}
+
+	e.linea("END:VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// Horario calcula el inicio y el fin del turno a partir de Fecha, Hora y
+// Duracion en la zona indicada.
+func Horario(turno models.Turno, zona *time.Location) (time.Time, time.Time, error) {
+	if !turno.Hora.Valida() {
+		return time.Time{}, time.Time{}, fmt.Errorf("hora inválida: %d minutos", int(turno.Hora))
+	}
+
+	duracion := turno.Duracion
+	if duracion <= 0 {
+		duracion = duracionPorDefecto
+	}
+
+	inicio := turno.Hora.EnFecha(turno.Fecha, zona)
+	return inicio, inicio.Add(time.Duration(duracion) * time.Minute), nil
+}
+
+// Estado traduce el estado del turno al STATUS de un VEVENT.
+func Estado(estado models.EstadoTurno) string {
+	switch estado {
+	case models.EstadoPendiente:
+		return "TENTATIVE"
+	case models.EstadoCancelado:
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+func resumen(turno models.Turno, redactar bool) string {
+	if redactar || turno.Paciente == nil {
+		if turno.Estado == models.EstadoCancelado {
+			return "Turno cancelado"
+		}
+		return "Turno"
+	}
+	if turno.Motivo == "" {
+		return turno.Paciente.Nombre
+	}
+	return turno.Paciente.Nombre + " - " + turno.Motivo
+}
+
+func descripcion(turno models.Turno, redactar bool) string {
+	if redactar {
+		return ""
+	}
+
+	var partes []string
+	if turno.Motivo != "" {
+		partes = append(partes, "Motivo: "+turno.Motivo)
+	}
+	if turno.Paciente != nil && turno.Paciente.Telefono != "" {
+		partes = append(partes, "Teléfono: "+turno.Paciente.Telefono)
+	}
+	if turno.Notas != "" {
+		partes = append(partes, "Notas: "+turno.Notas)
+	}
+	return strings.Join(partes, "\n")
+}
+
+var escapeTexto = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", "",
+)
+
+func escaparTexto(s string) string {
+	return escapeTexto.Replace(s)
+}
+
+type escritor struct {
+	w   *bufio.Writer
+	err error
+}
+
+// linea escribe una línea de contenido plegándola cada 75 octetos sin
+// cortar caracteres multibyte, como exige la sección 3.1 del RFC 5545.
+func (e *escritor) linea(s string) {
+	if e.err != nil {
+		return
+	}
+
+	limite := largoMaximoLinea
+	for len(s) > limite {
+		corte := limite
+		for corte > 0 && !utf8.RuneStart(s[corte]) {
+			corte--
+		}
+		if _, e.err = e.w.WriteString(s[:corte] + "\r\n "); e.err != nil {
+			return
+		}
+		s = s[corte:]
+		// Las líneas de continuación empiezan con un espacio
+		limite = largoMaximoLinea - 1
+	}
+	_, e.err = e.w.WriteString(s + "\r\n")
+}
@@ -0,0 +1,105 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+func turnoDePrueba() models.Turno {
+	return models.Turno{
+		ID:       42,
+		Fecha:    time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Hora:     models.NuevaHora(9, 30),
+		Duracion: 45,
+		Motivo:   "Control; presión, arterial",
+		Estado:   models.EstadoPendiente,
+		Paciente: &models.Paciente{
+			Nombre:   "María González",
+			Telefono: "+54 11 1234-5678",
+		},
+		UpdatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestGenerar(t *testing.T) {
+	buenosAires := time.FixedZone("ART", -3*60*60)
+
+	var buf bytes.Buffer
+	err := Generar(&buf, []models.Turno{turnoDePrueba()}, Opciones{
+		NombreCalendario: "Consultorio",
+		Zona:             buenosAires,
+	})
+	if err != nil {
+		t.Fatalf("Generar() error = %v", err)
+	}
+	salida := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Consultorio\r\n",
+		"UID:turno-42@yoyaku\r\n",
+		"DTSTART:20250310T123000Z\r\n",
+		"DTEND:20250310T131500Z\r\n",
+		"STATUS:TENTATIVE\r\n",
+		`SUMMARY:María González - Control\; presión\, arterial`,
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(salida, want) {
+			t.Errorf("La salida no contiene %q:\n%s", want, salida)
+		}
+	}
+
+	for _, linea := range strings.Split(salida, "\r\n") {
+		if len(linea) > largoMaximoLinea {
+			t.Errorf("Línea de %d octetos sin plegar: %q", len(linea), linea)
+		}
+	}
+}
+
+func TestGenerar_Redactado(t *testing.T) {
+	turno := turnoDePrueba()
+	turno.Notas = "Alergia a la penicilina"
+
+	var buf bytes.Buffer
+	if err := Generar(&buf, []models.Turno{turno}, Opciones{Redactar: true}); err != nil {
+		t.Fatalf("Generar() error = %v", err)
+	}
+	salida := buf.String()
+
+	for _, dato := range []string{"María", "1234", "penicilina", "DESCRIPTION"} {
+		if strings.Contains(salida, dato) {
+			t.Errorf("La salida redactada contiene %q", dato)
+		}
+	}
+	if !strings.Contains(salida, "SUMMARY:Turno\r\n") {
+		t.Errorf("SUMMARY redactado incorrecto:\n%s", salida)
+	}
+}
+
+func TestGenerar_HoraInvalida(t *testing.T) {
+	turno := turnoDePrueba()
+	turno.Hora = models.NuevaHora(25, 0)
+
+	var buf bytes.Buffer
+	if err := Generar(&buf, []models.Turno{turno}, Opciones{}); err == nil {
+		t.Error("Generar() con hora inválida should fail")
+	}
+}
+
+func TestEstado(t *testing.T) {
+	tests := map[models.EstadoTurno]string{
+		models.EstadoPendiente:  "TENTATIVE",
+		models.EstadoConfirmado: "CONFIRMED",
+		models.EstadoAtendido:   "CONFIRMED",
+		models.EstadoCancelado:  "CANCELLED",
+	}
+	for estado, want := range tests {
+		if got := Estado(estado); got != want {
+			t.Errorf("Estado(%s) = %s, want %s", estado, got, want)
+		}
+	}
+}
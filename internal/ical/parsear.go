@@ -0,0 +1,319 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Evento es un VEVENT leído de un archivo .ics.
+type Evento struct {
+	UID         string
+	Resumen     string
+	Descripcion string
+	Inicio      time.Time
+	Fin         time.Time
+	TodoElDia   bool
+	Estado      string
+	Asistentes  []Asistente
+	Regla       *Regla
+	Excepciones []time.Time
+	// Advertencias describe partes del evento que no se pudieron
+	// interpretar, como reglas de repetición no soportadas.
+	Advertencias []string
+
+	// zonaInicio es la zona en la que está escrito DTSTART. Las
+	// repeticiones conservan la hora en esa zona, aunque Inicio esté
+	// convertido a la del consultorio.
+	zonaInicio *time.Location
+	// diasExcluidos son los EXDATE sin hora, que excluyen el día completo.
+	// Excepciones tiene sólo los que indican fecha y hora.
+	diasExcluidos []time.Time
+}
+
+// Asistente es un ATTENDEE del evento.
+type Asistente struct {
+	Nombre string
+	Email  string
+}
+
+// Duracion devuelve la duración del evento, o cero si no tiene fin.
+func (e *Evento) Duracion() time.Duration {
+	if e.Fin.IsZero() || e.Fin.Before(e.Inicio) {
+		return 0
+	}
+	return e.Fin.Sub(e.Inicio)
+}
+
+type propiedad struct {
+	nombre     string
+	parametros map[string]string
+	valor      string
+}
+
+// Parsear lee los VEVENT de un calendario. Las fechas con TZID desconocido o
+// sin zona (hora flotante) se interpretan en zona; si es nil, en la local.
+func Parsear(r io.Reader, zona *time.Location) ([]Evento, error) {
+	if zona == nil {
+		zona = time.Local
+	}
+
+	lineas, err := desplegarLineas(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventos []Evento
+	var actual *Evento
+	// Profundidad de componentes anidados dentro del VEVENT (VALARM)
+	anidado := 0
+
+	for i, linea := range lineas {
+		if linea == "" {
+			continue
+		}
+		prop, err := parsearPropiedad(linea)
+		if err != nil {
+			return nil, fmt.Errorf("línea %d: %w", i+1, err)
+		}
+
+		switch {
+		case prop.nombre == "BEGIN" && strings.EqualFold(prop.valor, "VEVENT"):
+			actual = &Evento{}
+		case actual == nil:
+			continue
+		case prop.nombre == "BEGIN":
+			anidado++
+		case prop.nombre == "END" && anidado > 0:
+			anidado--
+		case anidado > 0:
+			continue
+		case prop.nombre == "END" && strings.EqualFold(prop.valor, "VEVENT"):
+			if actual.Inicio.IsZero() {
+				return nil, fmt.Errorf("evento %q sin DTSTART", actual.UID)
+			}
+			eventos = append(eventos, *actual)
+			actual = nil
+		default:
+			if err := actual.aplicar(prop, zona); err != nil {
+				return nil, fmt.Errorf("evento %q: %w", actual.UID, err)
+			}
+		}
+	}
+
+	return eventos, nil
+}
+
+func (e *Evento) aplicar(prop propiedad, zona *time.Location) error {
+	var err error
+	switch prop.nombre {
+	case "UID":
+		e.UID = prop.valor
+	case "SUMMARY":
+		e.Resumen = desescaparTexto(prop.valor)
+	case "DESCRIPTION":
+		e.Descripcion = desescaparTexto(prop.valor)
+	case "STATUS":
+		e.Estado = strings.ToUpper(prop.valor)
+	case "DTSTART":
+		e.Inicio, e.TodoElDia, err = parsearFecha(prop, zona)
+		e.zonaInicio = zonaDeFecha(prop, zona)
+	case "DTEND":
+		e.Fin, _, err = parsearFecha(prop, zona)
+	case "DURATION":
+		var d time.Duration
+		if d, err = parsearDuracion(prop.valor); err == nil && !e.Inicio.IsZero() {
+			e.Fin = e.Inicio.Add(d)
+		}
+	case "ATTENDEE":
+		e.Asistentes = append(e.Asistentes, Asistente{
+			Nombre: prop.parametros["CN"],
+			Email:  strings.TrimPrefix(strings.ToLower(prop.valor), "mailto:"),
+		})
+	case "RRULE":
+		regla, errRegla := parsearRegla(prop.valor, zona)
+		if errRegla != nil {
+			// Se importa sólo la primera ocurrencia y se avisa
+			e.Advertencias = append(e.Advertencias, errRegla.Error())
+			return nil
+		}
+		e.Regla = regla
+	case "EXDATE":
+		for _, valor := range strings.Split(prop.valor, ",") {
+			p := prop
+			p.valor = valor
+			var t time.Time
+			var todoElDia bool
+			if t, todoElDia, err = parsearFecha(p, zona); err != nil {
+				break
+			}
+			if todoElDia {
+				e.diasExcluidos = append(e.diasExcluidos, t)
+				continue
+			}
+			// Se conserva la zona en la que está escrita para comparar el día
+			e.Excepciones = append(e.Excepciones, t.In(zonaDeFecha(p, zona)))
+		}
+	}
+	return err
+}
+
+// desplegarLineas une las líneas plegadas (las que empiezan con espacio o
+// tabulación continúan la anterior).
+func desplegarLineas(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lineas []string
+	for scanner.Scan() {
+		linea := strings.TrimRight(scanner.Text(), "\r")
+		if len(linea) > 0 && (linea[0] == ' ' || linea[0] == '\t') && len(lineas) > 0 {
+			lineas[len(lineas)-1] += linea[1:]
+			continue
+		}
+		lineas = append(lineas, linea)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo calendario: %w", err)
+	}
+	return lineas, nil
+}
+
+// parsearPropiedad separa "NOMBRE;PARAM=valor;PARAM2="a:b":valor", respetando
+// los dos puntos dentro de parámetros entre comillas.
+func parsearPropiedad(linea string) (propiedad, error) {
+	prop := propiedad{parametros: map[string]string{}}
+
+	enComillas := false
+	separador := -1
+	for i, r := range linea {
+		if r == '"' {
+			enComillas = !enComillas
+		} else if r == ':' && !enComillas {
+			separador = i
+			break
+		}
+	}
+	if separador < 0 {
+		return prop, fmt.Errorf("propiedad inválida %q", linea)
+	}
+
+	cabecera := linea[:separador]
+	prop.valor = linea[separador+1:]
+
+	partes := dividirParametros(cabecera)
+	prop.nombre = strings.ToUpper(partes[0])
+	for _, p := range partes[1:] {
+		clave, valor, _ := strings.Cut(p, "=")
+		prop.parametros[strings.ToUpper(clave)] = strings.Trim(valor, `"`)
+	}
+
+	return prop, nil
+}
+
+func dividirParametros(s string) []string {
+	var partes []string
+	enComillas := false
+	inicio := 0
+	for i, r := range s {
+		if r == '"' {
+			enComillas = !enComillas
+		} else if r == ';' && !enComillas {
+			partes = append(partes, s[inicio:i])
+			inicio = i + 1
+		}
+	}
+	return append(partes, s[inicio:])
+}
+
+func parsearFecha(prop propiedad, zona *time.Location) (time.Time, bool, error) {
+	valor := strings.TrimSpace(prop.valor)
+
+	if prop.parametros["VALUE"] == "DATE" || len(valor) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", valor, zona)
+		if err != nil {
+			return t, true, fmt.Errorf("fecha inválida %q", valor)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(valor, "Z") {
+		t, err := time.Parse(formatoUTC, valor)
+		if err != nil {
+			return t, false, fmt.Errorf("fecha inválida %q", valor)
+		}
+		return t.In(zona), false, nil
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", valor, zonaDeFecha(prop, zona))
+	if err != nil {
+		return t, false, fmt.Errorf("fecha inválida %q", valor)
+	}
+	return t.In(zona), false, nil
+}
+
+// zonaDeFecha devuelve la zona en la que está escrita la fecha: UTC si
+// termina en Z, la de su TZID si se conoce y, si no, zona.
+func zonaDeFecha(prop propiedad, zona *time.Location) *time.Location {
+	valor := strings.TrimSpace(prop.valor)
+	if prop.parametros["VALUE"] != "DATE" && len(valor) != len("20060102") && strings.HasSuffix(valor, "Z") {
+		return time.UTC
+	}
+	if tzid := prop.parametros["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			return l
+		}
+	}
+	return zona
+}
+
+// parsearDuracion interpreta duraciones RFC 5545 como "PT45M", "PT1H30M" o
+// "P1D".
+func parsearDuracion(valor string) (time.Duration, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(strings.ToUpper(valor), "+"), "P")
+	if s == strings.ToUpper(valor) {
+		return 0, fmt.Errorf("duración inválida %q", valor)
+	}
+
+	var total time.Duration
+	enHora := false
+	numero := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			numero += string(r)
+		case r == 'T':
+			enHora = true
+		default:
+			n, err := strconv.Atoi(numero)
+			if err != nil {
+				return 0, fmt.Errorf("duración inválida %q", valor)
+			}
+			numero = ""
+			switch {
+			case r == 'W':
+				total += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D':
+				total += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && enHora:
+				total += time.Duration(n) * time.Hour
+			case r == 'M' && enHora:
+				total += time.Duration(n) * time.Minute
+			case r == 'S' && enHora:
+				total += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("duración inválida %q", valor)
+			}
+		}
+	}
+	return total, nil
+}
+
+var unescapeTexto = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func desescaparTexto(s string) string {
+	return unescapeTexto.Replace(s)
+}
@@ -0,0 +1,243 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+const calendarioGoogle = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:abc123@google.com\r\n" +
+	"DTSTART;TZID=America/Argentina/Buenos_Aires:20250310T093000\r\n" +
+	"DTEND;TZID=America/Argentina/Buenos_Aires:20250310T100000\r\n" +
+	"SUMMARY:Control\\, presión\r\n" +
+	"DESCRIPTION:Llamar al 11 1234-5678\\nTraer estudios\r\n" +
+	"ATTENDEE;CN=\"Pérez, Juan\";ROLE=REQ-PARTICIPANT:mailto:Juan@Email.com\r\n" +
+	"RRULE:FREQ=WEEKLY;COUNT=4;BYDAY=MO,TH\r\n" +
+	"EXDATE;TZID=America/Argentina/Buenos_Aires:20250313T093000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT10M\r\n" +
+	"DESCRIPTION:Recordatorio\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:largo\r\n" +
+	"DTSTART:20250311T150000Z\r\n" +
+	"DURATION:PT1H15M\r\n" +
+	"SUMMARY:Una descripción muy larga que el generador tuvo que plegar en varias\r\n" +
+	"  líneas\r\n" +
+	"STATUS:cancelled\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParsear(t *testing.T) {
+	buenosAires, err := time.LoadLocation("America/Argentina/Buenos_Aires")
+	if err != nil {
+		t.Skipf("zona horaria no disponible: %v", err)
+	}
+
+	eventos, err := Parsear(strings.NewReader(calendarioGoogle), buenosAires)
+	if err != nil {
+		t.Fatalf("Parsear() error = %v", err)
+	}
+	if len(eventos) != 2 {
+		t.Fatalf("len(eventos) = %d, want 2", len(eventos))
+	}
+
+	ev := eventos[0]
+	if ev.Resumen != "Control, presión" {
+		t.Errorf("Resumen = %q", ev.Resumen)
+	}
+	if ev.Descripcion != "Llamar al 11 1234-5678\nTraer estudios" {
+		t.Errorf("Descripcion = %q (el VALARM no debe pisarla)", ev.Descripcion)
+	}
+	if ev.Duracion() != 30*time.Minute {
+		t.Errorf("Duracion() = %v, want 30m", ev.Duracion())
+	}
+	if len(ev.Asistentes) != 1 || ev.Asistentes[0].Nombre != "Pérez, Juan" || ev.Asistentes[0].Email != "juan@email.com" {
+		t.Errorf("Asistentes = %+v", ev.Asistentes)
+	}
+
+	largo := eventos[1]
+	if largo.Resumen != "Una descripción muy larga que el generador tuvo que plegar en varias líneas" {
+		t.Errorf("Resumen plegado = %q", largo.Resumen)
+	}
+	if largo.Estado != "CANCELLED" || largo.Duracion() != 75*time.Minute {
+		t.Errorf("Estado/Duracion = %s/%v", largo.Estado, largo.Duracion())
+	}
+	if got := largo.Inicio.Format("15:04"); got != "12:00" {
+		t.Errorf("Inicio en zona local = %s, want 12:00", got)
+	}
+}
+
+func TestOcurrencias(t *testing.T) {
+	zona := time.UTC
+	inicio := time.Date(2025, 1, 31, 10, 0, 0, 0, zona)
+	limite := time.Date(2025, 12, 31, 0, 0, 0, 0, zona)
+
+	tests := []struct {
+		name  string
+		regla string
+		want  []string
+	}{
+		{"Diaria con intervalo", "FREQ=DAILY;INTERVAL=2;COUNT=3", []string{"01-31", "02-02", "02-04"}},
+		{"Semanal hasta fecha", "FREQ=WEEKLY;UNTIL=20250215T000000Z", []string{"01-31", "02-07", "02-14"}},
+		{"Semanal por días", "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3", []string{"01-31", "02-03", "02-07"}},
+		{"Mensual saltea meses cortos", "FREQ=MONTHLY;COUNT=3", []string{"01-31", "03-31", "05-31"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regla, err := parsearRegla(tt.regla, zona)
+			if err != nil {
+				t.Fatalf("parsearRegla() error = %v", err)
+			}
+			ev := Evento{Inicio: inicio, Regla: regla}
+
+			var got []string
+			for _, o := range ev.Ocurrencias(limite) {
+				got = append(got, o.Format("01-02"))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Ocurrencias() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOcurrencias_ConExcepcion(t *testing.T) {
+	eventos, err := Parsear(strings.NewReader(calendarioGoogle), time.UTC)
+	if err != nil {
+		t.Fatalf("Parsear() error = %v", err)
+	}
+
+	// COUNT=4 incluye la ocurrencia excluida por EXDATE
+	ocurrencias := eventos[0].Ocurrencias(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(ocurrencias) != 3 {
+		t.Errorf("len(Ocurrencias()) = %d, want 3", len(ocurrencias))
+	}
+}
+
+func TestOcurrencias_ZonaDelEvento(t *testing.T) {
+	// Nueva York pasa a horario de verano el 9 de marzo y Buenos Aires no
+	// cambia: la tercera repetición, siempre a las 10 en Nueva York, es a
+	// las 11 en el consultorio.
+	cal := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\nDTSTART;TZID=America/New_York:20250301T100000\nRRULE:FREQ=WEEKLY;COUNT=3\nEND:VEVENT\nEND:VCALENDAR\n"
+	zona, err := time.LoadLocation("America/Argentina/Buenos_Aires")
+	if err != nil {
+		t.Skipf("zona horaria no disponible: %v", err)
+	}
+
+	eventos, err := Parsear(strings.NewReader(cal), zona)
+	if err != nil {
+		t.Fatalf("Parsear() error = %v", err)
+	}
+
+	var got []string
+	for _, o := range eventos[0].Ocurrencias(time.Date(2026, 1, 1, 0, 0, 0, 0, zona)) {
+		if o.Location() != zona {
+			t.Errorf("ocurrencia en %v, want %v", o.Location(), zona)
+		}
+		got = append(got, o.Format("01-02 15:04"))
+	}
+	want := []string{"03-01 12:00", "03-08 12:00", "03-15 11:00"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Ocurrencias() = %v, want %v", got, want)
+	}
+}
+
+func TestOcurrencias_ExcepcionEnOtraZona(t *testing.T) {
+	zona, err := time.LoadLocation("America/Argentina/Buenos_Aires")
+	if err != nil {
+		t.Skipf("zona horaria no disponible: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		evento string
+		zona   *time.Location
+		want   []string
+	}{
+		{
+			// 03:00 UTC es medianoche en el consultorio pero no excluye el día.
+			"Hora en UTC",
+			"DTSTART:20250310T120000Z\nRRULE:FREQ=DAILY;COUNT=3\nEXDATE:20250312T030000Z\n",
+			zona,
+			[]string{"03-10 09:00", "03-11 09:00", "03-12 09:00"},
+		},
+		{
+			// El día del evento de día completo es el escrito en el EXDATE.
+			"Día completo con hora en UTC",
+			"DTSTART;VALUE=DATE:20250310\nRRULE:FREQ=DAILY;COUNT=3\nEXDATE:20250311T000000Z\n",
+			zona,
+			[]string{"03-10 00:00", "03-12 00:00"},
+		},
+		{
+			// 22:30 en Buenos Aires ya es el día siguiente en UTC.
+			"Día en la zona del evento",
+			"DTSTART;TZID=America/Argentina/Buenos_Aires:20250310T223000\nRRULE:FREQ=DAILY;COUNT=3\nEXDATE;VALUE=DATE:20250311\n",
+			time.UTC,
+			[]string{"03-11 01:30", "03-13 01:30"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\n" + tt.evento + "END:VEVENT\nEND:VCALENDAR\n"
+			eventos, err := Parsear(strings.NewReader(cal), tt.zona)
+			if err != nil {
+				t.Fatalf("Parsear() error = %v", err)
+			}
+
+			var got []string
+			for _, o := range eventos[0].Ocurrencias(time.Date(2026, 1, 1, 0, 0, 0, 0, tt.zona)) {
+				got = append(got, o.Format("01-02 15:04"))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Ocurrencias() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsear_ReglaNoSoportada(t *testing.T) {
+	cal := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:x\nDTSTART:20250101T100000\nRRULE:FREQ=MONTHLY;BYSETPOS=-1;BYDAY=FR\nEND:VEVENT\nEND:VCALENDAR\n"
+
+	eventos, err := Parsear(strings.NewReader(cal), time.UTC)
+	if err != nil {
+		t.Fatalf("Parsear() error = %v", err)
+	}
+	if eventos[0].Regla != nil || len(eventos[0].Advertencias) != 1 {
+		t.Errorf("Regla = %v, Advertencias = %v", eventos[0].Regla, eventos[0].Advertencias)
+	}
+}
+
+func TestParsear_IdaYVuelta(t *testing.T) {
+	turno := turnoDePrueba()
+
+	var buf bytes.Buffer
+	if err := Generar(&buf, []models.Turno{turno}, Opciones{Zona: time.UTC}); err != nil {
+		t.Fatalf("Generar() error = %v", err)
+	}
+
+	eventos, err := Parsear(&buf, time.UTC)
+	if err != nil {
+		t.Fatalf("Parsear() error = %v", err)
+	}
+	if len(eventos) != 1 {
+		t.Fatalf("len(eventos) = %d, want 1", len(eventos))
+	}
+
+	ev := eventos[0]
+	if ev.UID != UID(turno.ID) || ev.Resumen != "María González - Control; presión, arterial" {
+		t.Errorf("UID/Resumen = %s/%s", ev.UID, ev.Resumen)
+	}
+	inicio, fin, _ := Horario(turno, time.UTC)
+	if !ev.Inicio.Equal(inicio) || !ev.Fin.Equal(fin) {
+		t.Errorf("Inicio/Fin = %v/%v, want %v/%v", ev.Inicio, ev.Fin, inicio, fin)
+	}
+}
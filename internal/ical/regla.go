@@ -0,0 +1,194 @@
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maximoOcurrencias limita la expansión de reglas sin fin o mal formadas.
+const maximoOcurrencias = 1000
+
+// Regla es un RRULE simple: frecuencia diaria, semanal, mensual o anual, con
+// INTERVAL, COUNT, UNTIL y BYDAY (sólo para reglas semanales).
+type Regla struct {
+	Frecuencia string
+	Intervalo  int
+	Cantidad   int
+	Hasta      time.Time
+	DiasSemana []time.Weekday
+}
+
+var diasSemana = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parsearRegla(valor string, zona *time.Location) (*Regla, error) {
+	regla := &Regla{Intervalo: 1}
+
+	for _, parte := range strings.Split(valor, ";") {
+		clave, v, _ := strings.Cut(parte, "=")
+		switch strings.ToUpper(clave) {
+		case "FREQ":
+			regla.Frecuencia = strings.ToUpper(v)
+		case "INTERVAL":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL inválido %q", v)
+			}
+			regla.Intervalo = n
+		case "COUNT":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT inválido %q", v)
+			}
+			regla.Cantidad = n
+		case "UNTIL":
+			t, _, err := parsearFecha(propiedad{valor: v, parametros: map[string]string{}}, zona)
+			if err != nil {
+				return nil, fmt.Errorf("UNTIL inválido %q", v)
+			}
+			regla.Hasta = t
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				dia, ok := diasSemana[strings.ToUpper(d)]
+				if !ok {
+					return nil, fmt.Errorf("BYDAY no soportado %q", v)
+				}
+				regla.DiasSemana = append(regla.DiasSemana, dia)
+			}
+		case "WKST":
+		default:
+			return nil, fmt.Errorf("regla de repetición no soportada: %s", parte)
+		}
+	}
+
+	switch regla.Frecuencia {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("frecuencia no soportada %q", regla.Frecuencia)
+	}
+	if len(regla.DiasSemana) > 0 && regla.Frecuencia != "WEEKLY" {
+		return nil, fmt.Errorf("BYDAY sólo se soporta en reglas semanales")
+	}
+
+	return regla, nil
+}
+
+// Ocurrencias devuelve los inicios de cada repetición del evento hasta
+// limite inclusive, sin las fechas excluidas por EXDATE. Un evento sin
+// regla tiene una única ocurrencia. La regla se expande en la zona de
+// DTSTART, para que un cambio de horario de verano en esa zona no mueva la
+// hora de las repeticiones, y cada ocurrencia se devuelve en la zona de
+// Inicio.
+func (e *Evento) Ocurrencias(limite time.Time) []time.Time {
+	if e.Regla == nil {
+		return []time.Time{e.Inicio}
+	}
+
+	r := e.Regla
+	salida := e.Inicio.Location()
+	zona := e.zonaInicio
+	if zona == nil {
+		zona = salida
+	}
+	var ocurrencias []time.Time
+	contadas := 0
+
+	agregar := func(t time.Time) bool {
+		if !r.Hasta.IsZero() && t.After(r.Hasta) {
+			return false
+		}
+		if t.After(limite) {
+			return false
+		}
+		contadas++
+		if r.Cantidad > 0 && contadas > r.Cantidad {
+			return false
+		}
+		if !e.excluida(t) {
+			ocurrencias = append(ocurrencias, t.In(salida))
+		}
+		return contadas < maximoOcurrencias
+	}
+
+	inicio := e.Inicio.In(zona)
+	for paso := 0; ; paso++ {
+		n := paso * r.Intervalo
+		switch r.Frecuencia {
+		case "DAILY":
+			if !agregar(inicio.AddDate(0, 0, n)) {
+				return ocurrencias
+			}
+		case "WEEKLY":
+			for _, t := range e.diasDeSemana(inicio.AddDate(0, 0, 7*n)) {
+				if t.Before(inicio) {
+					continue
+				}
+				if !agregar(t) {
+					return ocurrencias
+				}
+			}
+		case "MONTHLY", "YEARLY":
+			meses := n
+			if r.Frecuencia == "YEARLY" {
+				meses = 12 * n
+			}
+			t := time.Date(inicio.Year(), inicio.Month()+time.Month(meses), inicio.Day(),
+				inicio.Hour(), inicio.Minute(), inicio.Second(), 0, inicio.Location())
+			// Los meses sin ese día (31, 29 de febrero) no tienen ocurrencia
+			if t.Day() != inicio.Day() {
+				if t.After(limite) {
+					return ocurrencias
+				}
+				continue
+			}
+			if !agregar(t) {
+				return ocurrencias
+			}
+		}
+	}
+}
+
+// diasDeSemana devuelve las ocurrencias de la semana que contiene referencia
+// según BYDAY, o la propia referencia si la regla no lo indica.
+func (e *Evento) diasDeSemana(referencia time.Time) []time.Time {
+	if len(e.Regla.DiasSemana) == 0 {
+		return []time.Time{referencia}
+	}
+
+	lunes := referencia.AddDate(0, 0, -((int(referencia.Weekday()) + 6) % 7))
+	dias := make([]time.Time, 0, len(e.Regla.DiasSemana))
+	for _, d := range e.Regla.DiasSemana {
+		dias = append(dias, lunes.AddDate(0, 0, (int(d)+6)%7))
+	}
+	sort.Slice(dias, func(i, j int) bool { return dias[i].Before(dias[j]) })
+	return dias
+}
+
+// excluida indica si la ocurrencia t, expresada en la zona de DTSTART, cae
+// en un EXDATE. Los EXDATE sin hora, y todos los de un evento de día
+// completo, excluyen el día escrito en el EXDATE, que se compara con el día
+// de la ocurrencia en esa zona.
+func (e *Evento) excluida(t time.Time) bool {
+	for _, ex := range e.Excepciones {
+		if ex.Equal(t) || e.TodoElDia && mismoDia(ex, t) {
+			return true
+		}
+	}
+	for _, d := range e.diasExcluidos {
+		if mismoDia(d, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func mismoDia(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Hoja es el contenido tabular de un archivo importado: la primera fila se
+// toma como encabezados.
+type Hoja struct {
+	Encabezados []string
+	Filas       []Fila
+}
+
+// Fila es una fila de datos con su número en el archivo original (contando
+// los encabezados como fila 1), para poder referenciarla en el reporte.
+type Fila struct {
+	Numero  int
+	Valores []string
+}
+
+// LeerArchivo lee un archivo CSV o XLSX según su extensión.
+func LeerArchivo(ruta string) (*Hoja, error) {
+	switch strings.ToLower(filepath.Ext(ruta)) {
+	case ".csv", ".txt":
+		f, err := os.Open(ruta)
+		if err != nil {
+			return nil, fmt.Errorf("error abriendo archivo: %w", err)
+		}
+		defer f.Close()
+		return LeerCSV(f)
+	case ".xlsx":
+		return LeerXLSX(ruta)
+	default:
+		return nil, fmt.Errorf("formato de archivo no soportado: %s", filepath.Ext(ruta))
+	}
+}
+
+// LeerCSV lee un CSV separado por comas, punto y coma o tabulaciones. El
+// separador se detecta a partir de la fila de encabezados, ya que las
+// planillas exportadas en español suelen usar punto y coma.
+func LeerCSV(r io.Reader) (*Hoja, error) {
+	br := bufio.NewReader(r)
+
+	primeraLinea, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("error leyendo CSV: %w", err)
+	}
+	primeraLinea = bytes.TrimPrefix(primeraLinea, []byte("\ufeff"))
+	if i := bytes.IndexByte(primeraLinea, '\n'); i >= 0 {
+		primeraLinea = primeraLinea[:i]
+	}
+
+	lector := csv.NewReader(br)
+	lector.Comma = detectarSeparador(string(primeraLinea))
+	lector.FieldsPerRecord = -1
+	lector.TrimLeadingSpace = true
+
+	var filas []Fila
+	for {
+		registro, err := lector.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo CSV: %w", err)
+		}
+		// El lector de CSV saltea las líneas vacías; se usa la línea real
+		// para que el reporte coincida con la planilla.
+		linea, _ := lector.FieldPos(0)
+		filas = append(filas, Fila{Numero: linea, Valores: registro})
+	}
+
+	return nuevaHoja(filas)
+}
+
+func detectarSeparador(linea string) rune {
+	separador := ','
+	maximo := strings.Count(linea, ",")
+	for _, candidato := range []rune{';', '\t'} {
+		if n := strings.Count(linea, string(candidato)); n > maximo {
+			separador, maximo = candidato, n
+		}
+	}
+	return separador
+}
+
+// nuevaHoja arma la hoja tomando la primera fila como encabezados y
+// descartando las filas sin datos.
+func nuevaHoja(filas []Fila) (*Hoja, error) {
+	if len(filas) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+
+	encabezados := make([]string, len(filas[0].Valores))
+	for i, e := range filas[0].Valores {
+		encabezados[i] = strings.TrimSpace(strings.TrimPrefix(e, "\ufeff"))
+	}
+
+	hoja := &Hoja{Encabezados: encabezados}
+	for _, fila := range filas[1:] {
+		if filaVacia(fila.Valores) {
+			continue
+		}
+		hoja.Filas = append(hoja.Filas, fila)
+	}
+
+	return hoja, nil
+}
+
+func filaVacia(registro []string) bool {
+	for _, valor := range registro {
+		if strings.TrimSpace(valor) != "" {
+			return false
+		}
+	}
+	return true
+}
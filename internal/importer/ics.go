@@ -0,0 +1,423 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/ical"
+	"yoyaku/internal/models"
+	"yoyaku/internal/telefono"
+)
+
+// horizonteRepeticiones es hasta cuándo se expanden las reglas de
+// repetición sin fin si no se indica otro límite.
+const horizonteRepeticiones = 365 * 24 * time.Hour
+
+// OpcionesICS controla la importación de un calendario.
+type OpcionesICS struct {
+	// Simular sólo arma el reporte, sin guardar nada.
+	Simular bool
+	// PermitirSuperpuestos importa también los turnos que se superponen con
+	// otros; si no, se informan como conflicto y se omiten.
+	PermitirSuperpuestos bool
+	// Hasta limita la expansión de eventos repetidos. Si es cero se usa un
+	// año a partir de Ahora.
+	Hasta time.Time
+	// Ahora decide si un evento confirmado ya pasó (atendido) o no
+	// (confirmado). Si es cero se usa la hora actual.
+	Ahora time.Time
+	// Zona de la agenda. Si es nil se usa la local.
+	Zona *time.Location
+}
+
+var patronTelefono = regexp.MustCompile(`\+?\d[\d\s\-.()]{6,}\d`)
+
+// ImportarICSArchivo lee un archivo .ics y lo importa con ImportarICS.
+func (s *Service) ImportarICSArchivo(ruta string, opciones OpcionesICS) (*models.ReporteImportacionICS, error) {
+	f, err := os.Open(ruta)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo: %w", err)
+	}
+	defer f.Close()
+
+	reporte, err := s.ImportarICS(f, opciones)
+	if err != nil {
+		return nil, err
+	}
+	reporte.Archivo = ruta
+
+	return reporte, nil
+}
+
+// ImportarICS convierte cada ocurrencia de los VEVENT en un turno. El
+// paciente se busca por email, teléfono o nombre entre los existentes y, si
+// no aparece, se crea. Los turnos ya importados se informan como duplicados y
+// los que se superponen con otros como conflictos.
+func (s *Service) ImportarICS(r io.Reader, opciones OpcionesICS) (*models.ReporteImportacionICS, error) {
+	if opciones.Zona == nil {
+		opciones.Zona = time.Local
+	}
+	if opciones.Ahora.IsZero() {
+		opciones.Ahora = time.Now()
+	}
+	if opciones.Hasta.IsZero() {
+		opciones.Hasta = opciones.Ahora.Add(horizonteRepeticiones)
+	}
+
+	eventos, err := ical.Parsear(r, opciones.Zona)
+	if err != nil {
+		return nil, err
+	}
+
+	pacientes, err := s.pacienteRepo.ListarTodos()
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo pacientes existentes: %w", err)
+	}
+
+	imp := &importacionICS{
+		service:   s,
+		opciones:  opciones,
+		pacientes: pacientes,
+		porDia:    make(map[string][]models.Turno),
+		reporte: &models.ReporteImportacionICS{
+			Simulacion: opciones.Simular,
+			Eventos:    len(eventos),
+			Turnos:     []models.TurnoImportado{},
+		},
+	}
+
+	for i := range eventos {
+		if err := imp.evento(&eventos[i]); err != nil {
+			return nil, err
+		}
+	}
+	imp.reporte.PacientesNuevos = len(imp.pacientesACrear())
+
+	if opciones.Simular || len(imp.aceptados) == 0 {
+		return imp.reporte, nil
+	}
+
+	if err := imp.guardar(); err != nil {
+		return nil, fmt.Errorf("error importando turnos: %w", err)
+	}
+
+	return imp.reporte, nil
+}
+
+type importacionICS struct {
+	service   *Service
+	opciones  OpcionesICS
+	pacientes []models.Paciente
+	// nuevos son los pacientes no encontrados, compartidos entre sus turnos
+	nuevos []*models.Paciente
+	// porDia tiene los turnos existentes y los aceptados de cada fecha
+	porDia map[string][]models.Turno
+	// aceptados son los índices en reporte.Turnos de los turnos a crear
+	aceptados []int
+	// pacienteDe asocia cada turno aceptado con su paciente
+	pacienteDe map[int]*models.Paciente
+	reporte    *models.ReporteImportacionICS
+}
+
+func (imp *importacionICS) evento(ev *ical.Evento) error {
+	for _, inicio := range ev.Ocurrencias(imp.opciones.Hasta) {
+		resultado := models.TurnoImportado{UID: ev.UID, Resumen: ev.Resumen}
+		if len(ev.Advertencias) > 0 {
+			resultado.Mensaje = strings.Join(ev.Advertencias, "; ")
+		}
+
+		if ev.TodoElDia {
+			imp.invalido(resultado, "evento de día completo")
+			continue
+		}
+
+		nombre, motivo := separarResumen(ev)
+		paciente, nuevo := imp.resolverPaciente(ev, nombre)
+		if paciente == nil {
+			imp.invalido(resultado, "no se pudo identificar al paciente")
+			continue
+		}
+
+		duracion := int(ev.Duracion() / time.Minute)
+		if duracion <= 0 {
+			duracion = 30
+		}
+
+		fecha := time.Date(inicio.Year(), inicio.Month(), inicio.Day(), 0, 0, 0, 0, time.UTC)
+		resultado.Turno = models.Turno{
+			PacienteID: paciente.ID,
+			Paciente:   paciente,
+			Fecha:      fecha,
+			Hora:       models.HoraDe(inicio),
+			Duracion:   duracion,
+			Motivo:     motivo,
+			Estado:     imp.estado(ev, inicio.Add(time.Duration(duracion)*time.Minute)),
+			Notas:      strings.TrimSpace(ev.Descripcion),
+		}
+		resultado.PacienteNuevo = nuevo
+
+		existentes, err := imp.turnosDelDia(fecha)
+		if err != nil {
+			return err
+		}
+
+		if dup := buscarMismoTurno(resultado.Turno, existentes); dup != nil {
+			resultado.Estado = models.TurnoImportadoDuplicado
+			resultado.Mensaje = unirMensajes(resultado.Mensaje, "el turno ya existe")
+			resultado.Turno.ID = dup.ID
+			imp.reporte.Duplicados++
+			imp.reporte.Turnos = append(imp.reporte.Turnos, resultado)
+			continue
+		}
+
+		if resultado.Turno.Estado != models.EstadoCancelado {
+			if otro := imp.buscarSuperpuesto(resultado.Turno, existentes); otro != nil {
+				resultado.ConflictoConID = otro.ID
+				mensaje := fmt.Sprintf("se superpone con el turno de %s a las %s", nombrePaciente(otro), otro.Hora)
+				resultado.Mensaje = unirMensajes(resultado.Mensaje, mensaje)
+				imp.reporte.Conflictos++
+				if !imp.opciones.PermitirSuperpuestos {
+					resultado.Estado = models.TurnoImportadoConflicto
+					imp.reporte.Turnos = append(imp.reporte.Turnos, resultado)
+					continue
+				}
+			}
+		}
+
+		resultado.Estado = models.TurnoImportadoNuevo
+		imp.reporte.Nuevos++
+		clave := fecha.Format("2006-01-02")
+		imp.porDia[clave] = append(imp.porDia[clave], resultado.Turno)
+		imp.reporte.Turnos = append(imp.reporte.Turnos, resultado)
+
+		indice := len(imp.reporte.Turnos) - 1
+		imp.aceptados = append(imp.aceptados, indice)
+		if imp.pacienteDe == nil {
+			imp.pacienteDe = make(map[int]*models.Paciente)
+		}
+		imp.pacienteDe[indice] = paciente
+	}
+
+	return nil
+}
+
+func (imp *importacionICS) invalido(resultado models.TurnoImportado, mensaje string) {
+	resultado.Estado = models.TurnoImportadoInvalido
+	resultado.Mensaje = unirMensajes(mensaje, resultado.Mensaje)
+	imp.reporte.Invalidos++
+	imp.reporte.Turnos = append(imp.reporte.Turnos, resultado)
+}
+
+func (imp *importacionICS) estado(ev *ical.Evento, fin time.Time) models.EstadoTurno {
+	switch ev.Estado {
+	case "CANCELLED":
+		return models.EstadoCancelado
+	case "TENTATIVE":
+		return models.EstadoPendiente
+	}
+	if fin.Before(imp.opciones.Ahora) {
+		return models.EstadoAtendido
+	}
+	return models.EstadoConfirmado
+}
+
+// resolverPaciente busca al paciente del evento por email, teléfono y
+// nombre, en ese orden. Si no existe devuelve uno nuevo, que se reutiliza en
+// las demás ocurrencias.
+func (imp *importacionICS) resolverPaciente(ev *ical.Evento, nombre string) (*models.Paciente, bool) {
+	var email string
+	for _, a := range ev.Asistentes {
+		if a.Email != "" {
+			email = a.Email
+			break
+		}
+	}
+	tel := telefono.Normalizar(patronTelefono.FindString(ev.Resumen + "\n" + ev.Descripcion))
+
+	candidatos := make([]*models.Paciente, 0, len(imp.pacientes)+len(imp.nuevos))
+	for i := range imp.pacientes {
+		candidatos = append(candidatos, &imp.pacientes[i])
+	}
+	candidatos = append(candidatos, imp.nuevos...)
+
+	esNuevo := func(p *models.Paciente) bool { return p.ID == 0 }
+
+	if email != "" {
+		for _, p := range candidatos {
+			if strings.EqualFold(p.Email, email) {
+				return p, esNuevo(p)
+			}
+		}
+	}
+	if tel != "" {
+		for _, p := range candidatos {
+			if telefono.Iguales(p.Telefono, tel) {
+				return p, esNuevo(p)
+			}
+		}
+	}
+	if nombre != "" {
+		clave := normalizarTexto(nombre)
+		for _, p := range candidatos {
+			if normalizarTexto(p.Nombre) == clave {
+				return p, esNuevo(p)
+			}
+		}
+	}
+
+	if nombre == "" {
+		return nil, false
+	}
+
+	paciente := &models.Paciente{Nombre: nombre, Telefono: tel, Email: email}
+	imp.nuevos = append(imp.nuevos, paciente)
+	return paciente, true
+}
+
+// separarResumen obtiene el nombre del paciente y el motivo del evento. El
+// nombre sale del primer asistente con nombre o, si no hay, de la parte del
+// resumen anterior a " - " (el formato que usa la exportación de yoyaku).
+func separarResumen(ev *ical.Evento) (string, string) {
+	resumen := strings.TrimSpace(ev.Resumen)
+	nombre, motivo, conMotivo := strings.Cut(resumen, " - ")
+	if !conMotivo {
+		nombre, motivo = resumen, ""
+	}
+
+	for _, a := range ev.Asistentes {
+		if a.Nombre != "" {
+			if !conMotivo && !strings.EqualFold(a.Nombre, resumen) {
+				motivo = resumen
+			}
+			nombre = a.Nombre
+			break
+		}
+	}
+
+	// Los turnos exportados de forma redactada no identifican al paciente
+	if strings.EqualFold(nombre, "Turno") || strings.EqualFold(nombre, "Turno cancelado") {
+		nombre = ""
+	}
+
+	return strings.TrimSpace(nombre), strings.TrimSpace(motivo)
+}
+
+func (imp *importacionICS) turnosDelDia(fecha time.Time) ([]models.Turno, error) {
+	clave := fecha.Format("2006-01-02")
+	if turnos, ok := imp.porDia[clave]; ok {
+		return turnos, nil
+	}
+
+	turnos, err := imp.service.turnoRepo.ListarPorFecha(fecha)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo turnos del %s: %w", clave, err)
+	}
+	if turnos == nil {
+		turnos = []models.Turno{}
+	}
+	imp.porDia[clave] = turnos
+	return turnos, nil
+}
+
+func buscarMismoTurno(turno models.Turno, existentes []models.Turno) *models.Turno {
+	for i := range existentes {
+		e := &existentes[i]
+		if e.Hora != turno.Hora {
+			continue
+		}
+		if turno.Paciente.ID != 0 && e.PacienteID == turno.Paciente.ID {
+			return e
+		}
+		// Paciente nuevo repetido dentro del mismo archivo
+		if turno.Paciente.ID == 0 && e.Paciente == turno.Paciente {
+			return e
+		}
+	}
+	return nil
+}
+
+func (imp *importacionICS) buscarSuperpuesto(turno models.Turno, existentes []models.Turno) *models.Turno {
+	inicio, fin, err := ical.Horario(turno, imp.opciones.Zona)
+	if err != nil {
+		return nil
+	}
+	for i := range existentes {
+		e := &existentes[i]
+		if e.Estado == models.EstadoCancelado {
+			continue
+		}
+		eInicio, eFin, err := ical.Horario(*e, imp.opciones.Zona)
+		if err != nil {
+			continue
+		}
+		if inicio.Before(eFin) && eInicio.Before(fin) {
+			return e
+		}
+	}
+	return nil
+}
+
+// guardar crea los pacientes nuevos y los turnos aceptados en una única
+// transacción.
+func (imp *importacionICS) guardar() error {
+	return imp.service.db.Transaccion(func(tx *db.DB) error {
+		pacienteRepo := db.NewPacienteRepo(tx)
+		turnoRepo := db.NewTurnoRepo(tx)
+
+		for _, paciente := range imp.pacientesACrear() {
+			if err := pacienteRepo.Crear(paciente); err != nil {
+				return fmt.Errorf("error creando paciente %q: %w", paciente.Nombre, err)
+			}
+		}
+
+		for _, i := range imp.aceptados {
+			resultado := &imp.reporte.Turnos[i]
+			paciente := imp.pacienteDe[i]
+			resultado.Turno.PacienteID = paciente.ID
+			resultado.Turno.Paciente = paciente
+			if err := turnoRepo.Crear(&resultado.Turno); err != nil {
+				return fmt.Errorf("error creando turno %s: %w", resultado.UID, err)
+			}
+		}
+
+		imp.reporte.Importados = len(imp.aceptados)
+		return nil
+	})
+}
+
+// pacientesACrear devuelve los pacientes nuevos que tienen al menos un turno
+// aceptado; los que sólo aparecen en turnos omitidos no se crean.
+func (imp *importacionICS) pacientesACrear() []*models.Paciente {
+	var pacientes []*models.Paciente
+	vistos := make(map[*models.Paciente]bool)
+	for _, i := range imp.aceptados {
+		paciente := imp.pacienteDe[i]
+		if paciente.ID == 0 && !vistos[paciente] {
+			vistos[paciente] = true
+			pacientes = append(pacientes, paciente)
+		}
+	}
+	return pacientes
+}
+
+func nombrePaciente(turno *models.Turno) string {
+	if turno.Paciente == nil {
+		return "otro paciente"
+	}
+	return turno.Paciente.Nombre
+}
+
+func unirMensajes(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "; " + b
+}
@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+const calendarioImportacion = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:control-semanal
+DTSTART:20250303T090000
+DTEND:20250303T093000
+SUMMARY:Control semanal
+ATTENDEE;CN=Juan Pérez:mailto:juan@email.com
+RRULE:FREQ=WEEKLY;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:maria
+DTSTART:20250304T100000
+DURATION:PT45M
+SUMMARY:Maria Gonzalez - Resultados
+END:VEVENT
+BEGIN:VEVENT
+UID:superpuesto
+DTSTART:20250310T091500
+DTEND:20250310T094500
+SUMMARY:Ana Rodríguez - Consulta
+DESCRIPTION:Tel. 11 5555-6666
+END:VEVENT
+BEGIN:VEVENT
+UID:feriado
+DTSTART;VALUE=DATE:20250324
+SUMMARY:Feriado
+END:VEVENT
+BEGIN:VEVENT
+UID:redactado
+DTSTART:20250305T100000
+SUMMARY:Turno
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestImportarICS(t *testing.T) {
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer database.Close()
+
+	service := NewService(database)
+	pacienteRepo := db.NewPacienteRepo(database)
+	turnoRepo := db.NewTurnoRepo(database)
+
+	maria := &models.Paciente{Nombre: "María González", Telefono: "11 1234-5678"}
+	if err := pacienteRepo.Crear(maria); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	opciones := OpcionesICS{
+		Zona:  time.UTC,
+		Ahora: time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Simulación", func(t *testing.T) {
+		opciones.Simular = true
+		reporte, err := service.ImportarICS(strings.NewReader(calendarioImportacion), opciones)
+		if err != nil {
+			t.Fatalf("ImportarICS() error = %v", err)
+		}
+
+		// 3 controles de Juan, 1 de María; el de Ana se superpone con el
+		// control del 10/03 y el feriado y el redactado no son turnos
+		if reporte.Eventos != 5 || reporte.Nuevos != 4 || reporte.Conflictos != 1 || reporte.Invalidos != 2 {
+			t.Errorf("Eventos/Nuevos/Conflictos/Invalidos = %d/%d/%d/%d, want 5/4/1/2",
+				reporte.Eventos, reporte.Nuevos, reporte.Conflictos, reporte.Invalidos)
+		}
+		if reporte.PacientesNuevos != 1 {
+			t.Errorf("PacientesNuevos = %d, want 1 (Ana no se crea porque su turno tiene conflicto)", reporte.PacientesNuevos)
+		}
+
+		turnoMaria := reporte.Turnos[3]
+		if turnoMaria.Turno.PacienteID != maria.ID || turnoMaria.PacienteNuevo {
+			t.Errorf("El turno de María no se asoció al paciente existente: %+v", turnoMaria)
+		}
+		if turnoMaria.Turno.Motivo != "Resultados" || turnoMaria.Turno.Duracion != 45 || turnoMaria.Turno.Estado != models.EstadoAtendido {
+			t.Errorf("Turno de María = %+v", turnoMaria.Turno)
+		}
+		if reporte.Turnos[2].Turno.Estado != models.EstadoConfirmado {
+			t.Errorf("Turno futuro con estado %s, want confirmado", reporte.Turnos[2].Turno.Estado)
+		}
+
+		turnos, _ := turnoRepo.ListarPorRango(time.Time{}, time.Time{})
+		if len(turnos) != 0 {
+			t.Errorf("La simulación creó %d turnos", len(turnos))
+		}
+	})
+
+	t.Run("Importación", func(t *testing.T) {
+		opciones.Simular = false
+		reporte, err := service.ImportarICS(strings.NewReader(calendarioImportacion), opciones)
+		if err != nil {
+			t.Fatalf("ImportarICS() error = %v", err)
+		}
+		if reporte.Importados != 4 {
+			t.Errorf("Importados = %d, want 4", reporte.Importados)
+		}
+
+		turnos, _ := turnoRepo.ListarPorRango(time.Time{}, time.Time{})
+		if len(turnos) != 4 {
+			t.Fatalf("Turnos en la base = %d, want 4", len(turnos))
+		}
+		if turnos[0].Paciente.Nombre != "Juan Pérez" || turnos[0].Paciente.Email != "juan@email.com" {
+			t.Errorf("Paciente creado = %+v", turnos[0].Paciente)
+		}
+		pacientes, _ := pacienteRepo.ListarTodos()
+		if len(pacientes) != 2 {
+			t.Errorf("Pacientes = %d, want 2", len(pacientes))
+		}
+
+		// Reimportar el mismo archivo no duplica turnos
+		reporte, err = service.ImportarICS(strings.NewReader(calendarioImportacion), opciones)
+		if err != nil {
+			t.Fatalf("ImportarICS() error = %v", err)
+		}
+		if reporte.Importados != 0 || reporte.Duplicados != 4 {
+			t.Errorf("Reimportación: Importados/Duplicados = %d/%d, want 0/4", reporte.Importados, reporte.Duplicados)
+		}
+	})
+}
@@ -0,0 +1,273 @@
+package importer
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+	"yoyaku/internal/telefono"
+)
+
+const minimoDigitosTelefono = 6
+
+type Service struct {
+	db           *db.DB
+	pacienteRepo *db.PacienteRepo
+	turnoRepo    *db.TurnoRepo
+}
+
+func NewService(database *db.DB) *Service {
+	return &Service{
+		db:           database,
+		pacienteRepo: db.NewPacienteRepo(database),
+		turnoRepo:    db.NewTurnoRepo(database),
+	}
+}
+
+// ImportarArchivo lee el archivo y lo importa con Importar.
+func (s *Service) ImportarArchivo(ruta string, mapeo models.MapeoImportacion, simular bool) (*models.ReporteImportacion, error) {
+	hoja, err := LeerArchivo(ruta)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte, err := s.Importar(hoja, mapeo, simular)
+	if err != nil {
+		return nil, err
+	}
+	reporte.Archivo = ruta
+
+	return reporte, nil
+}
+
+// Importar valida cada fila de la hoja y la compara con los pacientes
+// existentes y con las filas anteriores. En modo simulación sólo devuelve el
+// reporte; si no, crea todos los pacientes nuevos en una única transacción.
+func (s *Service) Importar(hoja *Hoja, mapeo models.MapeoImportacion, simular bool) (*models.ReporteImportacion, error) {
+	columnas, err := resolverMapeo(hoja.Encabezados, mapeo)
+	if err != nil {
+		return nil, err
+	}
+
+	existentes, err := s.pacienteRepo.ListarTodos()
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo pacientes existentes: %w", err)
+	}
+
+	reporte := &models.ReporteImportacion{
+		Simulacion: simular,
+		Filas:      make([]models.FilaImportacion, 0, len(hoja.Filas)),
+	}
+
+	var nuevos []*models.Paciente
+	filasNuevas := make(map[*models.Paciente]int)
+
+	for _, fila := range hoja.Filas {
+		paciente := columnas.paciente(fila.Valores)
+		resultado := models.FilaImportacion{Fila: fila.Numero, Paciente: paciente}
+
+		if msg := validar(paciente); msg != "" {
+			resultado.Estado = models.FilaInvalida
+			resultado.Mensaje = msg
+			reporte.Invalidos++
+		} else if existente := buscarDuplicado(paciente, existentes, esDuplicado); existente != nil {
+			resultado.Estado = models.FilaDuplicada
+			resultado.DuplicadoDeID = existente.ID
+			resultado.Mensaje = fmt.Sprintf("ya existe como %s (%s)", existente.Nombre, existente.Telefono)
+			reporte.Duplicados++
+		} else if anterior := buscarDuplicadoEnLote(paciente, nuevos, esDuplicado); anterior != nil {
+			resultado.Estado = models.FilaDuplicada
+			resultado.Mensaje = fmt.Sprintf("repetido en la fila %d", filasNuevas[anterior])
+			reporte.Duplicados++
+		} else {
+			resultado.Estado = models.FilaNueva
+			if parecido := buscarDuplicado(paciente, existentes, posibleDuplicado); parecido != nil {
+				resultado.Estado = models.FilaPosibleDuplicado
+				resultado.DuplicadoDeID = parecido.ID
+				resultado.Mensaje = fmt.Sprintf("el teléfono se parece al de %s (%s)", parecido.Nombre, parecido.Telefono)
+				reporte.PosiblesDuplicados++
+			} else if anterior := buscarDuplicadoEnLote(paciente, nuevos, posibleDuplicado); anterior != nil {
+				resultado.Estado = models.FilaPosibleDuplicado
+				resultado.Mensaje = fmt.Sprintf("el teléfono se parece al de la fila %d", filasNuevas[anterior])
+				reporte.PosiblesDuplicados++
+			}
+			reporte.Nuevos++
+			p := paciente
+			nuevos = append(nuevos, &p)
+			filasNuevas[&p] = fila.Numero
+		}
+
+		reporte.Filas = append(reporte.Filas, resultado)
+	}
+
+	if simular || len(nuevos) == 0 {
+		return reporte, nil
+	}
+
+	if err := s.pacienteRepo.CrearLote(nuevos); err != nil {
+		return nil, fmt.Errorf("error importando pacientes: %w", err)
+	}
+	reporte.Importados = len(nuevos)
+
+	// Completar el reporte con los datos asignados por la base
+	i := 0
+	for j := range reporte.Filas {
+		if estado := reporte.Filas[j].Estado; estado == models.FilaNueva || estado == models.FilaPosibleDuplicado {
+			reporte.Filas[j].Paciente = *nuevos[i]
+			i++
+		}
+	}
+
+	return reporte, nil
+}
+
+// DetectarMapeo sugiere la columna correspondiente a cada campo a partir de
+// los nombres de encabezado más habituales.
+func DetectarMapeo(encabezados []string) models.MapeoImportacion {
+	candidatos := map[string][]string{
+		"nombre":   {"nombre", "nombre y apellido", "apellido y nombre", "paciente", "name", "nombre completo"},
+		"telefono": {"telefono", "tel", "celular", "movil", "whatsapp", "phone"},
+		"email":    {"email", "e-mail", "mail", "correo", "correo electronico"},
+		"notas":    {"notas", "observaciones", "comentarios", "notes"},
+	}
+
+	encontrado := make(map[string]string)
+	for _, encabezado := range encabezados {
+		clave := normalizarTexto(encabezado)
+		for campo, nombres := range candidatos {
+			if _, ok := encontrado[campo]; ok {
+				continue
+			}
+			for _, nombre := range nombres {
+				if clave == nombre {
+					encontrado[campo] = encabezado
+					break
+				}
+			}
+		}
+	}
+
+	return models.MapeoImportacion{
+		Nombre:   encontrado["nombre"],
+		Telefono: encontrado["telefono"],
+		Email:    encontrado["email"],
+		Notas:    encontrado["notas"],
+	}
+}
+
+type columnas struct {
+	nombre, telefono, email, notas int
+}
+
+func resolverMapeo(encabezados []string, mapeo models.MapeoImportacion) (*columnas, error) {
+	indice := func(nombre string, requerido bool, campo string) (int, error) {
+		if nombre == "" {
+			if requerido {
+				return -1, fmt.Errorf("falta indicar la columna de %s", campo)
+			}
+			return -1, nil
+		}
+		for i, encabezado := range encabezados {
+			if strings.EqualFold(strings.TrimSpace(encabezado), strings.TrimSpace(nombre)) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("la columna %q no existe en el archivo", nombre)
+	}
+
+	var c columnas
+	var err error
+	if c.nombre, err = indice(mapeo.Nombre, true, "nombre"); err != nil {
+		return nil, err
+	}
+	if c.telefono, err = indice(mapeo.Telefono, true, "teléfono"); err != nil {
+		return nil, err
+	}
+	if c.email, err = indice(mapeo.Email, false, "email"); err != nil {
+		return nil, err
+	}
+	if c.notas, err = indice(mapeo.Notas, false, "notas"); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *columnas) paciente(valores []string) models.Paciente {
+	valor := func(i int) string {
+		if i < 0 || i >= len(valores) {
+			return ""
+		}
+		return strings.TrimSpace(valores[i])
+	}
+
+	return models.Paciente{
+		Nombre:   strings.Join(strings.Fields(valor(c.nombre)), " "),
+		Telefono: telefono.Normalizar(valor(c.telefono)),
+		Email:    strings.ToLower(valor(c.email)),
+		Notas:    valor(c.notas),
+	}
+}
+
+func validar(paciente models.Paciente) string {
+	if paciente.Nombre == "" {
+		return "falta el nombre"
+	}
+	if paciente.Telefono == "" {
+		return "falta el teléfono"
+	}
+	if len(telefono.Digitos(paciente.Telefono)) < minimoDigitosTelefono {
+		return "teléfono demasiado corto"
+	}
+	if paciente.Email != "" {
+		if _, err := mail.ParseAddress(paciente.Email); err != nil {
+			return "email inválido"
+		}
+	}
+	return ""
+}
+
+// esDuplicado considera el mismo paciente a quien tiene el mismo teléfono,
+// o el mismo nombre y email.
+func esDuplicado(a, b *models.Paciente) bool {
+	if telefono.Iguales(a.Telefono, b.Telefono) {
+		return true
+	}
+	return a.Email != "" && strings.EqualFold(a.Email, b.Email) &&
+		normalizarTexto(a.Nombre) == normalizarTexto(b.Nombre)
+}
+
+// posibleDuplicado marca a quien tiene un teléfono parecido: puede ser el
+// mismo paciente u otro con un número similar, así que sólo se avisa.
+func posibleDuplicado(a, b *models.Paciente) bool {
+	return telefono.Parecidos(a.Telefono, b.Telefono)
+}
+
+func buscarDuplicado(paciente models.Paciente, existentes []models.Paciente, coincide func(a, b *models.Paciente) bool) *models.Paciente {
+	for i := range existentes {
+		if coincide(&paciente, &existentes[i]) {
+			return &existentes[i]
+		}
+	}
+	return nil
+}
+
+func buscarDuplicadoEnLote(paciente models.Paciente, lote []*models.Paciente, coincide func(a, b *models.Paciente) bool) *models.Paciente {
+	for _, p := range lote {
+		if coincide(&paciente, p) {
+			return p
+		}
+	}
+	return nil
+}
+
+var sinAcentos = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"Á", "a", "É", "e", "Í", "i", "Ó", "o", "Ú", "u", "Ü", "u", "Ñ", "n",
+)
+
+// normalizarTexto pasa a minúsculas, quita acentos y colapsa espacios.
+func normalizarTexto(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(sinAcentos.Replace(s))), " ")
+}
@@ -0,0 +1,266 @@
+package importer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+func setupService(t *testing.T) (*Service, *db.PacienteRepo) {
+	t.Helper()
+
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	return NewService(database), db.NewPacienteRepo(database)
+}
+
+const csvPrueba = "\ufeffApellido y Nombre;Celular;Correo;Observaciones\n" +
+	"García, Ana;11 4433-2211;ana@email.com;\n" +
+	";11 5555-0000;;sin nombre\n" +
+	"\n" +
+	"Juan Pérez;+54 9 11 2345-6789;;ya cargado\n" +
+	"Lucía Díaz;011 3333-4444;lucia@;\n" +
+	"Ana García;(011) 4433.2211;;repetida\n" +
+	"Roberto  Sosa ;1144445555;ROBERTO@EMAIL.COM;\n"
+
+func TestLeerCSV(t *testing.T) {
+	hoja, err := LeerCSV(strings.NewReader(csvPrueba))
+	if err != nil {
+		t.Fatalf("LeerCSV() error = %v", err)
+	}
+
+	if len(hoja.Encabezados) != 4 || hoja.Encabezados[0] != "Apellido y Nombre" {
+		t.Errorf("Encabezados = %v", hoja.Encabezados)
+	}
+	if len(hoja.Filas) != 6 {
+		t.Fatalf("len(Filas) = %d, want 6", len(hoja.Filas))
+	}
+	// La fila vacía se descarta pero se conserva la numeración original
+	if hoja.Filas[2].Numero != 5 {
+		t.Errorf("Filas[2].Numero = %d, want 5", hoja.Filas[2].Numero)
+	}
+}
+
+func TestDetectarMapeo(t *testing.T) {
+	mapeo := DetectarMapeo([]string{"Apellido y Nombre", "Celular", "Correo", "Observaciones"})
+
+	want := models.MapeoImportacion{
+		Nombre:   "Apellido y Nombre",
+		Telefono: "Celular",
+		Email:    "Correo",
+		Notas:    "Observaciones",
+	}
+	if mapeo != want {
+		t.Errorf("DetectarMapeo() = %+v, want %+v", mapeo, want)
+	}
+}
+
+func TestImportar(t *testing.T) {
+	service, repo := setupService(t)
+
+	existente := &models.Paciente{Nombre: "Juan Pérez", Telefono: "11 2345 6789"}
+	if err := repo.Crear(existente); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	hoja, err := LeerCSV(strings.NewReader(csvPrueba))
+	if err != nil {
+		t.Fatalf("LeerCSV() error = %v", err)
+	}
+	mapeo := DetectarMapeo(hoja.Encabezados)
+
+	t.Run("Simulación", func(t *testing.T) {
+		reporte, err := service.Importar(hoja, mapeo, true)
+		if err != nil {
+			t.Fatalf("Importar() error = %v", err)
+		}
+
+		if reporte.Nuevos != 2 || reporte.Duplicados != 2 || reporte.Invalidos != 2 {
+			t.Errorf("Nuevos/Duplicados/Invalidos = %d/%d/%d, want 2/2/2",
+				reporte.Nuevos, reporte.Duplicados, reporte.Invalidos)
+		}
+		if reporte.Importados != 0 {
+			t.Errorf("Importados = %d, want 0 en simulación", reporte.Importados)
+		}
+
+		estados := []models.EstadoFilaImportacion{
+			models.FilaNueva, models.FilaInvalida, models.FilaDuplicada,
+			models.FilaInvalida, models.FilaDuplicada, models.FilaNueva,
+		}
+		for i, fila := range reporte.Filas {
+			if fila.Estado != estados[i] {
+				t.Errorf("Fila %d estado = %s, want %s (%s)", fila.Fila, fila.Estado, estados[i], fila.Mensaje)
+			}
+		}
+		if reporte.Filas[2].DuplicadoDeID != existente.ID {
+			t.Errorf("DuplicadoDeID = %d, want %d", reporte.Filas[2].DuplicadoDeID, existente.ID)
+		}
+
+		todos, _ := repo.ListarTodos()
+		if len(todos) != 1 {
+			t.Errorf("La simulación creó pacientes: %d en la base", len(todos))
+		}
+	})
+
+	t.Run("Importación", func(t *testing.T) {
+		reporte, err := service.Importar(hoja, mapeo, false)
+		if err != nil {
+			t.Fatalf("Importar() error = %v", err)
+		}
+		if reporte.Importados != 2 {
+			t.Errorf("Importados = %d, want 2", reporte.Importados)
+		}
+
+		roberto := reporte.Filas[5].Paciente
+		if roberto.ID == 0 {
+			t.Error("El reporte no incluye el ID asignado")
+		}
+		if roberto.Nombre != "Roberto Sosa" || roberto.Email != "roberto@email.com" || roberto.Telefono != "1144445555" {
+			t.Errorf("Paciente normalizado = %+v", roberto)
+		}
+
+		todos, _ := repo.ListarTodos()
+		if len(todos) != 3 {
+			t.Errorf("Pacientes en la base = %d, want 3", len(todos))
+		}
+
+		// Reimportar el mismo archivo no crea duplicados
+		reporte, err = service.Importar(hoja, mapeo, false)
+		if err != nil {
+			t.Fatalf("Importar() error = %v", err)
+		}
+		if reporte.Importados != 0 || reporte.Duplicados != 4 {
+			t.Errorf("Reimportación: Importados/Duplicados = %d/%d, want 0/4", reporte.Importados, reporte.Duplicados)
+		}
+	})
+
+	t.Run("Mapeo inválido", func(t *testing.T) {
+		_, err := service.Importar(hoja, models.MapeoImportacion{Nombre: "Nombre", Telefono: "Celular"}, true)
+		if err == nil {
+			t.Error("Importar() con columna inexistente should fail")
+		}
+	})
+}
+
+func TestImportar_TelefonoParecido(t *testing.T) {
+	service, repo := setupService(t)
+
+	existente := &models.Paciente{Nombre: "Juan Pérez", Telefono: "11 2345 6789"}
+	if err := repo.Crear(existente); err != nil {
+		t.Fatalf("Crear() failed: %v", err)
+	}
+
+	// Mismos 8 dígitos finales con otra característica: puede ser otra
+	// persona, así que se importa y se avisa.
+	hoja, err := LeerCSV(strings.NewReader("Nombre;Teléfono\n" +
+		"Juana Paz;351 2345-6789\n" +
+		"Pedro Paz;0341 2345-6789\n"))
+	if err != nil {
+		t.Fatalf("LeerCSV() error = %v", err)
+	}
+	reporte, err := service.Importar(hoja, DetectarMapeo(hoja.Encabezados), false)
+	if err != nil {
+		t.Fatalf("Importar() error = %v", err)
+	}
+
+	if reporte.Importados != 2 || reporte.Duplicados != 0 || reporte.PosiblesDuplicados != 2 {
+		t.Errorf("Importados/Duplicados/PosiblesDuplicados = %d/%d/%d, want 2/0/2",
+			reporte.Importados, reporte.Duplicados, reporte.PosiblesDuplicados)
+	}
+	for _, fila := range reporte.Filas {
+		if fila.Estado != models.FilaPosibleDuplicado || fila.Paciente.ID == 0 {
+			t.Errorf("Fila %d = %s, ID %d; want posible duplicado importado", fila.Fila, fila.Estado, fila.Paciente.ID)
+		}
+	}
+	if reporte.Filas[0].DuplicadoDeID != existente.ID {
+		t.Errorf("DuplicadoDeID = %d, want %d", reporte.Filas[0].DuplicadoDeID, existente.ID)
+	}
+}
+
+// escribirXLSX arma un libro con la hoja dada y devuelve su ruta.
+func escribirXLSX(t *testing.T, hoja string) string {
+	t.Helper()
+
+	ruta := filepath.Join(t.TempDir(), "pacientes.xlsx")
+	f, err := os.Create(ruta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(f)
+	archivos := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Pacientes" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="worksheet" Target="worksheets/hoja.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Nombre</t></si><si><t>Teléfono</t></si><si><r><t>María </t></r><r><t>González</t></r></si></sst>`,
+		"xl/worksheets/hoja.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			hoja + `</sheetData></worksheet>`,
+	}
+	for nombre, contenido := range archivos {
+		w, err := z.Create(nombre)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(contenido))
+	}
+	z.Close()
+	f.Close()
+	return ruta
+}
+
+func TestLeerXLSX(t *testing.T) {
+	ruta := escribirXLSX(t, `
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3"><v>1.1123456789E10</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>Juan</t></is></c><c r="C4"><v>7</v></c></row>
+`)
+
+	hoja, err := LeerArchivo(ruta)
+	if err != nil {
+		t.Fatalf("LeerArchivo() error = %v", err)
+	}
+
+	if len(hoja.Filas) != 2 {
+		t.Fatalf("len(Filas) = %d, want 2", len(hoja.Filas))
+	}
+	if hoja.Filas[0].Numero != 3 {
+		t.Errorf("Filas[0].Numero = %d, want 3", hoja.Filas[0].Numero)
+	}
+	if got := hoja.Filas[0].Valores; got[0] != "María González" || got[1] != "11123456789" {
+		t.Errorf("Filas[0] = %v", got)
+	}
+	if got := hoja.Filas[1].Valores; len(got) != 3 || got[0] != "Juan" || got[2] != "7" {
+		t.Errorf("Filas[1] = %v", got)
+	}
+}
+
+func TestLeerXLSX_ReferenciaInvalida(t *testing.T) {
+	for _, ref := range []string{"a1", "1A", "$A$1", "A", "ZZZZZZZ1", "XFE1"} {
+		t.Run(ref, func(t *testing.T) {
+			ruta := escribirXLSX(t, `<row r="1"><c r="`+ref+`"><v>1</v></c></row>`)
+			if _, err := LeerArchivo(ruta); err == nil {
+				t.Errorf("LeerArchivo() con la celda %q should fail", ref)
+			}
+		})
+	}
+
+	// La última columna de Excel sigue siendo válida.
+	ruta := escribirXLSX(t, `<row r="1"><c r="XFD1"><v>1</v></c></row>`)
+	hoja, err := LeerArchivo(ruta)
+	if err != nil {
+		t.Fatalf("LeerArchivo() error = %v", err)
+	}
+	if got := len(hoja.Encabezados); got != maxColumnasXLSX {
+		t.Errorf("len(Encabezados) = %d, want %d", got, maxColumnasXLSX)
+	}
+}
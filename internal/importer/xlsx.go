@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// LeerXLSX lee la primera hoja de un libro de Excel. Sólo interpreta los
+// valores de las celdas (texto, números y booleanos); fórmulas y formatos se
+// ignoran.
+func LeerXLSX(ruta string) (*Hoja, error) {
+	z, err := zip.OpenReader(ruta)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo XLSX: %w", err)
+	}
+	defer z.Close()
+
+	archivos := make(map[string]*zip.File, len(z.File))
+	for _, f := range z.File {
+		archivos[f.Name] = f
+	}
+
+	hojaPath, err := primeraHojaXLSX(archivos)
+	if err != nil {
+		return nil, err
+	}
+
+	var compartidos []string
+	if f, ok := archivos["xl/sharedStrings.xml"]; ok {
+		compartidos, err = leerStringsCompartidos(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := archivos[hojaPath]
+	if !ok {
+		return nil, fmt.Errorf("XLSX inválido: no se encontró %s", hojaPath)
+	}
+
+	filas, err := leerCeldas(f, compartidos)
+	if err != nil {
+		return nil, err
+	}
+
+	return nuevaHoja(filas)
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+func primeraHojaXLSX(archivos map[string]*zip.File) (string, error) {
+	const porDefecto = "xl/worksheets/sheet1.xml"
+
+	var libro xlsxWorkbook
+	if err := decodificarXML(archivos["xl/workbook.xml"], &libro); err != nil || len(libro.Sheets) == 0 {
+		return porDefecto, nil
+	}
+
+	var rels xlsxRelationships
+	if err := decodificarXML(archivos["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return porDefecto, nil
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != libro.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return porDefecto, nil
+}
+
+func decodificarXML(f *zip.File, v interface{}) error {
+	if f == nil {
+		return fmt.Errorf("archivo inexistente")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+type xlsxTexto struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxTexto) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+func leerStringsCompartidos(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []xlsxTexto `xml:"si"`
+	}
+	if err := decodificarXML(f, &sst); err != nil {
+		return nil, fmt.Errorf("error leyendo textos del XLSX: %w", err)
+	}
+
+	compartidos := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		compartidos[i] = item.String()
+	}
+	return compartidos, nil
+}
+
+type xlsxCelda struct {
+	Ref    string    `xml:"r,attr"`
+	Tipo   string    `xml:"t,attr"`
+	Valor  string    `xml:"v"`
+	Inline xlsxTexto `xml:"is"`
+}
+
+type xlsxFila struct {
+	Numero int         `xml:"r,attr"`
+	Celdas []xlsxCelda `xml:"c"`
+}
+
+func leerCeldas(f *zip.File, compartidos []string) ([]Fila, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo hoja del XLSX: %w", err)
+	}
+	defer rc.Close()
+
+	var filas []Fila
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo hoja del XLSX: %w", err)
+		}
+
+		inicio, ok := token.(xml.StartElement)
+		if !ok || inicio.Name.Local != "row" {
+			continue
+		}
+
+		var fila xlsxFila
+		if err := decoder.DecodeElement(&fila, &inicio); err != nil {
+			return nil, fmt.Errorf("error leyendo hoja del XLSX: %w", err)
+		}
+
+		numero := fila.Numero
+		if numero == 0 {
+			numero = len(filas) + 1
+		}
+
+		var valores []string
+		for i, celda := range fila.Celdas {
+			columna := i
+			if celda.Ref != "" {
+				columna, err = indiceColumna(celda.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for len(valores) <= columna {
+				valores = append(valores, "")
+			}
+			valores[columna] = valorCelda(celda, compartidos)
+		}
+		filas = append(filas, Fila{Numero: numero, Valores: valores})
+	}
+
+	return filas, nil
+}
+
+// maxColumnasXLSX es la cantidad de columnas de una hoja de Excel (A a XFD).
+const maxColumnasXLSX = 16384
+
+// indiceColumna convierte una referencia como "C7" en el índice de columna 2.
+// Las referencias de celda de una hoja son siempre letras mayúsculas seguidas
+// del número de fila; cualquier otra forma es un archivo inválido.
+func indiceColumna(ref string) (int, error) {
+	indice := 0
+	letras := 0
+	for letras < len(ref) && ref[letras] >= 'A' && ref[letras] <= 'Z' {
+		indice = indice*26 + int(ref[letras]-'A'+1)
+		if indice > maxColumnasXLSX {
+			return 0, fmt.Errorf("XLSX inválido: la celda %q está fuera de las columnas de Excel", ref)
+		}
+		letras++
+	}
+	fila := ref[letras:]
+	if letras == 0 || fila == "" || strings.Trim(fila, "0123456789") != "" {
+		return 0, fmt.Errorf("XLSX inválido: referencia de celda %q", ref)
+	}
+	return indice - 1, nil
+}
+
+func valorCelda(celda xlsxCelda, compartidos []string) string {
+	switch celda.Tipo {
+	case "s":
+		i, err := strconv.Atoi(celda.Valor)
+		if err != nil || i < 0 || i >= len(compartidos) {
+			return ""
+		}
+		return compartidos[i]
+	case "inlineStr":
+		return celda.Inline.String()
+	case "b":
+		if celda.Valor == "1" {
+			return "VERDADERO"
+		}
+		return "FALSO"
+	case "", "n":
+		// Los teléfonos cargados como número pueden venir en notación
+		// científica (1.1234E+9); se devuelven como entero.
+		if n, err := strconv.ParseFloat(celda.Valor, 64); err == nil && n == float64(int64(n)) {
+			return strconv.FormatInt(int64(n), 10)
+		}
+		return celda.Valor
+	default:
+		return celda.Valor
+	}
+}
@@ -0,0 +1,88 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// La activación liga una licencia a una máquina sin conexión: la aplicación
+// genera un código de solicitud con la huella de la máquina, el emisor lo
+// convierte en un código de activación firmado y la aplicación verifica que
+// la firma sea válida y que la huella sea la suya.
+const (
+	PrefijoSolicitud  = "YOYS1"
+	PrefijoActivacion = "YOYA1"
+)
+
+// SolicitudActivacion es lo que la aplicación le envía al emisor. No va
+// firmada: el emisor sólo confía en ella para saber qué licencia activar.
+type SolicitudActivacion struct {
+	LicenciaID string `json:"lic"`
+	ClienteID  string `json:"cli"`
+	Huella     string `json:"huella"`
+}
+
+// Activacion es el contenido firmado de un código de activación.
+type Activacion struct {
+	LicenciaID string
+	Huella     string
+	Fecha      time.Time
+}
+
+type cargaActivacion struct {
+	LicenciaID string `json:"lic"`
+	Huella     string `json:"huella"`
+	Fecha      string `json:"fecha"`
+}
+
+// Codificar devuelve el código "YOYS1.<datos>" que el usuario le envía al
+// emisor.
+func (s SolicitudActivacion) Codificar() string {
+	datos, _ := json.Marshal(s)
+	return PrefijoSolicitud + LicenseSeparator + codificacion.EncodeToString(datos)
+}
+
+// LeerSolicitud decodifica un código generado por Codificar.
+func LeerSolicitud(codigo string) (*SolicitudActivacion, error) {
+	partes := strings.Split(strings.Join(strings.Fields(codigo), ""), LicenseSeparator)
+	if len(partes) != 2 || partes[0] != PrefijoSolicitud {
+		return nil, fmt.Errorf("código de solicitud inválido")
+	}
+	datos, err := codificacion.DecodeString(partes[1])
+	if err != nil {
+		return nil, fmt.Errorf("código de solicitud inválido")
+	}
+	var s SolicitudActivacion
+	if err := json.Unmarshal(datos, &s); err != nil || s.LicenciaID == "" || s.Huella == "" {
+		return nil, fmt.Errorf("código de solicitud inválido")
+	}
+	return &s, nil
+}
+
+// GenerarActivacion firma el código de activación de a.
+func (g *Generator) GenerarActivacion(a Activacion) (string, error) {
+	if a.LicenciaID == "" || a.Huella == "" {
+		return "", fmt.Errorf("la activación necesita licencia y huella")
+	}
+	return g.firmar(PrefijoActivacion, cargaActivacion{
+		LicenciaID: a.LicenciaID,
+		Huella:     a.Huella,
+		Fecha:      a.Fecha.Format(formatoFecha),
+	})
+}
+
+// ValidarActivacion verifica la firma de codigo y devuelve su contenido.
+func ValidarActivacion(publicKey ed25519.PublicKey, codigo string) (*Activacion, error) {
+	var c cargaActivacion
+	if err := verificarFirma(publicKey, PrefijoActivacion, codigo, &c); err != nil {
+		return nil, fmt.Errorf("código de activación inválido")
+	}
+	fecha, err := time.Parse(formatoFecha, c.Fecha)
+	if err != nil || c.LicenciaID == "" || c.Huella == "" {
+		return nil, fmt.Errorf("código de activación inválido")
+	}
+	return &Activacion{LicenciaID: c.LicenciaID, Huella: c.Huella, Fecha: fecha}, nil
+}
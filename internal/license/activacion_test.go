@@ -0,0 +1,68 @@
+package license
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSolicitudActivacion(t *testing.T) {
+	solicitud := SolicitudActivacion{LicenciaID: "lic-0001", ClienteID: "CLI-0001", Huella: huellaPrueba}
+
+	codigo := solicitud.Codificar()
+	leida, err := LeerSolicitud(" " + codigo[:10] + "\n" + codigo[10:])
+	if err != nil {
+		t.Fatalf("LeerSolicitud() failed: %v", err)
+	}
+	if *leida != solicitud {
+		t.Errorf("LeerSolicitud() = %+v, want %+v", *leida, solicitud)
+	}
+
+	for _, codigo := range []string{"", "YOYS1", "YOYA1.e30", "YOYS1.!!!", "YOYS1.e30"} {
+		if _, err := LeerSolicitud(codigo); err == nil {
+			t.Errorf("LeerSolicitud(%q) expected error but got none", codigo)
+		}
+	}
+}
+
+func TestValidarActivacion(t *testing.T) {
+	generator, publicKey := clavesPrueba(t)
+
+	codigo, err := generator.GenerarActivacion(Activacion{LicenciaID: "lic-0001", Huella: huellaPrueba, Fecha: time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("GenerarActivacion() failed: %v", err)
+	}
+	a, err := ValidarActivacion(publicKey, codigo)
+	if err != nil {
+		t.Fatalf("ValidarActivacion() failed: %v", err)
+	}
+	if a.LicenciaID != "lic-0001" || a.Huella != huellaPrueba || !a.Fecha.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ValidarActivacion() = %+v", a)
+	}
+
+	// Una licencia no sirve como activación aunque esté bien firmada.
+	key := licenciaPrueba(t, generator, claimsPrueba(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)))
+	if _, err := ValidarActivacion(publicKey, key); err == nil {
+		t.Error("ValidarActivacion() should reject a license key")
+	}
+	if _, err := generator.GenerarActivacion(Activacion{LicenciaID: "lic-0001"}); err == nil {
+		t.Error("GenerarActivacion() without huella should fail")
+	}
+}
+
+func TestHuella(t *testing.T) {
+	formato := regexp.MustCompile(`^[A-Z2-7]{4}(-[A-Z2-7]{4}){3}$`)
+
+	huella := derivarHuella("4c4c4544-0042-3510-8052-b7c04f4b4e31")
+	if !formato.MatchString(huella) {
+		t.Errorf("derivarHuella() = %q, want XXXX-XXXX-XXXX-XXXX", huella)
+	}
+	if huella != derivarHuella("4c4c4544-0042-3510-8052-b7c04f4b4e31") || huella == derivarHuella("otra-maquina") {
+		t.Error("derivarHuella() should be stable per machine and differ between machines")
+	}
+
+	// En algunos entornos (contenedores) no hay identificador de máquina.
+	if huella, err := HuellaMaquina(); err == nil && !formato.MatchString(huella) {
+		t.Errorf("HuellaMaquina() = %q, want XXXX-XXXX-XXXX-XXXX", huella)
+	}
+}
@@ -0,0 +1,34 @@
+package license
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// FechaCompilacion es la fecha de publicación de este binario (AAAA-MM-DD),
+// con la que se decide si la versión está cubierta por el período de
+// actualizaciones del cliente. Se fija al compilar con
+// -ldflags "-X yoyaku/internal/license.FechaCompilacion=2025-06-01".
+var FechaCompilacion = ""
+
+// fechaCompilacion devuelve FechaCompilacion o, si no se fijó, la fecha del
+// commit que registra go build. En un binario sin ninguna de las dos
+// devuelve la fecha cero.
+func fechaCompilacion() time.Time {
+	if fecha, err := time.Parse(formatoFecha, FechaCompilacion); err == nil {
+		return fecha
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return time.Time{}
+	}
+	for _, s := range info.Settings {
+		if s.Key != "vcs.time" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, s.Value); err == nil {
+			return t.UTC().Truncate(24 * time.Hour)
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,51 @@
+package license
+
+import (
+	"sort"
+
+	"yoyaku/internal/models"
+)
+
+// FeaturesEdicion son las funciones que incluye cada edición. La básica
+// cubre un consultorio de un solo profesional; la pro agrega varios
+// profesionales, mensajes a pacientes y reportes.
+var FeaturesEdicion = map[models.Edicion][]models.Feature{
+	models.EdicionBasica: {},
+	models.EdicionPro: {
+		models.FeatureMultiProfesional,
+		models.FeatureMensajeria,
+		models.FeatureReportes,
+	},
+}
+
+// featureConocida indica si f es una de las funciones de la edición pro, que
+// las incluye todas.
+func featureConocida(f models.Feature) bool {
+	for _, conocida := range FeaturesEdicion[models.EdicionPro] {
+		if f == conocida {
+			return true
+		}
+	}
+	return false
+}
+
+// Features devuelve las funciones que habilita la licencia: las de su edición
+// más las adicionales, sin repetir y en orden.
+func (c Claims) Features() []models.Feature {
+	return unirFeatures(FeaturesEdicion[c.Edicion], c.Adicionales)
+}
+
+func unirFeatures(listas ...[]models.Feature) []models.Feature {
+	vistas := map[models.Feature]bool{}
+	features := []models.Feature{}
+	for _, lista := range listas {
+		for _, f := range lista {
+			if !vistas[f] {
+				vistas[f] = true
+				features = append(features, f)
+			}
+		}
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i] < features[j] })
+	return features
+}
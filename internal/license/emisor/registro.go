@@ -0,0 +1,340 @@
+// Package emisor lleva el registro de licencias emitidas. Lo usa sólo la
+// herramienta de licencias: la aplicación nunca lo importa.
+package emisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"yoyaku/internal/license"
+	"yoyaku/internal/models"
+)
+
+// Cliente es quien compró una o más licencias.
+type Cliente struct {
+	ID        string    `json:"id"`
+	Nombre    string    `json:"nombre"`
+	Contacto  string    `json:"contacto,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Licencia es una licencia emitida, con la clave firmada que se entregó.
+type Licencia struct {
+	ID                   string           `json:"id"`
+	ClienteID            string           `json:"clienteId"`
+	Maquinas             int              `json:"maquinas"`
+	Edicion              models.Edicion   `json:"edicion"`
+	Adicionales          []models.Feature `json:"adicionales,omitempty"`
+	Meses                int              `json:"meses"`
+	Emision              time.Time        `json:"emision"`
+	ActualizacionesHasta time.Time        `json:"actualizacionesHasta"`
+	Clave                string           `json:"clave"`
+	Renueva              string           `json:"renueva,omitempty"`
+	Activaciones         []Activacion     `json:"activaciones,omitempty"`
+	RevocadaAt           *time.Time       `json:"revocadaAt,omitempty"`
+	MotivoRevocacion     string           `json:"motivoRevocacion,omitempty"`
+}
+
+// Activacion es una máquina en la que se activó una licencia.
+type Activacion struct {
+	Huella string    `json:"huella"`
+	Fecha  time.Time `json:"fecha"`
+	Codigo string    `json:"codigo"`
+}
+
+func (l *Licencia) Revocada() bool {
+	return l.RevocadaAt != nil
+}
+
+// Solicitud describe una licencia nueva. Si ClienteID está vacío se da de
+// alta un cliente nuevo con Nombre y Contacto.
+type Solicitud struct {
+	ClienteID string
+	Nombre    string
+	Contacto  string
+	Maquinas  int
+	Edicion   models.Edicion
+	// Adicionales son funciones que se habilitan además de las de la
+	// edición.
+	Adicionales []models.Feature
+	Meses       int
+}
+
+type contenido struct {
+	Clientes  []Cliente  `json:"clientes"`
+	Licencias []Licencia `json:"licencias"`
+	// UltimaRevocacion es el número de la última lista de revocación
+	// firmada.
+	UltimaRevocacion int `json:"ultimaRevocacion,omitempty"`
+}
+
+// Registro es el archivo JSON con los clientes y las licencias emitidas.
+// Cada operación que lo modifica lo guarda completo.
+type Registro struct {
+	ruta  string
+	datos contenido
+}
+
+// AbrirRegistro lee el registro de ruta; si el archivo no existe empieza
+// uno vacío.
+func AbrirRegistro(ruta string) (*Registro, error) {
+	r := &Registro{ruta: ruta}
+	datos, err := os.ReadFile(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo registro: %w", err)
+	}
+	if err := json.Unmarshal(datos, &r.datos); err != nil {
+		return nil, fmt.Errorf("registro inválido en %s: %w", ruta, err)
+	}
+	return r, nil
+}
+
+// guardar escribe a un archivo temporal y lo renombra, para no dejar el
+// registro a medio escribir.
+func (r *Registro) guardar() error {
+	datos, err := json.MarshalIndent(r.datos, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.ruta), ".registro-*")
+	if err != nil {
+		return fmt.Errorf("error guardando registro: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(datos, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error guardando registro: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error guardando registro: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.ruta); err != nil {
+		return fmt.Errorf("error guardando registro: %w", err)
+	}
+	return nil
+}
+
+// Emitir firma una licencia nueva según s y la agrega al registro.
+func (r *Registro) Emitir(g *license.Generator, s Solicitud, ahora time.Time) (*Licencia, error) {
+	if s.Meses < 1 {
+		return nil, fmt.Errorf("la licencia debe incluir al menos un mes de actualizaciones")
+	}
+	if s.Maquinas < 1 {
+		return nil, fmt.Errorf("la licencia debe cubrir al menos una máquina")
+	}
+
+	var cliente *Cliente
+	if s.ClienteID != "" {
+		cliente = r.Cliente(s.ClienteID)
+		if cliente == nil {
+			return nil, fmt.Errorf("cliente no encontrado: %s", s.ClienteID)
+		}
+	} else {
+		nombre := strings.TrimSpace(s.Nombre)
+		if nombre == "" {
+			return nil, fmt.Errorf("el nombre del cliente es obligatorio")
+		}
+		r.datos.Clientes = append(r.datos.Clientes, Cliente{
+			ID:        fmt.Sprintf("CLI-%04d", len(r.datos.Clientes)+1),
+			Nombre:    nombre,
+			Contacto:  strings.TrimSpace(s.Contacto),
+			CreatedAt: ahora,
+		})
+		cliente = &r.datos.Clientes[len(r.datos.Clientes)-1]
+	}
+
+	hoy := dia(ahora)
+	licencia, err := r.firmar(g, Licencia{
+		ClienteID:            cliente.ID,
+		Maquinas:             s.Maquinas,
+		Edicion:              s.Edicion,
+		Adicionales:          s.Adicionales,
+		Meses:                s.Meses,
+		Emision:              hoy,
+		ActualizacionesHasta: hoy.AddDate(0, s.Meses, 0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return licencia, r.guardar()
+}
+
+// Renovar emite una licencia nueva para el cliente de la licencia id,
+// extendiendo las actualizaciones meses a partir de su vencimiento o de hoy,
+// lo que sea posterior.
+func (r *Registro) Renovar(g *license.Generator, id string, meses int, ahora time.Time) (*Licencia, error) {
+	if meses < 1 {
+		return nil, fmt.Errorf("la renovación debe incluir al menos un mes de actualizaciones")
+	}
+	anterior := r.Licencia(id)
+	if anterior == nil {
+		return nil, fmt.Errorf("licencia no encontrada: %s", id)
+	}
+	if anterior.Revocada() {
+		return nil, fmt.Errorf("la licencia %s está revocada", id)
+	}
+
+	hoy := dia(ahora)
+	desde := anterior.ActualizacionesHasta
+	if desde.Before(hoy) {
+		desde = hoy
+	}
+	licencia, err := r.firmar(g, Licencia{
+		ClienteID:            anterior.ClienteID,
+		Maquinas:             anterior.Maquinas,
+		Edicion:              anterior.Edicion,
+		Adicionales:          anterior.Adicionales,
+		Meses:                meses,
+		Emision:              hoy,
+		ActualizacionesHasta: desde.AddDate(0, meses, 0),
+		Renueva:              id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return licencia, r.guardar()
+}
+
+// Revocar marca la licencia id como revocada.
+func (r *Registro) Revocar(id, motivo string, ahora time.Time) (*Licencia, error) {
+	licencia := r.Licencia(id)
+	if licencia == nil {
+		return nil, fmt.Errorf("licencia no encontrada: %s", id)
+	}
+	if licencia.Revocada() {
+		return nil, fmt.Errorf("la licencia %s ya está revocada", id)
+	}
+	licencia.RevocadaAt = &ahora
+	licencia.MotivoRevocacion = strings.TrimSpace(motivo)
+	return licencia, r.guardar()
+}
+
+// ListaRevocacion firma una lista nueva con todas las licencias revocadas
+// del registro. Cada lista lleva un número mayor que la anterior, para que la
+// aplicación no la reemplace por una vieja.
+func (r *Registro) ListaRevocacion(g *license.Generator, ahora time.Time) (*models.ListaRevocacion, string, error) {
+	lista := &models.ListaRevocacion{
+		Numero:    r.datos.UltimaRevocacion + 1,
+		Fecha:     dia(ahora),
+		Licencias: []string{},
+	}
+	for _, l := range r.datos.Licencias {
+		if l.Revocada() {
+			lista.Licencias = append(lista.Licencias, l.ID)
+		}
+	}
+	texto, err := g.GenerarListaRevocacion(*lista)
+	if err != nil {
+		return nil, "", err
+	}
+	r.datos.UltimaRevocacion = lista.Numero
+	return lista, texto, r.guardar()
+}
+
+// Activar responde el código de solicitud generado por la aplicación con el
+// código de activación para esa máquina. Volver a activar una máquina ya
+// registrada devuelve un código nuevo sin ocupar otro lugar; pasar de la
+// cantidad de máquinas de la licencia requiere forzar.
+func (r *Registro) Activar(g *license.Generator, codigo string, forzar bool, ahora time.Time) (*Licencia, string, error) {
+	solicitud, err := license.LeerSolicitud(codigo)
+	if err != nil {
+		return nil, "", err
+	}
+	licencia := r.Licencia(solicitud.LicenciaID)
+	if licencia == nil {
+		return nil, "", fmt.Errorf("licencia no encontrada: %s", solicitud.LicenciaID)
+	}
+	if licencia.Revocada() {
+		return nil, "", fmt.Errorf("la licencia %s está revocada", licencia.ID)
+	}
+
+	activacion, err := g.GenerarActivacion(license.Activacion{LicenciaID: licencia.ID, Huella: solicitud.Huella, Fecha: ahora})
+	if err != nil {
+		return nil, "", err
+	}
+
+	registrada := false
+	for i := range licencia.Activaciones {
+		if licencia.Activaciones[i].Huella == solicitud.Huella {
+			licencia.Activaciones[i].Fecha = ahora
+			licencia.Activaciones[i].Codigo = activacion
+			registrada = true
+		}
+	}
+	if !registrada {
+		if len(licencia.Activaciones) >= licencia.Maquinas && !forzar {
+			return nil, "", fmt.Errorf("la licencia %s ya está activada en %d de %d máquinas", licencia.ID, len(licencia.Activaciones), licencia.Maquinas)
+		}
+		licencia.Activaciones = append(licencia.Activaciones, Activacion{Huella: solicitud.Huella, Fecha: ahora, Codigo: activacion})
+	}
+	return licencia, activacion, r.guardar()
+}
+
+// firmar completa l con un identificador nuevo y su clave firmada, y la
+// agrega al registro.
+func (r *Registro) firmar(g *license.Generator, l Licencia) (*Licencia, error) {
+	id, err := license.NuevoID()
+	if err != nil {
+		return nil, err
+	}
+	clave, err := g.GenerateLicenseKey(license.Claims{
+		ID:                   id,
+		ClienteID:            l.ClienteID,
+		Emision:              l.Emision,
+		ActualizacionesHasta: l.ActualizacionesHasta,
+		Edicion:              l.Edicion,
+		Meses:                l.Meses,
+		Adicionales:          l.Adicionales,
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.ID, l.Clave = id, clave
+	r.datos.Licencias = append(r.datos.Licencias, l)
+	return &r.datos.Licencias[len(r.datos.Licencias)-1], nil
+}
+
+// Cliente devuelve el cliente id, o nil si no existe.
+func (r *Registro) Cliente(id string) *Cliente {
+	for i := range r.datos.Clientes {
+		if strings.EqualFold(r.datos.Clientes[i].ID, id) {
+			return &r.datos.Clientes[i]
+		}
+	}
+	return nil
+}
+
+// Licencia devuelve la licencia id, o nil si no existe.
+func (r *Registro) Licencia(id string) *Licencia {
+	for i := range r.datos.Licencias {
+		if r.datos.Licencias[i].ID == id {
+			return &r.datos.Licencias[i]
+		}
+	}
+	return nil
+}
+
+// Licencias devuelve las licencias emitidas, de la última a la primera; si
+// clienteID no está vacío, sólo las de ese cliente.
+func (r *Registro) Licencias(clienteID string) []Licencia {
+	var licencias []Licencia
+	for i := len(r.datos.Licencias) - 1; i >= 0; i-- {
+		if l := r.datos.Licencias[i]; clienteID == "" || strings.EqualFold(l.ClienteID, clienteID) {
+			licencias = append(licencias, l)
+		}
+	}
+	return licencias
+}
+
+func dia(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
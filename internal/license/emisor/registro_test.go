@@ -0,0 +1,187 @@
+package emisor
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yoyaku/internal/license"
+	"yoyaku/internal/models"
+)
+
+var ahoraPrueba = time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+
+func setupRegistro(t *testing.T) (*Registro, *license.Generator, ed25519.PublicKey, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed: %v", err)
+	}
+	ruta := filepath.Join(t.TempDir(), "licencias.json")
+	r, err := AbrirRegistro(ruta)
+	if err != nil {
+		t.Fatalf("AbrirRegistro() failed: %v", err)
+	}
+	return r, license.NewGenerator(priv), pub, ruta
+}
+
+func TestRegistro_Emitir(t *testing.T) {
+	r, g, pub, ruta := setupRegistro(t)
+
+	licencia, err := r.Emitir(g, Solicitud{Nombre: "Consultorio Pérez", Contacto: "perez@example.com", Maquinas: 2, Edicion: models.EdicionPro, Meses: 12}, ahoraPrueba)
+	if err != nil {
+		t.Fatalf("Emitir() failed: %v", err)
+	}
+	if licencia.ClienteID != "CLI-0001" || licencia.Maquinas != 2 {
+		t.Errorf("licencia = %+v, want cliente CLI-0001 con 2 máquinas", licencia)
+	}
+	if want := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC); !licencia.ActualizacionesHasta.Equal(want) {
+		t.Errorf("ActualizacionesHasta = %v, want %v", licencia.ActualizacionesHasta, want)
+	}
+
+	claims, err := license.ValidateLicenseKey(pub, licencia.Clave)
+	if err != nil {
+		t.Fatalf("la clave emitida no verifica: %v", err)
+	}
+	if claims.ID != licencia.ID || claims.ClienteID != "CLI-0001" || claims.Edicion != models.EdicionPro {
+		t.Errorf("claims = %+v, want los datos del registro", claims)
+	}
+
+	// Una segunda licencia del mismo cliente no lo duplica.
+	segunda, err := r.Emitir(g, Solicitud{ClienteID: "cli-0001", Maquinas: 1, Edicion: models.EdicionBasica, Adicionales: []models.Feature{models.FeatureReportes}, Meses: 12}, ahoraPrueba)
+	if err != nil {
+		t.Fatalf("Emitir() para cliente existente failed: %v", err)
+	}
+	if claims, err := license.ValidateLicenseKey(pub, segunda.Clave); err != nil || len(claims.Features()) != 1 || claims.Features()[0] != models.FeatureReportes {
+		t.Errorf("claims adicionales = %+v, %v; want reportes", claims, err)
+	}
+	errores := []Solicitud{
+		{ClienteID: "CLI-9999", Maquinas: 1, Edicion: models.EdicionBasica, Meses: 12},
+		{Nombre: " ", Maquinas: 1, Edicion: models.EdicionBasica, Meses: 12},
+		{Nombre: "Sin meses", Maquinas: 1, Edicion: models.EdicionBasica},
+		{Nombre: "Sin máquinas", Edicion: models.EdicionBasica, Meses: 12},
+		{Nombre: "Función desconocida", Maquinas: 1, Edicion: models.EdicionBasica, Adicionales: []models.Feature{"recetas"}, Meses: 12},
+	}
+	for _, s := range errores {
+		if _, err := r.Emitir(g, s, ahoraPrueba); err == nil {
+			t.Errorf("Emitir(%+v) expected error but got none", s)
+		}
+	}
+
+	// El registro se lee de vuelta desde el archivo.
+	leido, err := AbrirRegistro(ruta)
+	if err != nil {
+		t.Fatalf("AbrirRegistro() failed: %v", err)
+	}
+	if len(leido.Licencias("")) != 2 || len(leido.Licencias("CLI-0001")) != 2 {
+		t.Errorf("registro leído = %d licencias, want 2", len(leido.Licencias("")))
+	}
+	if c := leido.Cliente("CLI-0001"); c == nil || c.Contacto != "perez@example.com" {
+		t.Errorf("Cliente() = %+v, want contacto guardado", c)
+	}
+}
+
+func TestRegistro_RenovarYRevocar(t *testing.T) {
+	r, g, pub, _ := setupRegistro(t)
+
+	vigente, err := r.Emitir(g, Solicitud{Nombre: "Vigente", Maquinas: 1, Edicion: models.EdicionBasica, Meses: 12}, ahoraPrueba)
+	if err != nil {
+		t.Fatalf("Emitir() failed: %v", err)
+	}
+	vencida, err := r.Emitir(g, Solicitud{Nombre: "Vencida", Maquinas: 1, Edicion: models.EdicionBasica, Meses: 1}, ahoraPrueba.AddDate(-1, 0, 0))
+	if err != nil {
+		t.Fatalf("Emitir() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		want time.Time
+	}{
+		// Vigente hasta 10/03/2026: se extiende desde el vencimiento.
+		{"vigente", vigente.ID, time.Date(2026, 9, 10, 0, 0, 0, 0, time.UTC)},
+		// Vencida el 10/04/2024: se extiende desde hoy.
+		{"vencida", vencida.ID, time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		renovada, err := r.Renovar(g, tt.id, 6, ahoraPrueba)
+		if err != nil {
+			t.Fatalf("Renovar(%s) failed: %v", tt.name, err)
+		}
+		if !renovada.ActualizacionesHasta.Equal(tt.want) || renovada.Renueva != tt.id || renovada.ID == tt.id {
+			t.Errorf("Renovar(%s) = %+v, want nueva licencia hasta %v", tt.name, renovada, tt.want)
+		}
+	}
+
+	revocada, err := r.Revocar(vigente.ID, "reembolso", ahoraPrueba)
+	if err != nil {
+		t.Fatalf("Revocar() failed: %v", err)
+	}
+	if !revocada.Revocada() || revocada.MotivoRevocacion != "reembolso" {
+		t.Errorf("Revocar() = %+v, want revocada por reembolso", revocada)
+	}
+	if _, err := r.Revocar(vigente.ID, "", ahoraPrueba); err == nil {
+		t.Error("Revocar() twice should fail")
+	}
+	for numero := 1; numero <= 2; numero++ {
+		lista, texto, err := r.ListaRevocacion(g, ahoraPrueba)
+		if err != nil {
+			t.Fatalf("ListaRevocacion() failed: %v", err)
+		}
+		verificada, err := license.ValidarListaRevocacion(pub, texto)
+		if err != nil || verificada.Numero != numero || lista.Numero != numero || !verificada.Incluye(vigente.ID) || verificada.Incluye(vencida.ID) {
+			t.Errorf("ListaRevocacion() = %+v, %v; want número %d con %s", verificada, err, numero, vigente.ID)
+		}
+	}
+	if _, err := r.Renovar(g, vigente.ID, 6, ahoraPrueba); err == nil {
+		t.Error("Renovar() of a revoked license should fail")
+	}
+	if _, err := r.Renovar(g, "inexistente", 6, ahoraPrueba); err == nil {
+		t.Error("Renovar() of a missing license should fail")
+	}
+}
+
+func TestRegistro_Activar(t *testing.T) {
+	r, g, pub, _ := setupRegistro(t)
+
+	licencia, err := r.Emitir(g, Solicitud{Nombre: "Consultorio", Maquinas: 1, Edicion: models.EdicionBasica, Meses: 12}, ahoraPrueba)
+	if err != nil {
+		t.Fatalf("Emitir() failed: %v", err)
+	}
+	solicitud := func(huella string) string {
+		return license.SolicitudActivacion{LicenciaID: licencia.ID, ClienteID: licencia.ClienteID, Huella: huella}.Codificar()
+	}
+
+	_, codigo, err := r.Activar(g, solicitud("AAAA-AAAA-AAAA-AAAA"), false, ahoraPrueba)
+	if err != nil {
+		t.Fatalf("Activar() failed: %v", err)
+	}
+	a, err := license.ValidarActivacion(pub, codigo)
+	if err != nil || a.LicenciaID != licencia.ID || a.Huella != "AAAA-AAAA-AAAA-AAAA" {
+		t.Errorf("ValidarActivacion() = %+v, %v; want licencia y huella de la solicitud", a, err)
+	}
+
+	// Reactivar la misma máquina no ocupa otro lugar.
+	if _, _, err := r.Activar(g, solicitud("AAAA-AAAA-AAAA-AAAA"), false, ahoraPrueba); err != nil {
+		t.Errorf("Activar() same machine failed: %v", err)
+	}
+	if _, _, err := r.Activar(g, solicitud("BBBB-BBBB-BBBB-BBBB"), false, ahoraPrueba); err == nil {
+		t.Error("Activar() beyond the machine count should fail")
+	}
+	activada, _, err := r.Activar(g, solicitud("BBBB-BBBB-BBBB-BBBB"), true, ahoraPrueba)
+	if err != nil {
+		t.Fatalf("Activar() forced failed: %v", err)
+	}
+	if len(activada.Activaciones) != 2 {
+		t.Errorf("Activaciones = %d, want 2", len(activada.Activaciones))
+	}
+
+	if _, err := r.Revocar(licencia.ID, "", ahoraPrueba); err != nil {
+		t.Fatalf("Revocar() failed: %v", err)
+	}
+	if _, _, err := r.Activar(g, solicitud("AAAA-AAAA-AAAA-AAAA"), false, ahoraPrueba); err == nil {
+		t.Error("Activar() of a revoked license should fail")
+	}
+}
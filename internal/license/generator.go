@@ -1,75 +1,197 @@
 package license
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
+
+	"yoyaku/internal/models"
 )
 
 const (
-	LicensePrefix    = "YOY"
-	LicenseSeparator = "-"
-	LicenseLength    = 16
+	LicensePrefix    = "YOY1"
+	LicenseSeparator = "."
 	Version          = "1.0.0"
+
+	formatoFecha = "2006-01-02"
+	tipoPEM      = "PRIVATE KEY"
 )
 
-type Generator struct {
-	secretKey string
+// Claims es el contenido firmado de una licencia. La firma cubre todos los
+// campos, así que ninguno se puede modificar sin invalidar la clave.
+type Claims struct {
+	ID                   string
+	ClienteID            string
+	Emision              time.Time
+	ActualizacionesHasta time.Time
+	Edicion              models.Edicion
+	// Meses es el período de actualizaciones que suma la licencia al
+	// aplicarse. Si es cero se toma de Emision a ActualizacionesHasta.
+	Meses int
+	// Adicionales son funciones habilitadas además de las de la edición.
+	Adicionales []models.Feature
 }
 
-func NewGenerator(secretKey string) *Generator {
-	return &Generator{secretKey: secretKey}
+// carga es la forma serializada de Claims: claves cortas y fechas sin hora
+// para que la licencia se pueda copiar y pegar sin problemas.
+type carga struct {
+	ID                   string           `json:"id"`
+	ClienteID            string           `json:"cli"`
+	Emision              string           `json:"emi"`
+	ActualizacionesHasta string           `json:"act"`
+	Edicion              models.Edicion   `json:"ed"`
+	Meses                int              `json:"mes,omitempty"`
+	Adicionales          []models.Feature `json:"ft,omitempty"`
 }
 
-func (g *Generator) GenerateLicenseKey(year int) string {
-	raw := fmt.Sprintf("%s%d%s", g.secretKey, year, Version)
-	hash := sha256.Sum256([]byte(raw))
-	hexStr := hex.EncodeToString(hash[:])
-
-	key := fmt.Sprintf("%s%d%s%s%s%s",
-		LicensePrefix,
-		year,
-		LicenseSeparator,
-		hexStr[0:4],
-		LicenseSeparator,
-		hexStr[4:8],
-	)
-
-	return strings.ToUpper(key)
+var codificacion = base64.RawURLEncoding
+
+// Generator firma licencias. Sólo existe del lado del emisor: la aplicación
+// únicamente conoce la clave pública.
+type Generator struct {
+	privateKey ed25519.PrivateKey
 }
 
-func (g *Generator) ValidateLicenseKey(key string) (bool, int, error) {
-	key = strings.ToUpper(strings.TrimSpace(key))
-	parts := strings.Split(key, LicenseSeparator)
+func NewGenerator(privateKey ed25519.PrivateKey) *Generator {
+	return &Generator{privateKey: privateKey}
+}
 
-	if len(parts) != 3 {
-		return false, 0, fmt.Errorf("formato de licencia inválido")
+// GenerateLicenseKey devuelve la licencia "YOY1.<carga>.<firma>" para claims.
+func (g *Generator) GenerateLicenseKey(claims Claims) (string, error) {
+	if err := claims.validar(); err != nil {
+		return "", err
+	}
+	for _, f := range claims.Adicionales {
+		if !featureConocida(f) {
+			return "", fmt.Errorf("función desconocida: %q", f)
+		}
 	}
+	if claims.Meses == 0 {
+		claims.Meses = mesesEntre(claims.Emision, claims.ActualizacionesHasta)
+	}
+	return g.firmar(LicensePrefix, carga{
+		ID:                   claims.ID,
+		ClienteID:            claims.ClienteID,
+		Emision:              claims.Emision.Format(formatoFecha),
+		ActualizacionesHasta: claims.ActualizacionesHasta.Format(formatoFecha),
+		Edicion:              claims.Edicion,
+		Meses:                claims.Meses,
+		Adicionales:          claims.Adicionales,
+	})
+}
 
-	if !strings.HasPrefix(parts[0], LicensePrefix) {
-		return false, 0, fmt.Errorf("prefijo de licencia inválido")
+// firmar serializa v y devuelve "<prefijo>.<v>.<firma>", con la firma
+// calculada sobre "<prefijo>.<v>".
+func (g *Generator) firmar(prefijo string, v interface{}) (string, error) {
+	datos, err := json.Marshal(v)
+	if err != nil {
+		return "", err
 	}
+	cuerpo := prefijo + LicenseSeparator + codificacion.EncodeToString(datos)
+	firma := ed25519.Sign(g.privateKey, []byte(cuerpo))
+	return cuerpo + LicenseSeparator + codificacion.EncodeToString(firma), nil
+}
 
-	yearStr := strings.TrimPrefix(parts[0], LicensePrefix)
-	year, err := strconv.Atoi(yearStr)
+// ValidateLicenseKey verifica la firma de key con publicKey y devuelve su
+// contenido. No consulta ningún servidor.
+func ValidateLicenseKey(publicKey ed25519.PublicKey, key string) (*Claims, error) {
+	var c carga
+	if err := verificarFirma(publicKey, LicensePrefix, key, &c); err != nil {
+		return nil, err
+	}
+	emision, err := time.Parse(formatoFecha, c.Emision)
+	if err != nil {
+		return nil, fmt.Errorf("fecha de emisión inválida en la licencia")
+	}
+	hasta, err := time.Parse(formatoFecha, c.ActualizacionesHasta)
 	if err != nil {
-		return false, 0, fmt.Errorf("año inválido en licencia")
+		return nil, fmt.Errorf("fecha de actualizaciones inválida en la licencia")
 	}
 
-	expectedKey := g.GenerateLicenseKey(year)
+	claims := &Claims{
+		ID:                   c.ID,
+		ClienteID:            c.ClienteID,
+		Emision:              emision,
+		ActualizacionesHasta: hasta,
+		Edicion:              c.Edicion,
+		Meses:                c.Meses,
+		Adicionales:          c.Adicionales,
+	}
+	if err := claims.validar(); err != nil {
+		return nil, err
+	}
+	if claims.Meses == 0 {
+		claims.Meses = mesesEntre(emision, hasta)
+	}
+	return claims, nil
+}
+
+// verificarFirma comprueba un texto generado por firmar con prefijo y
+// decodifica su contenido en v. Ignora los espacios y saltos de línea que se
+// cuelan al copiar y pegar.
+func verificarFirma(publicKey ed25519.PublicKey, prefijo, texto string, v interface{}) error {
+	partes := strings.Split(strings.Join(strings.Fields(texto), ""), LicenseSeparator)
+	if len(partes) != 3 || partes[0] != prefijo {
+		return fmt.Errorf("formato de licencia inválido")
+	}
+
+	firma, err := codificacion.DecodeString(partes[2])
+	if err != nil || len(firma) != ed25519.SignatureSize {
+		return fmt.Errorf("formato de licencia inválido")
+	}
+	cuerpo := partes[0] + LicenseSeparator + partes[1]
+	if !ed25519.Verify(publicKey, []byte(cuerpo), firma) {
+		return fmt.Errorf("licencia inválida")
+	}
+
+	datos, err := codificacion.DecodeString(partes[1])
+	if err != nil {
+		return fmt.Errorf("formato de licencia inválido")
+	}
+	if err := json.Unmarshal(datos, v); err != nil {
+		return fmt.Errorf("formato de licencia inválido")
+	}
+	return nil
+}
 
-	return key == expectedKey, year, nil
+func (c Claims) validar() error {
+	if strings.TrimSpace(c.ID) == "" {
+		return fmt.Errorf("la licencia no tiene identificador")
+	}
+	if strings.TrimSpace(c.ClienteID) == "" {
+		return fmt.Errorf("la licencia no tiene cliente")
+	}
+	if c.Edicion != models.EdicionBasica && c.Edicion != models.EdicionPro {
+		return fmt.Errorf("edición de licencia desconocida: %q", c.Edicion)
+	}
+	if c.ActualizacionesHasta.Before(c.Emision) {
+		return fmt.Errorf("el período de actualizaciones termina antes de la emisión")
+	}
+	if c.Meses < 0 {
+		return fmt.Errorf("el período de actualizaciones no puede ser negativo")
+	}
+	return nil
 }
 
-func (g *Generator) GetExpirationDate(activationDate time.Time) time.Time {
-	return activationDate.AddDate(1, 0, 0)
+// mesesEntre cuenta los meses completos de desde a hasta.
+func mesesEntre(desde, hasta time.Time) int {
+	meses := (hasta.Year()-desde.Year())*12 + int(hasta.Month()-desde.Month())
+	if hasta.Day() < desde.Day() {
+		meses--
+	}
+	return meses
 }
 
-func (g *Generator) GetLicenseStatus(activationDate, expirationDate time.Time) (string, int) {
-	now := time.Now()
+// GetLicenseStatusAt clasifica el período de actualizaciones que termina en
+// expirationDate, evaluado en now: "activa", "por_expirar" (30 días o menos)
+// o "expirada". now debe ser la fecha efectiva del Reloj, no la del sistema.
+func GetLicenseStatusAt(activationDate, expirationDate, now time.Time) (string, int) {
 	daysRemaining := int(expirationDate.Sub(now).Hours() / 24)
 
 	if now.After(expirationDate) {
@@ -82,3 +204,53 @@ func (g *Generator) GetLicenseStatus(activationDate, expirationDate time.Time) (
 
 	return "activa", daysRemaining
 }
+
+// NuevoID genera un identificador aleatorio de licencia.
+func NuevoID() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return codificacion.EncodeToString(b), nil
+}
+
+// GenerarClaves crea un par de claves nuevo. La privada se devuelve en PEM
+// para guardarla fuera del repositorio; la pública en base64, lista para
+// embeber en la aplicación.
+func GenerarClaves() (privadaPEM []byte, publica string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, "", err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: tipoPEM, Bytes: der}), base64.StdEncoding.EncodeToString(pub), nil
+}
+
+// LeerClavePrivada decodifica una clave privada generada por GenerarClaves.
+func LeerClavePrivada(datos []byte) (ed25519.PrivateKey, error) {
+	bloque, _ := pem.Decode(datos)
+	if bloque == nil || bloque.Type != tipoPEM {
+		return nil, fmt.Errorf("la clave privada no está en formato PEM")
+	}
+	clave, err := x509.ParsePKCS8PrivateKey(bloque.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("clave privada inválida: %w", err)
+	}
+	priv, ok := clave.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("la clave privada no es Ed25519")
+	}
+	return priv, nil
+}
+
+// LeerClavePublica decodifica una clave pública en base64.
+func LeerClavePublica(texto string) (ed25519.PublicKey, error) {
+	datos, err := base64.StdEncoding.DecodeString(strings.TrimSpace(texto))
+	if err != nil || len(datos) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("clave pública de licencias inválida")
+	}
+	return ed25519.PublicKey(datos), nil
+}
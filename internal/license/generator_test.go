@@ -1,142 +1,160 @@
 package license
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"yoyaku/internal/models"
 )
 
-func TestNewGenerator(t *testing.T) {
-	generator := NewGenerator("test_secret")
-	if generator == nil {
-		t.Fatal("NewGenerator returned nil")
-	}
-	if generator.secretKey != "test_secret" {
-		t.Errorf("Expected secretKey to be 'test_secret', got '%s'", generator.secretKey)
+// clavesPrueba genera un par de claves descartable: los tests nunca usan la
+// clave pública embebida en la aplicación.
+func clavesPrueba(t *testing.T) (*Generator, ed25519.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() failed: %v", err)
 	}
+	return NewGenerator(priv), pub
 }
 
-func TestGenerateLicenseKey(t *testing.T) {
-	generator := NewGenerator("test_secret")
+func claimsPrueba(hasta time.Time) Claims {
+	return Claims{
+		ID:                   "lic-0001",
+		ClienteID:            "CLI-0001",
+		Emision:              time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		ActualizacionesHasta: hasta,
+		Edicion:              models.EdicionPro,
+		Meses:                12,
+	}
+}
 
-	tests := []struct {
-		name    string
-		year    int
-		wantErr bool
-	}{
-		{
-			name:    "Generar licencia 2024",
-			year:    2024,
-			wantErr: false,
-		},
-		{
-			name:    "Generar licencia 2025",
-			year:    2025,
-			wantErr: false,
-		},
-		{
-			name:    "Generar licencia 2030",
-			year:    2030,
-			wantErr: false,
-		},
+func licenciaPrueba(t *testing.T, g *Generator, claims Claims) string {
+	t.Helper()
+	key, err := g.GenerateLicenseKey(claims)
+	if err != nil {
+		t.Fatalf("GenerateLicenseKey() failed: %v", err)
 	}
+	return key
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			key := generator.GenerateLicenseKey(tt.year)
+func TestGenerateLicenseKey(t *testing.T) {
+	generator, publicKey := clavesPrueba(t)
+	claims := claimsPrueba(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
 
-			if key == "" {
-				t.Error("GenerateLicenseKey returned empty string")
-			}
+	key := licenciaPrueba(t, generator, claims)
+	if !strings.HasPrefix(key, LicensePrefix+LicenseSeparator) {
+		t.Errorf("Key should start with %q, got %q", LicensePrefix+LicenseSeparator, key)
+	}
+	if parts := strings.Split(key, LicenseSeparator); len(parts) != 3 {
+		t.Errorf("Key should have 3 parts, got %d", len(parts))
+	}
 
-			// Verificar formato
-			if !strings.HasPrefix(key, "YOY") {
-				t.Errorf("Key should start with 'YOY', got '%s'", key)
-			}
+	got, err := ValidateLicenseKey(publicKey, key)
+	if err != nil {
+		t.Fatalf("ValidateLicenseKey() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, claims) {
+		t.Errorf("ValidateLicenseKey() = %+v, want %+v", *got, claims)
+	}
 
-			// Verificar que contiene el año
-			yearStr := strings.TrimPrefix(key[:7], "YOY")
-			if yearStr != string(rune('0'+tt.year/1000))+string(rune('0'+(tt.year/100)%10))+string(rune('0'+(tt.year/10)%10))+string(rune('0'+tt.year%10)) {
-				// Simplificado: solo verificar que el año está en la key
-				if !strings.Contains(key, string(rune('0'+tt.year/1000))) {
-					t.Errorf("Key should contain year %d", tt.year)
-				}
-			}
+	// Sin período explícito se toma de las fechas.
+	sinMeses := claims
+	sinMeses.Meses = 0
+	sinMeses.ActualizacionesHasta = time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)
+	got, err = ValidateLicenseKey(publicKey, licenciaPrueba(t, generator, sinMeses))
+	if err != nil || got.Meses != 5 {
+		t.Errorf("ValidateLicenseKey() Meses = %v (%v), want 5", got, err)
+	}
 
-			// Verificar formato con guiones
-			parts := strings.Split(key, "-")
-			if len(parts) != 3 {
-				t.Errorf("Key should have 3 parts separated by '-', got %d parts", len(parts))
-			}
+	// Las funciones adicionales viajan firmadas y se suman a las de la edición.
+	basica := claims
+	basica.Edicion = models.EdicionBasica
+	basica.Adicionales = []models.Feature{models.FeatureReportes}
+	got, err = ValidateLicenseKey(publicKey, licenciaPrueba(t, generator, basica))
+	if err != nil || !reflect.DeepEqual(got.Features(), []models.Feature{models.FeatureReportes}) {
+		t.Errorf("Features() = %v (%v), want [reportes]", got, err)
+	}
+	if features := claims.Features(); len(features) != 3 {
+		t.Errorf("Features() pro = %v, want las 3 funciones", features)
+	}
 
-			// Verificar longitud aproximada
-			if len(key) < 15 || len(key) > 20 {
-				t.Errorf("Key length should be between 15-20, got %d", len(key))
-			}
-		})
+	invalidas := []struct {
+		name   string
+		claims Claims
+	}{
+		{"sin cliente", Claims{ID: "x", Edicion: models.EdicionBasica}},
+		{"sin identificador", Claims{ClienteID: "CLI", Edicion: models.EdicionBasica}},
+		{"edición desconocida", Claims{ID: "x", ClienteID: "CLI", Edicion: "enterprise"}},
+		{"función desconocida", Claims{ID: "x", ClienteID: "CLI", Edicion: models.EdicionBasica, Adicionales: []models.Feature{"teletransporte"}}},
+		{"período invertido", Claims{ID: "x", ClienteID: "CLI", Edicion: models.EdicionBasica, Emision: claims.ActualizacionesHasta, ActualizacionesHasta: claims.Emision}},
+	}
+	for _, tt := range invalidas {
+		if _, err := generator.GenerateLicenseKey(tt.claims); err == nil {
+			t.Errorf("GenerateLicenseKey(%s) expected error but got none", tt.name)
+		}
 	}
 }
 
 func TestValidateLicenseKey(t *testing.T) {
-	generator := NewGenerator("test_secret")
+	generator, publicKey := clavesPrueba(t)
+	otroGenerador, _ := clavesPrueba(t)
 
-	// Generar una licencia válida para testing
-	validKey2025 := generator.GenerateLicenseKey(2025)
-	validKey2024 := generator.GenerateLicenseKey(2024)
+	validKey := licenciaPrueba(t, generator, claimsPrueba(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)))
+	parts := strings.Split(validKey, LicenseSeparator)
+	otraCarga := strings.Split(licenciaPrueba(t, generator, claimsPrueba(time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC))), LicenseSeparator)[1]
 
 	tests := []struct {
 		name        string
 		key         string
-		wantValid   bool
-		wantYear    int
 		wantErr     bool
 		errContains string
 	}{
 		{
-			name:      "Licencia válida 2025",
-			key:       validKey2025,
-			wantValid: true,
-			wantYear:  2025,
-			wantErr:   false,
+			name: "Licencia válida",
+			key:  validKey,
 		},
 		{
-			name:      "Licencia válida 2024",
-			key:       validKey2024,
-			wantValid: true,
-			wantYear:  2024,
-			wantErr:   false,
+			name: "Licencia con espacios y saltos de línea",
+			key:  "  " + validKey[:40] + "\n" + validKey[40:] + "  ",
 		},
 		{
-			name:        "Formato inválido - sin guiones",
-			key:         "YOY2025XXXX",
-			wantValid:   false,
+			name:        "Firmada con otra clave",
+			key:         licenciaPrueba(t, otroGenerador, claimsPrueba(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))),
 			wantErr:     true,
-			errContains: "formato",
+			errContains: "inválida",
 		},
 		{
-			name:        "Formato inválido - prefijo incorrecto",
-			key:         "XXX2025-AAAA-BBBB",
-			wantValid:   false,
+			name:        "Contenido modificado",
+			key:         parts[0] + LicenseSeparator + otraCarga + LicenseSeparator + parts[2],
 			wantErr:     true,
-			errContains: "prefijo",
+			errContains: "inválida",
 		},
 		{
-			name:      "Licencia inválida - key incorrecta",
-			key:       "YOY2025-XXXX-YYYY",
-			wantValid: false,
-			wantErr:   false,
+			name:        "Prefijo incorrecto",
+			key:         "YOY2" + strings.TrimPrefix(validKey, LicensePrefix),
+			wantErr:     true,
+			errContains: "formato",
 		},
 		{
-			name:        "String vacío",
-			key:         "",
-			wantValid:   false,
+			name:        "Formato anterior",
+			key:         "YOY2025-9AE6-67EA",
+			wantErr:     true,
+			errContains: "formato",
+		},
+		{
+			name:        "Firma truncada",
+			key:         validKey[:len(validKey)-4],
 			wantErr:     true,
 			errContains: "formato",
 		},
 		{
-			name:        "Solo espacios",
-			key:         "   ",
-			wantValid:   false,
+			name:        "String vacío",
+			key:         "",
 			wantErr:     true,
 			errContains: "formato",
 		},
@@ -144,7 +162,7 @@ func TestValidateLicenseKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			valid, year, err := generator.ValidateLicenseKey(tt.key)
+			claims, err := ValidateLicenseKey(publicKey, tt.key)
 
 			if tt.wantErr {
 				if err == nil {
@@ -161,61 +179,42 @@ func TestValidateLicenseKey(t *testing.T) {
 				t.Errorf("ValidateLicenseKey() unexpected error = %v", err)
 				return
 			}
-
-			if valid != tt.wantValid {
-				t.Errorf("ValidateLicenseKey() valid = %v, want %v", valid, tt.wantValid)
-			}
-
-			if valid && year != tt.wantYear {
-				t.Errorf("ValidateLicenseKey() year = %d, want %d", year, tt.wantYear)
+			if claims.ClienteID != "CLI-0001" || claims.Edicion != models.EdicionPro {
+				t.Errorf("ValidateLicenseKey() claims = %+v", claims)
 			}
 		})
 	}
 }
 
-func TestValidateLicenseKey_CaseInsensitive(t *testing.T) {
-	generator := NewGenerator("test_secret")
-	validKey := generator.GenerateLicenseKey(2025)
-
-	// Probar en minúsculas
-	lowerKey := strings.ToLower(validKey)
-	valid, year, err := generator.ValidateLicenseKey(lowerKey)
-
+func TestClaves(t *testing.T) {
+	privadaPEM, publica, err := GenerarClaves()
 	if err != nil {
-		t.Errorf("ValidateLicenseKey() with lowercase key unexpected error = %v", err)
+		t.Fatalf("GenerarClaves() failed: %v", err)
 	}
-	if !valid {
-		t.Error("ValidateLicenseKey() should accept lowercase keys")
+
+	privateKey, err := LeerClavePrivada(privadaPEM)
+	if err != nil {
+		t.Fatalf("LeerClavePrivada() failed: %v", err)
 	}
-	if year != 2025 {
-		t.Errorf("ValidateLicenseKey() year = %d, want 2025", year)
+	publicKey, err := LeerClavePublica(publica)
+	if err != nil {
+		t.Fatalf("LeerClavePublica() failed: %v", err)
 	}
-}
-
-func TestGetExpirationDate(t *testing.T) {
-	generator := NewGenerator("test_secret")
-
-	activationDate := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
-	expirationDate := generator.GetExpirationDate(activationDate)
 
-	expectedDate := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
-
-	if !expirationDate.Equal(expectedDate) {
-		t.Errorf("GetExpirationDate() = %v, want %v", expirationDate, expectedDate)
+	key := licenciaPrueba(t, NewGenerator(privateKey), claimsPrueba(time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)))
+	if _, err := ValidateLicenseKey(publicKey, key); err != nil {
+		t.Errorf("ValidateLicenseKey() with generated pair failed: %v", err)
 	}
 
-	// Verificar que siempre es exactamente 1 año después
-	diff := expirationDate.Sub(activationDate)
-	days := int(diff.Hours() / 24)
-
-	// Considerando años bisiestos, debería ser aproximadamente 365 o 366 días
-	if days != 365 && days != 366 {
-		t.Errorf("Expected 365 or 366 days, got %d", days)
+	if _, err := LeerClavePrivada([]byte("no es PEM")); err == nil {
+		t.Error("LeerClavePrivada() should reject invalid input")
+	}
+	if _, err := LeerClavePublica("corta"); err == nil {
+		t.Error("LeerClavePublica() should reject invalid input")
 	}
 }
 
-func TestGetLicenseStatus(t *testing.T) {
-	generator := NewGenerator("test_secret")
+func TestGetLicenseStatusAt(t *testing.T) {
 	now := time.Now()
 
 	tests := []struct {
@@ -270,42 +269,16 @@ func TestGetLicenseStatus(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			status, daysRemaining := generator.GetLicenseStatus(tt.activationDate, tt.expirationDate)
+			status, daysRemaining := GetLicenseStatusAt(tt.activationDate, tt.expirationDate, now)
 
 			if status != tt.wantStatus {
-				t.Errorf("GetLicenseStatus() status = %v, want %v", status, tt.wantStatus)
+				t.Errorf("GetLicenseStatusAt() status = %v, want %v", status, tt.wantStatus)
 			}
 
 			if daysRemaining < tt.minDaysRemaining || daysRemaining > tt.maxDaysRemaining {
-				t.Errorf("GetLicenseStatus() daysRemaining = %d, want between %d and %d",
+				t.Errorf("GetLicenseStatusAt() daysRemaining = %d, want between %d and %d",
 					daysRemaining, tt.minDaysRemaining, tt.maxDaysRemaining)
 			}
 		})
 	}
 }
-
-func TestGenerateLicenseKey_Deterministic(t *testing.T) {
-	// El mismo año debería generar la misma key
-	generator1 := NewGenerator("test_secret")
-	generator2 := NewGenerator("test_secret")
-
-	key1 := generator1.GenerateLicenseKey(2025)
-	key2 := generator2.GenerateLicenseKey(2025)
-
-	if key1 != key2 {
-		t.Errorf("Same year should generate same key: %s vs %s", key1, key2)
-	}
-
-	// Diferente año debería generar diferente key
-	key2024 := generator1.GenerateLicenseKey(2024)
-	if key1 == key2024 {
-		t.Error("Different years should generate different keys")
-	}
-
-	// Diferente secreto debería generar diferente key
-	generator3 := NewGenerator("different_secret")
-	key3 := generator3.GenerateLicenseKey(2025)
-	if key1 == key3 {
-		t.Error("Different secrets should generate different keys")
-	}
-}
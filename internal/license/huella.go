@@ -0,0 +1,32 @@
+package license
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"strings"
+)
+
+// HuellaMaquina identifica esta máquina a partir del identificador que le
+// asigna el sistema operativo al instalarse, que no cambia al renombrar el
+// equipo ni al cambiar de red. Se devuelve derivada con SHA-256 para no
+// exponer el identificador original en los códigos de activación.
+func HuellaMaquina() (string, error) {
+	id, err := idMaquina()
+	if err != nil {
+		return "", fmt.Errorf("no se pudo identificar la máquina: %w", err)
+	}
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return "", fmt.Errorf("no se pudo identificar la máquina: identificador vacío")
+	}
+	return derivarHuella(id), nil
+}
+
+// derivarHuella devuelve 16 caracteres en grupos de 4 (XXXX-XXXX-XXXX-XXXX)
+// para que se puedan dictar por teléfono.
+func derivarHuella(id string) string {
+	suma := sha256.Sum256([]byte("yoyaku-huella|" + id))
+	texto := base32.StdEncoding.EncodeToString(suma[:10])
+	return texto[0:4] + "-" + texto[4:8] + "-" + texto[8:12] + "-" + texto[12:16]
+}
@@ -0,0 +1,24 @@
+package license
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// idMaquina lee el IOPlatformUUID del equipo.
+func idMaquina() (string, error) {
+	salida, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", err
+	}
+	for _, linea := range strings.Split(string(salida), "\n") {
+		if !strings.Contains(linea, "IOPlatformUUID") {
+			continue
+		}
+		if partes := strings.Split(linea, `"`); len(partes) >= 4 {
+			return partes[3], nil
+		}
+	}
+	return "", fmt.Errorf("no se encontró IOPlatformUUID")
+}
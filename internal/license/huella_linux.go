@@ -0,0 +1,18 @@
+package license
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// idMaquina lee el machine-id de systemd, o el de D-Bus en sistemas sin
+// systemd.
+func idMaquina() (string, error) {
+	for _, ruta := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if datos, err := os.ReadFile(ruta); err == nil && strings.TrimSpace(string(datos)) != "" {
+			return string(datos), nil
+		}
+	}
+	return "", fmt.Errorf("no se encontró /etc/machine-id")
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package license
+
+import "fmt"
+
+func idMaquina() (string, error) {
+	return "", fmt.Errorf("sistema operativo no soportado")
+}
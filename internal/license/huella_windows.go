@@ -0,0 +1,25 @@
+package license
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// idMaquina lee el MachineGuid que Windows genera al instalarse.
+func idMaquina() (string, error) {
+	cmd := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid")
+	// Sin esto la aplicación abre una consola por un instante.
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	salida, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	for _, linea := range strings.Split(string(salida), "\n") {
+		if campos := strings.Fields(linea); len(campos) == 3 && campos[0] == "MachineGuid" {
+			return campos[2], nil
+		}
+	}
+	return "", fmt.Errorf("no se encontró MachineGuid")
+}
@@ -0,0 +1,136 @@
+package license
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+// periodo es el período de actualizaciones que resulta de las licencias
+// firmadas aplicadas en esta instalación.
+type periodo struct {
+	activacion time.Time
+	expiracion time.Time
+	// alterado indica que lo guardado no coincide con lo firmado.
+	alterado bool
+}
+
+// periodoFirmado recalcula el período de licencia, cuya clave ya verificó
+// como claims, a partir de las licencias firmadas: las del historial,
+// aplicadas en orden con las mismas reglas que ValidarLicencia. Las fechas
+// guardadas en licencia sólo se comparan con el resultado; si no coinciden, o
+// el historial no es coherente con las licencias que registra, el período
+// queda marcado como alterado.
+func (s *Service) periodoFirmado(licencia *models.Licencia, claims *Claims) (*periodo, error) {
+	aplicaciones, err := s.repo.ListarAplicaciones()
+	if err != nil {
+		return nil, err
+	}
+	// Las instalaciones anteriores al historial sólo aplicaron la vigente.
+	p := &periodo{activacion: licencia.FechaActivacion, expiracion: claims.ActualizacionesHasta}
+	if len(aplicaciones) > 0 {
+		p = s.recorrerHistorial(aplicaciones)
+		if aplicaciones[0].LicenseKey != licencia.LicenseKey {
+			p.alterado = true
+		}
+	}
+	if !licencia.Activa || !mismaFecha(licencia.FechaExpiracion, p.expiracion) {
+		p.alterado = true
+	}
+	return p, nil
+}
+
+// recorrerHistorial vuelve a aplicar, de la más antigua a la más reciente,
+// las licencias de aplicaciones. La primera no tiene período anterior: un
+// vencimiento previo sin licencia firmada que lo respalde no se acepta.
+func (s *Service) recorrerHistorial(aplicaciones []models.LicenciaAplicada) *periodo {
+	p := &periodo{}
+	for i := len(aplicaciones) - 1; i >= 0; i-- {
+		a := aplicaciones[i]
+		claims, err := s.verificar(a.LicenseKey)
+		if err != nil || claims.ID != a.LicenciaID {
+			p.alterado = true
+			continue
+		}
+
+		nueva := claims.ActualizacionesHasta
+		if p.expiracion.IsZero() {
+			p.activacion = a.AplicadaAt
+			if a.ExpiracionAnterior != nil {
+				p.alterado = true
+			}
+		} else {
+			if a.ExpiracionAnterior == nil || !mismaFecha(*a.ExpiracionAnterior, p.expiracion) {
+				p.alterado = true
+			}
+			nueva = extenderPeriodo(p.expiracion, a.AplicadaAt, claims)
+		}
+		if !mismaFecha(a.ExpiracionNueva, nueva) {
+			p.alterado = true
+		}
+		p.expiracion = nueva
+	}
+	return p
+}
+
+// periodoVigente devuelve el período firmado de actual, o nil si no hay
+// licencia guardada o su clave no verifica.
+func (s *Service) periodoVigente(actual *models.Licencia) (*periodo, error) {
+	if actual == nil {
+		return nil, nil
+	}
+	claims, err := s.verificar(actual.LicenseKey)
+	if err != nil {
+		return nil, nil
+	}
+	return s.periodoFirmado(actual, claims)
+}
+
+// infoNoVerificada informa una licencia guardada cuya clave no verifica con
+// la clave pública. Ni sus fechas ni su edición están firmadas, así que no se
+// usan ni habilitan funciones: hay que activar una licencia válida.
+func infoNoVerificada(licencia *models.Licencia) *models.InfoLicencia {
+	if esClaveAnterior(licencia.LicenseKey) {
+		return &models.InfoLicencia{
+			Estado:   models.LicenciaNoConfigurada,
+			Features: []models.Feature{},
+			Mensaje:  "Su licencia es del formato anterior, que ya no se acepta. Solicite a soporte una licencia nueva y actívela en esta máquina; sus datos se conservan.",
+		}
+	}
+	return &models.InfoLicencia{
+		Estado:   models.LicenciaAlterada,
+		Features: []models.Feature{},
+		Mensaje:  "La licencia guardada no es válida: fue modificada o no corresponde a una licencia emitida. Active una licencia para seguir usando Yoyaku; sus datos se conservan.",
+	}
+}
+
+// secretoAnterior es el de las claves YOY<año>-XXXX-XXXX del formato
+// anterior. Sólo sirve para reconocerlas al informar el estado; ya no
+// habilitan nada.
+const secretoAnterior = "yoyaku_secret_2024"
+
+func esClaveAnterior(key string) bool {
+	partes := strings.Split(strings.ToUpper(strings.TrimSpace(key)), "-")
+	if len(partes) != 3 || !strings.HasPrefix(partes[0], "YOY") {
+		return false
+	}
+	anio, err := strconv.Atoi(strings.TrimPrefix(partes[0], "YOY"))
+	if err != nil {
+		return false
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s%d%s", secretoAnterior, anio, "1.0.0")))
+	esperada := strings.ToUpper(hex.EncodeToString(hash[:]))
+	return partes[1] == esperada[0:4] && partes[2] == esperada[4:8]
+}
+
+// mismaFecha compara dos vencimientos con un margen de un día, el que puede
+// aparecer al releer de la base una fecha guardada en otra zona horaria.
+func mismaFecha(a, b time.Time) bool {
+	diferencia := a.Sub(b)
+	return diferencia > -24*time.Hour && diferencia < 24*time.Hour
+}
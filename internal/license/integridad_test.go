@@ -0,0 +1,144 @@
+package license
+
+import (
+	"testing"
+	"time"
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+func TestObtenerInfoLicencia_Alterada(t *testing.T) {
+	hoy := time.Now().UTC().Truncate(24 * time.Hour)
+	vence := hoy.AddDate(0, 0, 100)
+	renovada := vence.AddDate(0, 12, 0)
+
+	tests := []struct {
+		name    string
+		alterar func(m *MockLicenseRepo)
+		want    models.EstadoLicencia
+		// wantExpiracion, si no es cero, reemplaza al período renovado: sin
+		// la primera licencia la renovación no tiene de dónde extenderse.
+		wantExpiracion time.Time
+	}{
+		{"sin cambios", func(m *MockLicenseRepo) {}, models.LicenciaActiva, time.Time{}},
+		{"vencimiento extendido", func(m *MockLicenseRepo) {
+			m.licencia.FechaExpiracion = renovada.AddDate(5, 0, 0)
+		}, models.LicenciaAlterada, time.Time{}},
+		{"desactivada", func(m *MockLicenseRepo) {
+			m.licencia.Activa = false
+		}, models.LicenciaAlterada, time.Time{}},
+		{"historial extendido", func(m *MockLicenseRepo) {
+			m.aplicaciones[1].ExpiracionNueva = vence.AddDate(5, 0, 0)
+		}, models.LicenciaAlterada, time.Time{}},
+		{"licencia del historial cambiada", func(m *MockLicenseRepo) {
+			m.aplicaciones[1].LicenseKey = m.aplicaciones[0].LicenseKey
+		}, models.LicenciaAlterada, hoy},
+		{"clave vigente reemplazada", func(m *MockLicenseRepo) {
+			m.licencia.LicenseKey = m.aplicaciones[1].LicenseKey
+		}, models.LicenciaAlterada, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockLicenseRepo{}
+			service, generator := servicioPrueba(t, mockRepo)
+
+			primera := claimsPrueba(vence)
+			if _, err := service.ValidarLicencia(licenciaPrueba(t, generator, primera),
+				activacionPrueba(t, generator, primera.ID, huellaPrueba)); err != nil {
+				t.Fatalf("ValidarLicencia() failed: %v", err)
+			}
+			renovacion := claimsPrueba(hoy)
+			renovacion.ID = "lic-0002"
+			if _, err := service.ValidarLicencia(licenciaPrueba(t, generator, renovacion),
+				activacionPrueba(t, generator, renovacion.ID, huellaPrueba)); err != nil {
+				t.Fatalf("ValidarLicencia() renovación failed: %v", err)
+			}
+
+			tt.alterar(mockRepo)
+			info, err := service.ObtenerInfoLicencia()
+			if err != nil {
+				t.Fatalf("ObtenerInfoLicencia() failed: %v", err)
+			}
+			if info.Estado != tt.want {
+				t.Errorf("Estado = %v, want %v (%s)", info.Estado, tt.want, info.Mensaje)
+			}
+			// Las fechas salen siempre de las licencias firmadas.
+			want := renovada
+			if !tt.wantExpiracion.IsZero() {
+				want = tt.wantExpiracion
+			}
+			if !info.FechaExpiracion.Equal(want) {
+				t.Errorf("FechaExpiracion = %v, want %v", info.FechaExpiracion, want)
+			}
+		})
+	}
+}
+
+func TestValidarLicencia_RenovacionAlterada(t *testing.T) {
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer database.Close()
+	repo := db.NewLicenseRepo(database)
+	service, generator := servicioPrueba(t, repo)
+
+	vence := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 100)
+	primera := claimsPrueba(vence)
+	if _, err := service.ValidarLicencia(licenciaPrueba(t, generator, primera),
+		activacionPrueba(t, generator, primera.ID, huellaPrueba)); err != nil {
+		t.Fatalf("ValidarLicencia() failed: %v", err)
+	}
+
+	// Editar la base no extiende el período, ni siquiera al renovar.
+	licencia, err := repo.Obtener()
+	if err != nil {
+		t.Fatalf("Obtener() failed: %v", err)
+	}
+	licencia.FechaExpiracion = vence.AddDate(10, 0, 0)
+	if err := repo.Guardar(licencia); err != nil {
+		t.Fatalf("Guardar() failed: %v", err)
+	}
+	if info, err := service.ObtenerInfoLicencia(); err != nil || info.Estado != models.LicenciaAlterada {
+		t.Fatalf("ObtenerInfoLicencia() = %+v, %v; want alterada", info, err)
+	}
+
+	renovacion := claimsPrueba(vence)
+	renovacion.ID = "lic-0002"
+	info, err := service.ValidarLicencia(licenciaPrueba(t, generator, renovacion),
+		activacionPrueba(t, generator, renovacion.ID, huellaPrueba))
+	if err != nil {
+		t.Fatalf("ValidarLicencia() renovación failed: %v", err)
+	}
+	if info.Estado != models.LicenciaActiva {
+		t.Errorf("Estado = %v, want activa después de renovar (%s)", info.Estado, info.Mensaje)
+	}
+	if want := vence.AddDate(0, 12, 0); !info.FechaExpiracion.Equal(want) {
+		t.Errorf("FechaExpiracion = %v, want %v", info.FechaExpiracion, want)
+	}
+}
+
+func TestValidarLicencia_SobreFilaNoVerificada(t *testing.T) {
+	// Una fila editada a mano no aporta días a la licencia que se active.
+	mockRepo := &MockLicenseRepo{licencia: &models.Licencia{
+		LicenseKey:      "cualquier-cosa",
+		FechaActivacion: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		FechaExpiracion: time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC),
+		Activa:          true,
+	}}
+	service, generator := servicioPrueba(t, mockRepo)
+
+	vence := time.Now().UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+	claims := claimsPrueba(vence)
+	info, err := service.ValidarLicencia(licenciaPrueba(t, generator, claims),
+		activacionPrueba(t, generator, claims.ID, huellaPrueba))
+	if err != nil {
+		t.Fatalf("ValidarLicencia() failed: %v", err)
+	}
+	if info.Estado != models.LicenciaActiva || !info.FechaExpiracion.Equal(vence) {
+		t.Errorf("ObtenerInfoLicencia() = %s hasta %v; want activa hasta %v", info.Estado, info.FechaExpiracion, vence)
+	}
+	if a := mockRepo.aplicaciones[0]; a.ExpiracionAnterior != nil {
+		t.Errorf("ExpiracionAnterior = %v, want nil", a.ExpiracionAnterior)
+	}
+}
@@ -0,0 +1,214 @@
+package license
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+// DiasPrueba es la duración del período de prueba, que empieza la primera
+// vez que se abre la aplicación sin licencia.
+const DiasPrueba = 30
+
+// El inicio de la prueba se guarda como una marca "<fecha>.<mac>" en la base
+// de datos y en archivos fuera de ella. La clave del MAC se deriva de la
+// huella de la máquina: editar la fecha o copiar la marca de otra computadora
+// la invalida, y borrar alguna copia se repara con las otras. La clave no es
+// secreta, así que quien lea el programa puede fabricar una marca; por eso el
+// inicio tampoco puede ser posterior al primer paciente o turno cargado, y
+// reiniciar la prueba exige borrar también los datos.
+
+func marcaPrueba(inicio time.Time, huella string) string {
+	return marcarFecha("yoyaku-prueba", inicio, huella)
+}
+
+func leerMarcaPrueba(marca, huella string) (time.Time, error) {
+	t, err := leerMarcaFecha("yoyaku-prueba", marca, huella)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("marca de prueba inválida")
+	}
+	return t, nil
+}
+
+// marcarFecha firma t con una clave derivada de contexto y huella, así una
+// marca no sirve para otro uso ni en otra máquina.
+func marcarFecha(contexto string, t time.Time, huella string) string {
+	fecha := t.UTC().Format(time.RFC3339)
+	return fecha + LicenseSeparator + codificacion.EncodeToString(macFecha(contexto, fecha, huella))
+}
+
+func leerMarcaFecha(contexto, marca, huella string) (time.Time, error) {
+	fecha, mac, ok := strings.Cut(strings.TrimSpace(marca), LicenseSeparator)
+	firma, err := codificacion.DecodeString(mac)
+	if !ok || err != nil || !hmac.Equal(firma, macFecha(contexto, fecha, huella)) {
+		return time.Time{}, fmt.Errorf("marca inválida")
+	}
+	return time.Parse(time.RFC3339, fecha)
+}
+
+func macFecha(contexto, fecha, huella string) []byte {
+	clave := sha256.Sum256([]byte(contexto + "|" + huella))
+	mac := hmac.New(sha256.New, clave[:])
+	mac.Write([]byte(fecha))
+	return mac.Sum(nil)
+}
+
+// copiasPredeterminadas son las copias secundarias de una marca, en las
+// carpetas de configuración y de caché del usuario y no junto a la base de
+// datos.
+func copiasPredeterminadas(nombre string) []string {
+	var archivos []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		archivos = append(archivos, filepath.Join(dir, "yoyaku", nombre))
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		archivos = append(archivos, filepath.Join(dir, "yoyaku", "."+nombre))
+	}
+	return archivos
+}
+
+// leerCopias devuelve el contenido de cada archivo, o "" si no se puede leer.
+func leerCopias(archivos []string) []string {
+	copias := make([]string, len(archivos))
+	for i, archivo := range archivos {
+		if datos, err := os.ReadFile(archivo); err == nil {
+			copias[i] = strings.TrimSpace(string(datos))
+		}
+	}
+	return copias
+}
+
+// guardarCopias escribe marca en los archivos cuyo contenido leído no
+// coincide. Las copias secundarias son una ayuda: si no se pueden escribir,
+// sigue valiendo la de la base de datos.
+func guardarCopias(archivos, leidas []string, marca string) {
+	for i, archivo := range archivos {
+		if leidas[i] == marca {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(archivo), 0700); err == nil {
+			os.WriteFile(archivo, []byte(marca), 0600)
+		}
+	}
+}
+
+// Prueba es el estado del período de prueba de esta instalación.
+type Prueba struct {
+	Inicio time.Time
+	Fin    time.Time
+	// Alterada indica que alguna de las marcas no verifica; la prueba se
+	// da por terminada.
+	Alterada bool
+}
+
+// Vigente indica si la prueba sigue en curso en ahora.
+func (p *Prueba) Vigente(ahora time.Time) bool {
+	return !p.Alterada && ahora.Before(p.Fin)
+}
+
+// DiasRestantes cuenta los días que faltan para el fin, redondeando hacia
+// arriba para que el último día figure como 1.
+func (p *Prueba) DiasRestantes(ahora time.Time) int {
+	if !p.Vigente(ahora) {
+		return 0
+	}
+	return int(math.Ceil(p.Fin.Sub(ahora).Hours() / 24))
+}
+
+// prueba lee el inicio de la prueba de sus copias, lo inicia si no hay
+// ninguna y restaura las que falten. Si las copias difieren, o hay datos
+// cargados antes, se toma la fecha más antigua.
+func (s *Service) prueba() (*Prueba, error) {
+	huella, err := s.huella()
+	if err != nil {
+		// Sin huella la marca sólo queda ligada a esta instalación.
+		huella = ""
+	}
+	enBase, err := s.repo.ObtenerPrueba()
+	if err != nil {
+		return nil, err
+	}
+	enArchivos := leerCopias(s.archivosPrueba)
+
+	var inicio time.Time
+	for _, marca := range append([]string{enBase}, enArchivos...) {
+		if marca == "" {
+			continue
+		}
+		t, err := leerMarcaPrueba(marca, huella)
+		if err != nil {
+			return &Prueba{Alterada: true}, nil
+		}
+		if inicio.IsZero() || t.Before(inicio) {
+			inicio = t
+		}
+	}
+	primero, err := s.repo.PrimerRegistro()
+	if err != nil {
+		return nil, err
+	}
+	if !primero.IsZero() && (inicio.IsZero() || primero.Before(inicio)) {
+		inicio = primero.UTC().Truncate(time.Second)
+	}
+	if inicio.IsZero() {
+		inicio = s.ahora().UTC().Truncate(time.Second)
+	}
+
+	marca := marcaPrueba(inicio, huella)
+	if enBase != marca {
+		if err := s.repo.GuardarPrueba(marca); err != nil {
+			return nil, fmt.Errorf("error guardando período de prueba: %w", err)
+		}
+	}
+	guardarCopias(s.archivosPrueba, enArchivos, marca)
+
+	return &Prueba{Inicio: inicio, Fin: inicio.AddDate(0, 0, DiasPrueba)}, nil
+}
+
+// infoPrueba informa el estado de una instalación sin licencia, evaluado en
+// la fecha efectiva de reloj.
+func (s *Service) infoPrueba(reloj *Reloj) (*models.InfoLicencia, error) {
+	p, err := s.prueba()
+	if err != nil {
+		return nil, err
+	}
+	ahora := reloj.Efectiva()
+
+	if p.Vigente(ahora) {
+		dias := p.DiasRestantes(ahora)
+		info := &models.InfoLicencia{
+			Estado:          models.LicenciaPrueba,
+			FechaActivacion: p.Inicio,
+			FechaExpiracion: p.Fin,
+			DiasRestantes:   dias,
+			Features:        unirFeatures(FeaturesEdicion[models.EdicionBasica]),
+			Mensaje: fmt.Sprintf("Período de prueba: quedan %d días (hasta el %s). Active su licencia para seguir usando Yoyaku después.",
+				dias, p.Fin.Local().Format("02/01/2006")),
+		}
+		if reloj.Atrasado() {
+			info.Estado = models.LicenciaRelojAtrasado
+			info.Mensaje = mensajeReloj(reloj)
+		}
+		return info, nil
+	}
+
+	info := &models.InfoLicencia{
+		Estado:   models.LicenciaPruebaVencida,
+		Features: []models.Feature{},
+		Mensaje:  "El período de prueba finalizó. Active una licencia para seguir usando Yoyaku; sus datos se conservan.",
+	}
+	if !p.Alterada {
+		info.FechaActivacion = p.Inicio
+		info.FechaExpiracion = p.Fin
+		info.Mensaje = fmt.Sprintf("El período de prueba finalizó el %s. Active una licencia para seguir usando Yoyaku; sus datos se conservan.",
+			p.Fin.Local().Format("02/01/2006"))
+	}
+	return info, nil
+}
@@ -0,0 +1,99 @@
+package license
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+func TestMarcaPrueba(t *testing.T) {
+	inicio := time.Date(2025, 3, 10, 15, 4, 5, 0, time.UTC)
+	marca := marcaPrueba(inicio, huellaPrueba)
+
+	got, err := leerMarcaPrueba(marca, huellaPrueba)
+	if err != nil || !got.Equal(inicio) {
+		t.Errorf("leerMarcaPrueba() = %v, %v; want %v", got, err, inicio)
+	}
+
+	invalidas := map[string]string{
+		"fecha editada": "2025-04-10T15:04:05Z" + marca[len("2025-03-10T15:04:05Z"):],
+		"otra máquina":  marcaPrueba(inicio, "ZZZZ-ZZZZ-ZZZZ-ZZZZ"),
+		"sin mac":       "2025-03-10T15:04:05Z",
+		"vacía":         "",
+	}
+	for nombre, m := range invalidas {
+		if _, err := leerMarcaPrueba(m, huellaPrueba); err == nil {
+			t.Errorf("leerMarcaPrueba(%s) expected error but got none", nombre)
+		}
+	}
+}
+
+func TestPrueba(t *testing.T) {
+	mockRepo := &MockLicenseRepo{}
+	service, _ := servicioPrueba(t, mockRepo)
+
+	// La primera vez se inicia y se guarda en todas las copias.
+	info, err := service.ObtenerInfoLicencia()
+	if err != nil {
+		t.Fatalf("ObtenerInfoLicencia() failed: %v", err)
+	}
+	if info.Estado != models.LicenciaPrueba || info.DiasRestantes != DiasPrueba {
+		t.Errorf("info = %+v, want prueba con %d días", info, DiasPrueba)
+	}
+	for _, archivo := range service.archivosPrueba {
+		copia, err := os.ReadFile(archivo)
+		if err != nil || string(copia) != mockRepo.prueba || mockRepo.prueba == "" {
+			t.Fatalf("copias de la marca: base %q, %s %q (%v)", mockRepo.prueba, archivo, copia, err)
+		}
+	}
+
+	// Borrar la copia de la base y una de las otras no reinicia la prueba:
+	// se restauran de la que queda, y entre varias copias vale la más antigua.
+	anterior := marcaPrueba(time.Now().AddDate(0, 0, -20), huellaPrueba)
+	os.Remove(service.archivosPrueba[0])
+	os.WriteFile(service.archivosPrueba[1], []byte(anterior), 0600)
+	mockRepo.prueba = ""
+	info, err = service.ObtenerInfoLicencia()
+	if err != nil || info.DiasRestantes != 10 || mockRepo.prueba != anterior {
+		t.Errorf("after deleting the DB copy: info = %+v, %v; base = %q", info, err, mockRepo.prueba)
+	}
+	if copia, err := os.ReadFile(service.archivosPrueba[0]); err != nil || string(copia) != anterior {
+		t.Errorf("restored copy = %q, %v; want %q", copia, err, anterior)
+	}
+
+	// Borrar todas las copias tampoco: la prueba empezó, a más tardar, con
+	// el primer dato cargado.
+	mockRepo.prueba = ""
+	for _, archivo := range service.archivosPrueba {
+		os.Remove(archivo)
+	}
+	mockRepo.primer = time.Now().AddDate(0, 0, -DiasPrueba-5)
+	info, err = service.ObtenerInfoLicencia()
+	if err != nil || info.Estado != models.LicenciaPruebaVencida {
+		t.Errorf("after deleting every copy: info = %+v, %v; want prueba vencida", info, err)
+	}
+	mockRepo.primer = time.Time{}
+
+	// Una marca editada da la prueba por terminada.
+	mockRepo.prueba = marcaPrueba(time.Now(), "otra-maquina")
+	info, err = service.ObtenerInfoLicencia()
+	if err != nil || info.Estado != models.LicenciaPruebaVencida {
+		t.Errorf("with a tampered mark: info = %+v, %v; want prueba vencida", info, err)
+	}
+	if requiere, err := service.RequiereActivacion(); err != nil || !requiere {
+		t.Errorf("RequiereActivacion() with a tampered mark = %v, %v; want true", requiere, err)
+	}
+
+	// Al vencer se pide activación y se informa la fecha de fin.
+	vencida := time.Now().AddDate(0, 0, -DiasPrueba-2)
+	mockRepo.prueba = marcaPrueba(vencida, huellaPrueba)
+	for _, archivo := range service.archivosPrueba {
+		os.Remove(archivo)
+	}
+	info, err = service.ObtenerInfoLicencia()
+	if err != nil || info.Estado != models.LicenciaPruebaVencida || info.DiasRestantes != 0 || !containsStr(info.Mensaje, "finalizó el") {
+		t.Errorf("expired trial: info = %+v, %v", info, err)
+	}
+}
@@ -0,0 +1,114 @@
+package license
+
+import (
+	"fmt"
+	"time"
+)
+
+// ToleranciaReloj es cuánto puede estar el reloj del sistema antes del último
+// uso registrado sin considerarlo atrasado: cubre las correcciones de la
+// sincronización horaria y un día de margen por cualquier otro ajuste.
+const ToleranciaReloj = 24 * time.Hour
+
+// IntervaloUso es cada cuánto la aplicación abierta registra el uso.
+const IntervaloUso = 15 * time.Minute
+
+// contextoUso separa la clave de la marca de último uso de la de prueba.
+const contextoUso = "yoyaku-uso"
+
+// Reloj es la fecha del sistema contrastada con el último uso registrado.
+// Atrasar el reloj no devuelve días de prueba ni de actualizaciones: las
+// fechas se evalúan con Efectiva, que nunca retrocede.
+type Reloj struct {
+	Sistema   time.Time
+	UltimoUso time.Time
+}
+
+// Atrasado indica que el reloj del sistema está más de ToleranciaReloj antes
+// del último uso.
+func (r *Reloj) Atrasado() bool {
+	return r.Sistema.Before(r.UltimoUso.Add(-ToleranciaReloj))
+}
+
+// Efectiva es la fecha con la que se evalúan la licencia y la prueba: la del
+// sistema, salvo que sea anterior al último uso.
+func (r *Reloj) Efectiva() time.Time {
+	if r.UltimoUso.After(r.Sistema) {
+		return r.UltimoUso
+	}
+	return r.Sistema
+}
+
+// reloj arma el Reloj con la fecha más reciente que se sabe que ya pasó: el
+// último uso registrado en la base o en sus copias, la última licencia
+// aplicada, el inicio de la prueba o el último paciente o turno guardado.
+// Una marca que no verifica se ignora, como si no existiera; por eso borrar
+// las marcas no alcanza para ocultar un reloj atrasado mientras queden datos.
+func (s *Service) reloj() (*Reloj, error) {
+	huella, err := s.huella()
+	if err != nil {
+		huella = ""
+	}
+	r := &Reloj{Sistema: s.ahora()}
+
+	marca, err := s.repo.ObtenerUltimoUso()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range append([]string{marca}, leerCopias(s.archivosUso)...) {
+		if t, err := leerMarcaFecha(contextoUso, m, huella); err == nil && t.After(r.UltimoUso) {
+			r.UltimoUso = t
+		}
+	}
+	aplicaciones, err := s.repo.ListarAplicaciones()
+	if err != nil {
+		return nil, err
+	}
+	if len(aplicaciones) > 0 && aplicaciones[0].AplicadaAt.After(r.UltimoUso) {
+		r.UltimoUso = aplicaciones[0].AplicadaAt
+	}
+	marca, err = s.repo.ObtenerPrueba()
+	if err != nil {
+		return nil, err
+	}
+	if t, err := leerMarcaPrueba(marca, huella); err == nil && t.After(r.UltimoUso) {
+		r.UltimoUso = t
+	}
+	ultimo, err := s.repo.UltimoRegistro()
+	if err != nil {
+		return nil, err
+	}
+	if ultimo.After(r.UltimoUso) {
+		r.UltimoUso = ultimo
+	}
+	return r, nil
+}
+
+// RegistrarUso guarda la fecha del sistema como último uso. Se llama al
+// iniciar y cada IntervaloUso mientras la aplicación está abierta; el último
+// uso sólo avanza, así que con el reloj atrasado no se modifica.
+func (s *Service) RegistrarUso() error {
+	r, err := s.reloj()
+	if err != nil {
+		return err
+	}
+	if !r.Sistema.After(r.UltimoUso) {
+		return nil
+	}
+	huella, err := s.huella()
+	if err != nil {
+		huella = ""
+	}
+	marca := marcarFecha(contextoUso, r.Sistema, huella)
+	if err := s.repo.GuardarUltimoUso(marca); err != nil {
+		return fmt.Errorf("error guardando último uso: %w", err)
+	}
+	guardarCopias(s.archivosUso, make([]string, len(s.archivosUso)), marca)
+	return nil
+}
+
+// mensajeReloj explica el estado de reloj atrasado.
+func mensajeReloj(r *Reloj) string {
+	return fmt.Sprintf("La fecha de la computadora (%s) es anterior a la última vez que se usó Yoyaku (%s). Corrija la fecha y hora del sistema; mientras tanto la licencia se evalúa con la fecha del último uso.",
+		r.Sistema.Local().Format("02/01/2006"), r.UltimoUso.Local().Format("02/01/2006"))
+}
@@ -0,0 +1,144 @@
+package license
+
+import (
+	"os"
+	"testing"
+	"time"
+	"yoyaku/internal/models"
+)
+
+func TestReloj(t *testing.T) {
+	uso := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		sistema      time.Time
+		wantAtrasado bool
+		wantEfectiva time.Time
+	}{
+		{"adelante", uso.Add(time.Hour), false, uso.Add(time.Hour)},
+		{"ajuste dentro de la tolerancia", uso.Add(-time.Hour), false, uso},
+		{"atrasado", uso.AddDate(0, 0, -10), true, uso},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reloj{Sistema: tt.sistema, UltimoUso: uso}
+			if r.Atrasado() != tt.wantAtrasado {
+				t.Errorf("Atrasado() = %v, want %v", r.Atrasado(), tt.wantAtrasado)
+			}
+			if !r.Efectiva().Equal(tt.wantEfectiva) {
+				t.Errorf("Efectiva() = %v, want %v", r.Efectiva(), tt.wantEfectiva)
+			}
+		})
+	}
+}
+
+func TestRegistrarUso(t *testing.T) {
+	mockRepo := &MockLicenseRepo{}
+	service, _ := servicioPrueba(t, mockRepo)
+	hoy := time.Now().UTC().Truncate(time.Second)
+	service.ahora = func() time.Time { return hoy }
+
+	if err := service.RegistrarUso(); err != nil {
+		t.Fatalf("RegistrarUso() failed: %v", err)
+	}
+	marca := mockRepo.ultimoUso
+
+	// Con el reloj atrasado el último uso no retrocede.
+	service.ahora = func() time.Time { return hoy.AddDate(0, 0, -10) }
+	if err := service.RegistrarUso(); err != nil {
+		t.Fatalf("RegistrarUso() failed: %v", err)
+	}
+	if mockRepo.ultimoUso != marca {
+		t.Errorf("ultimoUso = %q, want %q", mockRepo.ultimoUso, marca)
+	}
+	r, err := service.reloj()
+	if err != nil || !r.UltimoUso.Equal(hoy) || !r.Atrasado() {
+		t.Errorf("reloj() = %+v, %v; want atrasado respecto de %v", r, err, hoy)
+	}
+
+	// Una marca alterada se ignora, y las copias conservan el último uso.
+	mockRepo.ultimoUso = marcarFecha(contextoUso, hoy.AddDate(1, 0, 0), "ZZZZ-ZZZZ-ZZZZ-ZZZZ")
+	if r, err := service.reloj(); err != nil || !r.UltimoUso.Equal(hoy) {
+		t.Errorf("reloj() = %+v, %v; want último uso %v desde las copias", r, err, hoy)
+	}
+
+	// Sin ninguna marca, el último paciente o turno guardado sigue contando.
+	for _, archivo := range service.archivosUso {
+		if err := os.Remove(archivo); err != nil {
+			t.Fatalf("Remove() failed: %v", err)
+		}
+	}
+	mockRepo.ultimoUso = ""
+	if r, err := service.reloj(); err != nil || !r.UltimoUso.IsZero() {
+		t.Errorf("reloj() = %+v, %v; want sin último uso", r, err)
+	}
+	mockRepo.ultimo = hoy.AddDate(0, 0, -2)
+	if r, err := service.reloj(); err != nil || !r.UltimoUso.Equal(mockRepo.ultimo) || !r.Atrasado() {
+		t.Errorf("reloj() = %+v, %v; want atrasado respecto de %v", r, err, mockRepo.ultimo)
+	}
+}
+
+func TestObtenerInfoLicencia_RelojAtrasado(t *testing.T) {
+	hoy := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("prueba", func(t *testing.T) {
+		for _, tt := range []struct {
+			name       string
+			inicio     time.Time
+			wantEstado models.EstadoLicencia
+			wantDias   int
+		}{
+			{"vigente", hoy.AddDate(0, 0, -25), models.LicenciaRelojAtrasado, 5},
+			{"vencida", hoy.AddDate(0, 0, -35), models.LicenciaPruebaVencida, 0},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				mockRepo := &MockLicenseRepo{prueba: marcaPrueba(tt.inicio, huellaPrueba)}
+				service, _ := servicioPrueba(t, mockRepo)
+				service.ahora = func() time.Time { return hoy }
+				if err := service.RegistrarUso(); err != nil {
+					t.Fatalf("RegistrarUso() failed: %v", err)
+				}
+
+				// Atrasar el reloj 20 días no devuelve días de prueba.
+				service.ahora = func() time.Time { return hoy.AddDate(0, 0, -20) }
+				info, err := service.ObtenerInfoLicencia()
+				if err != nil {
+					t.Fatalf("ObtenerInfoLicencia() failed: %v", err)
+				}
+				if info.Estado != tt.wantEstado || info.DiasRestantes != tt.wantDias {
+					t.Errorf("ObtenerInfoLicencia() = %s, %d días; want %s, %d", info.Estado, info.DiasRestantes, tt.wantEstado, tt.wantDias)
+				}
+				requiere, err := service.RequiereActivacion()
+				if err != nil || requiere != (tt.wantEstado == models.LicenciaPruebaVencida) {
+					t.Errorf("RequiereActivacion() = %v, %v", requiere, err)
+				}
+			})
+		}
+	})
+
+	t.Run("licencia", func(t *testing.T) {
+		mockRepo := &MockLicenseRepo{}
+		service, generator := servicioPrueba(t, mockRepo)
+		claims := claimsPrueba(hoy.AddDate(0, 0, -5))
+		if _, err := service.ValidarLicencia(licenciaPrueba(t, generator, claims),
+			activacionPrueba(t, generator, claims.ID, huellaPrueba)); err != nil {
+			t.Fatalf("ValidarLicencia() failed: %v", err)
+		}
+		if err := service.RegistrarUso(); err != nil {
+			t.Fatalf("RegistrarUso() failed: %v", err)
+		}
+
+		service.ahora = func() time.Time { return hoy.AddDate(0, 0, -30) }
+		info, err := service.ObtenerInfoLicencia()
+		if err != nil {
+			t.Fatalf("ObtenerInfoLicencia() failed: %v", err)
+		}
+		if info.Estado != models.LicenciaRelojAtrasado || info.DiasRestantes > 0 {
+			t.Errorf("ObtenerInfoLicencia() = %s, %d días; want reloj atrasado y período vencido", info.Estado, info.DiasRestantes)
+		}
+		if !service.TieneFeature(models.FeatureReportes) {
+			t.Error("TieneFeature() with the clock behind should keep the license features")
+		}
+	})
+}
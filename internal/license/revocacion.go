@@ -0,0 +1,120 @@
+package license
+
+import (
+	"crypto/ed25519"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+// PrefijoRevocacion identifica las listas de revocación firmadas.
+const PrefijoRevocacion = "YOYR1"
+
+// revocacionesEmbebidas es la lista que se distribuye con cada versión. Se
+// reemplaza el archivo con la salida de "license-generator revocations"
+// antes de compilar; vacío significa que no hay ninguna.
+//
+//go:embed revocaciones.txt
+var revocacionesEmbebidas string
+
+type cargaRevocacion struct {
+	Numero    int      `json:"num"`
+	Fecha     string   `json:"fecha"`
+	Licencias []string `json:"lic"`
+}
+
+// GenerarListaRevocacion firma la lista l.
+func (g *Generator) GenerarListaRevocacion(l models.ListaRevocacion) (string, error) {
+	if l.Numero < 1 {
+		return "", fmt.Errorf("la lista de revocación necesita un número")
+	}
+	licencias := l.Licencias
+	if licencias == nil {
+		licencias = []string{}
+	}
+	return g.firmar(PrefijoRevocacion, cargaRevocacion{
+		Numero:    l.Numero,
+		Fecha:     l.Fecha.Format(formatoFecha),
+		Licencias: licencias,
+	})
+}
+
+// ValidarListaRevocacion verifica la firma de texto y devuelve la lista.
+func ValidarListaRevocacion(publicKey ed25519.PublicKey, texto string) (*models.ListaRevocacion, error) {
+	var c cargaRevocacion
+	if err := verificarFirma(publicKey, PrefijoRevocacion, texto, &c); err != nil {
+		return nil, fmt.Errorf("lista de revocación inválida")
+	}
+	fecha, err := time.Parse(formatoFecha, c.Fecha)
+	if err != nil || c.Numero < 1 {
+		return nil, fmt.Errorf("lista de revocación inválida")
+	}
+	return &models.ListaRevocacion{Numero: c.Numero, Fecha: fecha, Licencias: c.Licencias}, nil
+}
+
+// listaRevocacion devuelve la más nueva entre la lista embebida y la
+// importada, o nil si no hay ninguna. Una lista que no verifica se ignora.
+func (s *Service) listaRevocacion() (*models.ListaRevocacion, error) {
+	if s.errClave != nil {
+		return nil, s.errClave
+	}
+	importada, err := s.repo.ObtenerRevocaciones()
+	if err != nil {
+		return nil, err
+	}
+
+	var vigente *models.ListaRevocacion
+	for _, texto := range []string{s.revocacionesEmbebidas, importada} {
+		if strings.TrimSpace(texto) == "" {
+			continue
+		}
+		lista, err := ValidarListaRevocacion(s.publicKey, texto)
+		if err == nil && (vigente == nil || lista.Numero > vigente.Numero) {
+			vigente = lista
+		}
+	}
+	return vigente, nil
+}
+
+// ImportarRevocaciones guarda la lista firmada texto si es más nueva que la
+// vigente. La licencia de esta máquina deja de valer si figura en ella.
+func (s *Service) ImportarRevocaciones(texto string) (*models.ListaRevocacion, error) {
+	if s.errClave != nil {
+		return nil, s.errClave
+	}
+	lista, err := ValidarListaRevocacion(s.publicKey, texto)
+	if err != nil {
+		return nil, err
+	}
+	vigente, err := s.listaRevocacion()
+	if err != nil {
+		return nil, err
+	}
+	if vigente != nil && lista.Numero <= vigente.Numero {
+		return nil, fmt.Errorf("la lista de revocación ya está actualizada (número %d)", vigente.Numero)
+	}
+	if err := s.repo.GuardarRevocaciones(strings.Join(strings.Fields(texto), "")); err != nil {
+		return nil, fmt.Errorf("error guardando lista de revocación: %w", err)
+	}
+	return lista, nil
+}
+
+// ListaRevocacion devuelve la lista vigente, o nil si no hay ninguna.
+func (s *Service) ListaRevocacion() (*models.ListaRevocacion, error) {
+	return s.listaRevocacion()
+}
+
+// verificarRevocacion devuelve un error si la licencia id está revocada.
+func (s *Service) verificarRevocacion(id string) error {
+	lista, err := s.listaRevocacion()
+	if err != nil {
+		return err
+	}
+	if lista.Incluye(id) {
+		return fmt.Errorf("esta licencia fue revocada. Contacte soporte")
+	}
+	return nil
+}
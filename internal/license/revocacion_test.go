@@ -0,0 +1,91 @@
+package license
+
+import (
+	"testing"
+	"time"
+
+	"yoyaku/internal/models"
+)
+
+func listaPrueba(t *testing.T, g *Generator, numero int, licencias ...string) string {
+	t.Helper()
+	texto, err := g.GenerarListaRevocacion(models.ListaRevocacion{Numero: numero, Fecha: time.Now(), Licencias: licencias})
+	if err != nil {
+		t.Fatalf("GenerarListaRevocacion() failed: %v", err)
+	}
+	return texto
+}
+
+func TestValidarListaRevocacion(t *testing.T) {
+	generator, publicKey := clavesPrueba(t)
+	otroGenerador, _ := clavesPrueba(t)
+
+	lista, err := ValidarListaRevocacion(publicKey, listaPrueba(t, generator, 3, "lic-0001"))
+	if err != nil || lista.Numero != 3 || !lista.Incluye("lic-0001") || lista.Incluye("lic-0002") {
+		t.Errorf("ValidarListaRevocacion() = %+v, %v; want número 3 con lic-0001", lista, err)
+	}
+
+	invalidas := map[string]string{
+		"otra clave": listaPrueba(t, otroGenerador, 3, "lic-0001"),
+		"licencia":   licenciaPrueba(t, generator, claimsPrueba(time.Now())),
+		"vacía":      "",
+	}
+	for nombre, texto := range invalidas {
+		if _, err := ValidarListaRevocacion(publicKey, texto); err == nil {
+			t.Errorf("ValidarListaRevocacion(%s) expected error but got none", nombre)
+		}
+	}
+	if _, err := generator.GenerarListaRevocacion(models.ListaRevocacion{}); err == nil {
+		t.Error("GenerarListaRevocacion() without a number should fail")
+	}
+}
+
+func TestRevocacion(t *testing.T) {
+	mockRepo := &MockLicenseRepo{}
+	service, generator := servicioPrueba(t, mockRepo)
+	hasta := time.Now().AddDate(1, 0, 0)
+	key := licenciaPrueba(t, generator, claimsPrueba(hasta))
+	activacion := activacionPrueba(t, generator, "lic-0001", huellaPrueba)
+
+	if _, err := service.ValidarLicencia(key, activacion); err != nil {
+		t.Fatalf("ValidarLicencia() failed: %v", err)
+	}
+
+	// La lista que trae el binario ya revoca la licencia guardada.
+	service.revocacionesEmbebidas = listaPrueba(t, generator, 1, "lic-0001")
+	info, err := service.ObtenerInfoLicencia()
+	if err != nil || info.Estado != models.LicenciaRevocada || len(info.Features) != 0 {
+		t.Errorf("ObtenerInfoLicencia() = %+v, %v; want revocada sin funciones", info, err)
+	}
+	if requiere, err := service.RequiereActivacion(); err != nil || !requiere {
+		t.Errorf("RequiereActivacion() = %v, %v; want true", requiere, err)
+	}
+
+	// Una lista importada más nueva reemplaza a la embebida.
+	if _, err := service.ImportarRevocaciones(listaPrueba(t, generator, 1)); err == nil {
+		t.Error("ImportarRevocaciones() of a list that is not newer should fail")
+	}
+	lista, err := service.ImportarRevocaciones(listaPrueba(t, generator, 2, "lic-0009"))
+	if err != nil || lista.Numero != 2 {
+		t.Fatalf("ImportarRevocaciones() = %+v, %v", lista, err)
+	}
+	if requiere, err := service.RequiereActivacion(); err != nil || requiere {
+		t.Errorf("RequiereActivacion() after a newer list = %v, %v; want false", requiere, err)
+	}
+
+	// Una licencia revocada no se puede activar ni pedir su activación.
+	revocada := claimsPrueba(hasta)
+	revocada.ID = "lic-0009"
+	claveRevocada := licenciaPrueba(t, generator, revocada)
+	if _, err := service.SolicitarActivacion(claveRevocada); err == nil || !containsStr(err.Error(), "revocada") {
+		t.Errorf("SolicitarActivacion() of a revoked license error = %v", err)
+	}
+	if _, err := service.ValidarLicencia(claveRevocada, activacionPrueba(t, generator, "lic-0009", huellaPrueba)); err == nil || !containsStr(err.Error(), "revocada") {
+		t.Errorf("ValidarLicencia() of a revoked license error = %v", err)
+	}
+
+	// Un archivo alterado no se importa.
+	if _, err := service.ImportarRevocaciones(listaPrueba(t, generator, 5)[1:]); err == nil {
+		t.Error("ImportarRevocaciones() of a tampered list should fail")
+	}
+}
@@ -1,7 +1,9 @@
 package license
 
 import (
+	"crypto/ed25519"
 	"fmt"
+	"strings"
 	"time"
 
 	"yoyaku/internal/models"
@@ -10,74 +12,298 @@ import (
 type LicenseRepository interface {
 	Obtener() (*models.Licencia, error)
 	Guardar(licencia *models.Licencia) error
+	Aplicar(licencia *models.Licencia, aplicacion *models.LicenciaAplicada) error
+	ObtenerAplicacion(licenciaID string) (*models.LicenciaAplicada, error)
+	ListarAplicaciones() ([]models.LicenciaAplicada, error)
+	ObtenerPrueba() (string, error)
+	GuardarPrueba(marca string) error
+	PrimerRegistro() (time.Time, error)
+	UltimoRegistro() (time.Time, error)
+	ObtenerRevocaciones() (string, error)
+	GuardarRevocaciones(lista string) error
+	ObtenerUltimoUso() (string, error)
+	GuardarUltimoUso(marca string) error
 }
 
+// ClavePublica es la clave Ed25519, en base64, con la que se verifican las
+// licencias. La clave privada correspondiente nunca se distribuye. Se puede
+// reemplazar al compilar con
+// -ldflags "-X yoyaku/internal/license.ClavePublica=<base64>".
+var ClavePublica = "pyLI13orsNgLulubGYpkpfYcFVLyi7esx5X6VE3S1JY="
+
 type Service struct {
 	repo      LicenseRepository
-	generator *Generator
+	publicKey ed25519.PublicKey
+	errClave  error
+	huella    func() (string, error)
+	// ahora es el reloj del sistema; ver Reloj para cómo se contrasta.
+	ahora func() time.Time
+	// compilacion es la fecha de publicación de este binario; cero si no
+	// se conoce.
+	compilacion time.Time
+	// archivosPrueba y archivosUso son las copias de las marcas de prueba y
+	// de último uso fuera de la base.
+	archivosPrueba []string
+	archivosUso    []string
+	// revocacionesEmbebidas es la lista de revocación que trae el binario.
+	revocacionesEmbebidas string
 }
 
 func NewService(repo LicenseRepository) *Service {
+	publicKey, err := LeerClavePublica(ClavePublica)
 	return &Service{
-		repo:      repo,
-		generator: NewGenerator("yoyaku_secret_2024"),
+		repo:                  repo,
+		publicKey:             publicKey,
+		errClave:              err,
+		huella:                HuellaMaquina,
+		ahora:                 time.Now,
+		compilacion:           fechaCompilacion(),
+		archivosPrueba:        copiasPredeterminadas("prueba"),
+		archivosUso:           copiasPredeterminadas("uso"),
+		revocacionesEmbebidas: revocacionesEmbebidas,
+	}
+}
+
+// verificar valida key contra la clave pública embebida.
+func (s *Service) verificar(key string) (*Claims, error) {
+	if s.errClave != nil {
+		return nil, s.errClave
+	}
+	return ValidateLicenseKey(s.publicKey, key)
+}
+
+// verificarActivacion comprueba que activacion esté firmada para la
+// licencia claims y para esta máquina.
+func (s *Service) verificarActivacion(claims *Claims, activacion string) error {
+	a, err := ValidarActivacion(s.publicKey, activacion)
+	if err != nil {
+		return err
+	}
+	if a.LicenciaID != claims.ID {
+		return fmt.Errorf("el código de activación corresponde a otra licencia")
+	}
+	huella, err := s.huella()
+	if err != nil {
+		return err
 	}
+	if a.Huella != huella {
+		return fmt.Errorf("el código de activación corresponde a otra máquina")
+	}
+	return nil
 }
 
-func (s *Service) ValidarLicencia(key string) (*models.InfoLicencia, error) {
-	valid, year, err := s.generator.ValidateLicenseKey(key)
+// SolicitarActivacion verifica key y devuelve el código de solicitud con la
+// huella de esta máquina, que el usuario le envía al emisor para recibir el
+// código de activación.
+func (s *Service) SolicitarActivacion(key string) (string, error) {
+	claims, err := s.verificar(key)
+	if err != nil {
+		return "", err
+	}
+	if err := s.verificarRevocacion(claims.ID); err != nil {
+		return "", err
+	}
+	huella, err := s.huella()
+	if err != nil {
+		return "", err
+	}
+	return SolicitudActivacion{LicenciaID: claims.ID, ClienteID: claims.ClienteID, Huella: huella}.Codificar(), nil
+}
+
+// ValidarLicencia activa key en esta máquina. activacion es el código que
+// devolvió el emisor para la solicitud de SolicitarActivacion.
+//
+// Una renovación no pierde los días que quedaban: el período se suma a partir
+// del vencimiento vigente, o de hoy si ya venció. Cada licencia se aplica una
+// sola vez; volver a ingresar la vigente sólo sirve para activarla en otra
+// máquina.
+func (s *Service) ValidarLicencia(key, activacion string) (*models.InfoLicencia, error) {
+	claims, err := s.verificar(key)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.verificarRevocacion(claims.ID); err != nil {
+		return nil, err
+	}
+	key = strings.Join(strings.Fields(key), "")
+	activacion = strings.Join(strings.Fields(activacion), "")
 
-	if !valid {
-		return nil, fmt.Errorf("licencia inválida")
+	actual, err := s.repo.Obtener()
+	if err != nil {
+		return nil, err
+	}
+	previa, err := s.repo.ObtenerAplicacion(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if previa != nil || (actual != nil && actual.LicenseKey == key) {
+		return s.reactivar(claims, key, actual, previa, activacion)
 	}
 
-	activationDate := time.Now()
-	expirationDate := s.generator.GetExpirationDate(activationDate)
+	if err := s.verificarActivacion(claims, activacion); err != nil {
+		return nil, err
+	}
 
+	reloj, err := s.reloj()
+	if err != nil {
+		return nil, err
+	}
+	ahora := reloj.Efectiva()
 	licencia := &models.Licencia{
 		LicenseKey:      key,
-		FechaActivacion: activationDate,
-		FechaExpiracion: expirationDate,
+		FechaActivacion: ahora,
+		FechaExpiracion: claims.ActualizacionesHasta,
 		Activa:          true,
-		Version:         fmt.Sprintf("%d.0.0", year),
+		Version:         Version,
+		Activacion:      activacion,
+	}
+	aplicacion := &models.LicenciaAplicada{
+		LicenciaID: claims.ID,
+		LicenseKey: key,
+		ClienteID:  claims.ClienteID,
+		Edicion:    claims.Edicion,
+		Meses:      claims.Meses,
+		AplicadaAt: ahora,
 	}
+	// Sólo se renueva un período firmado; una fila guardada sin licencia
+	// válida no aporta días.
+	if vigente, err := s.periodoVigente(actual); err != nil {
+		return nil, err
+	} else if vigente != nil {
+		anterior := vigente.expiracion
+		aplicacion.ExpiracionAnterior = &anterior
+		licencia.FechaActivacion = vigente.activacion
+		licencia.FechaExpiracion = extenderPeriodo(anterior, ahora, claims)
+	}
+	aplicacion.ExpiracionNueva = licencia.FechaExpiracion
 
-	if err := s.repo.Guardar(licencia); err != nil {
+	if err := s.repo.Aplicar(licencia, aplicacion); err != nil {
 		return nil, fmt.Errorf("error guardando licencia: %w", err)
 	}
 
 	return s.ObtenerInfoLicencia()
 }
 
+// reactivar vuelve a ligar a esta máquina la licencia vigente, por ejemplo
+// al mudar la base de datos a otra computadora, sin tocar su vencimiento.
+// Cualquier otro reingreso de una licencia ya aplicada se rechaza.
+func (s *Service) reactivar(claims *Claims, key string, actual *models.Licencia, previa *models.LicenciaAplicada, activacion string) (*models.InfoLicencia, error) {
+	if actual == nil || actual.LicenseKey != key || s.verificarActivacion(claims, actual.Activacion) == nil {
+		aplicada := previa
+		if aplicada == nil {
+			aplicada = &models.LicenciaAplicada{AplicadaAt: actual.FechaActivacion}
+		}
+		return nil, fmt.Errorf("esta licencia ya se aplicó el %s", aplicada.AplicadaAt.Format("02/01/2006"))
+	}
+	if err := s.verificarActivacion(claims, activacion); err != nil {
+		return nil, err
+	}
+	actual.Activacion = activacion
+	if err := s.repo.Guardar(actual); err != nil {
+		return nil, fmt.Errorf("error guardando licencia: %w", err)
+	}
+	return s.ObtenerInfoLicencia()
+}
+
+// extenderPeriodo suma el período de claims al vencimiento vigente, o a
+// ahora si ya pasó. Nunca queda antes de la fecha firmada en la licencia.
+func extenderPeriodo(vencimiento, ahora time.Time, claims *Claims) time.Time {
+	desde := vencimiento
+	if ahora.After(desde) {
+		desde = ahora
+	}
+	hasta := desde.AddDate(0, claims.Meses, 0)
+	if hasta.Before(claims.ActualizacionesHasta) {
+		hasta = claims.ActualizacionesHasta
+	}
+	return hasta
+}
+
+// TieneFeature indica si la licencia de esta máquina habilita f. La prueba
+// habilita lo mismo que la edición básica. Ante cualquier error de lectura
+// la función queda deshabilitada.
+func (s *Service) TieneFeature(f models.Feature) bool {
+	info, err := s.ObtenerInfoLicencia()
+	if err != nil {
+		return false
+	}
+	for _, habilitada := range info.Features {
+		if habilitada == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Cobertura indica si una versión publicada en compilacion está incluida en
+// un período de actualizaciones que vence en expiracion. Se comparan días: la
+// versión publicada el mismo día del vencimiento todavía está cubierta.
+func Cobertura(compilacion, expiracion time.Time) models.CoberturaActualizacion {
+	if dia(compilacion).After(dia(expiracion)) {
+		return models.VersionNoCubierta
+	}
+	return models.VersionCubierta
+}
+
+func dia(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// HistorialLicencias devuelve las licencias aplicadas en esta instalación,
+// de la más reciente a la más antigua.
+func (s *Service) HistorialLicencias() ([]models.LicenciaAplicada, error) {
+	return s.repo.ListarAplicaciones()
+}
+
 func (s *Service) ObtenerInfoLicencia() (*models.InfoLicencia, error) {
 	licencia, err := s.repo.Obtener()
 	if err != nil {
 		return nil, err
 	}
 
+	reloj, err := s.reloj()
+	if err != nil {
+		return nil, err
+	}
 	if licencia == nil {
-		return &models.InfoLicencia{
-			Estado:  models.LicenciaNoConfigurada,
-			Mensaje: "No hay licencia configurada. Por favor, active su licencia.",
-		}, nil
+		return s.infoPrueba(reloj)
 	}
 
-	status, daysRemaining := s.generator.GetLicenseStatus(licencia.FechaActivacion, licencia.FechaExpiracion)
+	// Las fechas se toman siempre de las licencias firmadas, no de la fila
+	// guardada, que cualquiera puede editar.
+	claims, err := s.verificar(licencia.LicenseKey)
+	if err != nil {
+		return infoNoVerificada(licencia), nil
+	}
+	p, err := s.periodoFirmado(licencia, claims)
+	if err != nil {
+		return nil, err
+	}
+	status, daysRemaining := GetLicenseStatusAt(p.activacion, p.expiracion, reloj.Efectiva())
 
 	info := &models.InfoLicencia{
-		FechaActivacion: licencia.FechaActivacion,
-		FechaExpiracion: licencia.FechaExpiracion,
+		FechaActivacion: p.activacion,
+		FechaExpiracion: p.expiracion,
 		DiasRestantes:   daysRemaining,
 	}
+	info.Features = []models.Feature{}
+	info.ClienteID = claims.ClienteID
+	info.Edicion = claims.Edicion
+	lista, err := s.listaRevocacion()
+	if err != nil {
+		return nil, err
+	}
+	revocada := lista.Incluye(claims.ID)
+	if !revocada && s.verificarActivacion(claims, licencia.Activacion) == nil {
+		info.Features = claims.Features()
+	}
 
 	switch status {
 	case "activa":
 		info.Estado = models.LicenciaActiva
 		info.Mensaje = fmt.Sprintf("Licencia activa. Actualizaciones disponibles hasta %s (%d días restantes)",
-			licencia.FechaExpiracion.Format("02/01/2006"), daysRemaining)
+			p.expiracion.Format("02/01/2006"), daysRemaining)
 	case "por_expirar":
 		info.Estado = models.LicenciaActiva
 		info.Mensaje = fmt.Sprintf("Su período de actualizaciones expira pronto (%d días). El software seguirá funcionando.",
@@ -85,16 +311,68 @@ func (s *Service) ObtenerInfoLicencia() (*models.InfoLicencia, error) {
 	case "expirada":
 		info.Estado = models.LicenciaExpirada
 		info.Mensaje = fmt.Sprintf("Período de actualizaciones finalizado el %s. El software sigue funcionando. Contacte soporte para renovar.",
-			licencia.FechaExpiracion.Format("02/01/2006"))
+			p.expiracion.Format("02/01/2006"))
+	}
+	if reloj.Atrasado() {
+		info.Estado = models.LicenciaRelojAtrasado
+		info.Mensaje = mensajeReloj(reloj)
+	}
+
+	if revocada {
+		info.Estado = models.LicenciaRevocada
+		info.Mensaje = "Esta licencia fue revocada. Contacte soporte para obtener una licencia válida."
+		return info, nil
+	}
+
+	if !s.compilacion.IsZero() {
+		compilacion := s.compilacion
+		info.FechaCompilacion = &compilacion
+		info.Cobertura = Cobertura(compilacion, p.expiracion)
+		if info.Cobertura == models.VersionNoCubierta {
+			info.Mensaje = fmt.Sprintf("Esta versión se publicó el %s, después del fin de su período de actualizaciones (%s). Puede seguir usándola normalmente; renueve su licencia para que quede cubierta.",
+				compilacion.Format("02/01/2006"), p.expiracion.Format("02/01/2006"))
+		}
+	}
+
+	if p.alterado {
+		info.Estado = models.LicenciaAlterada
+		info.Mensaje = fmt.Sprintf("Los datos guardados de la licencia fueron modificados y no coinciden con la licencia firmada. Se usa el período firmado, hasta el %s. Contacte soporte si no hizo ningún cambio.",
+			p.expiracion.Format("02/01/2006"))
 	}
 
 	return info, nil
 }
 
+// RequiereActivacion indica si falta activar una licencia en esta máquina:
+// no hay ninguna y terminó el período de prueba, la guardada no verifica con
+// la clave pública (incluidas las del formato anterior), fue revocada o se
+// activó en otra (por ejemplo, al copiar la base de datos).
 func (s *Service) RequiereActivacion() (bool, error) {
 	licencia, err := s.repo.Obtener()
 	if err != nil {
 		return false, err
 	}
-	return licencia == nil, nil
+	if licencia == nil {
+		p, err := s.prueba()
+		if err != nil {
+			return false, err
+		}
+		reloj, err := s.reloj()
+		if err != nil {
+			return false, err
+		}
+		return !p.Vigente(reloj.Efectiva()), nil
+	}
+	claims, err := s.verificar(licencia.LicenseKey)
+	if err != nil {
+		return true, nil
+	}
+	lista, err := s.listaRevocacion()
+	if err != nil {
+		return false, err
+	}
+	if lista.Incluye(claims.ID) {
+		return true, nil
+	}
+	return s.verificarActivacion(claims, licencia.Activacion) != nil, nil
 }
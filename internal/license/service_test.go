@@ -1,6 +1,7 @@
 package license
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 	"yoyaku/internal/db"
@@ -10,6 +11,12 @@ import (
 // MockLicenseRepo es un mock del repositorio de licencias para testing
 type MockLicenseRepo struct {
 	licencia      *models.Licencia
+	aplicaciones  []models.LicenciaAplicada
+	prueba        string
+	primer        time.Time
+	ultimo        time.Time
+	revocaciones  string
+	ultimoUso     string
 	errObtener    error
 	errGuardar    error
 	guardarCalled bool
@@ -25,6 +32,70 @@ func (m *MockLicenseRepo) Guardar(licencia *models.Licencia) error {
 	return m.errGuardar
 }
 
+func (m *MockLicenseRepo) Aplicar(licencia *models.Licencia, aplicacion *models.LicenciaAplicada) error {
+	m.guardarCalled = true
+	if m.errGuardar != nil {
+		return m.errGuardar
+	}
+	m.licencia = licencia
+	m.aplicaciones = append([]models.LicenciaAplicada{*aplicacion}, m.aplicaciones...)
+	return nil
+}
+
+func (m *MockLicenseRepo) ObtenerAplicacion(licenciaID string) (*models.LicenciaAplicada, error) {
+	for _, a := range m.aplicaciones {
+		if a.LicenciaID == licenciaID {
+			return &a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockLicenseRepo) ListarAplicaciones() ([]models.LicenciaAplicada, error) {
+	return m.aplicaciones, nil
+}
+
+func (m *MockLicenseRepo) ObtenerPrueba() (string, error) {
+	return m.prueba, m.errObtener
+}
+
+func (m *MockLicenseRepo) GuardarPrueba(marca string) error {
+	m.prueba = marca
+	return m.errGuardar
+}
+
+func (m *MockLicenseRepo) PrimerRegistro() (time.Time, error) {
+	return m.primer, m.errObtener
+}
+
+func (m *MockLicenseRepo) UltimoRegistro() (time.Time, error) {
+	return m.ultimo, m.errObtener
+}
+
+func (m *MockLicenseRepo) ObtenerRevocaciones() (string, error) {
+	return m.revocaciones, m.errObtener
+}
+
+func (m *MockLicenseRepo) GuardarRevocaciones(lista string) error {
+	if m.errGuardar != nil {
+		return m.errGuardar
+	}
+	m.revocaciones = lista
+	return nil
+}
+
+func (m *MockLicenseRepo) ObtenerUltimoUso() (string, error) {
+	return m.ultimoUso, m.errObtener
+}
+
+func (m *MockLicenseRepo) GuardarUltimoUso(marca string) error {
+	if m.errGuardar != nil {
+		return m.errGuardar
+	}
+	m.ultimoUso = marca
+	return nil
+}
+
 func (m *MockLicenseRepo) TieneLicenciaActiva() (bool, error) {
 	if m.errObtener != nil {
 		return false, m.errObtener
@@ -47,48 +118,116 @@ func TestNewService(t *testing.T) {
 		t.Error("Service repository not set correctly")
 	}
 
-	if service.generator == nil {
-		t.Error("Service generator not initialized")
+	if service.errClave != nil || len(service.publicKey) == 0 {
+		t.Errorf("embedded public key is invalid: %v", service.errClave)
+	}
+}
+
+const huellaPrueba = "AAAA-BBBB-CCCC-DDDD"
+
+// servicioPrueba arma un Service que verifica con una clave de prueba en
+// lugar de la embebida y con una huella fija, y el generador que firma con
+// esa clave.
+func servicioPrueba(t *testing.T, repo LicenseRepository) (*Service, *Generator) {
+	t.Helper()
+	generator, publicKey := clavesPrueba(t)
+	service := NewService(repo)
+	service.publicKey = publicKey
+	service.huella = func() (string, error) { return huellaPrueba, nil }
+	dir := t.TempDir()
+	service.archivosPrueba = []string{filepath.Join(dir, "config", "prueba"), filepath.Join(dir, "cache", ".prueba")}
+	service.archivosUso = []string{filepath.Join(dir, "config", "uso"), filepath.Join(dir, "cache", ".uso")}
+	return service, generator
+}
+
+func activacionPrueba(t *testing.T, g *Generator, licenciaID, huella string) string {
+	t.Helper()
+	codigo, err := g.GenerarActivacion(Activacion{LicenciaID: licenciaID, Huella: huella, Fecha: time.Now()})
+	if err != nil {
+		t.Fatalf("GenerarActivacion() failed: %v", err)
 	}
+	return codigo
+}
+
+// firmarFila reemplaza la clave de licencia por una firmada que vence en su
+// FechaExpiracion, activada en esta máquina.
+func firmarFila(t *testing.T, g *Generator, licencia *models.Licencia) {
+	t.Helper()
+	claims := claimsPrueba(licencia.FechaExpiracion)
+	licencia.LicenseKey = licenciaPrueba(t, g, claims)
+	licencia.Activacion = activacionPrueba(t, g, claims.ID, huellaPrueba)
 }
 
 func TestValidarLicencia(t *testing.T) {
 	mockRepo := &MockLicenseRepo{}
-	service := NewService(mockRepo)
+	service, generator := servicioPrueba(t, mockRepo)
 
 	// Generar una licencia válida
-	generator := NewGenerator("yoyaku_secret_2024")
-	validKey := generator.GenerateLicenseKey(2025)
+	hasta := time.Now().UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+	validKey := licenciaPrueba(t, generator, claimsPrueba(hasta))
+	otroGenerador, _ := clavesPrueba(t)
+	ajena := licenciaPrueba(t, otroGenerador, claimsPrueba(hasta))
+	activacion := activacionPrueba(t, generator, "lic-0001", huellaPrueba)
 
 	tests := []struct {
 		name           string
 		key            string
+		activacion     string
 		mockErrGuardar error
 		wantErr        bool
 		errContains    string
 		wantEstado     models.EstadoLicencia
 	}{
 		{
-			name:       "Licencia válida 2025",
+			name:       "Licencia válida",
 			key:        validKey,
+			activacion: activacion,
 			wantErr:    false,
 			wantEstado: models.LicenciaActiva,
 		},
 		{
-			name:        "Licencia inválida",
-			key:         "YOY2025-XXXX-YYYY",
+			name:        "Activación de otra máquina",
+			key:         validKey,
+			activacion:  activacionPrueba(t, generator, "lic-0001", "ZZZZ-ZZZZ-ZZZZ-ZZZZ"),
+			wantErr:     true,
+			errContains: "otra máquina",
+		},
+		{
+			name:        "Activación de otra licencia",
+			key:         validKey,
+			activacion:  activacionPrueba(t, generator, "lic-0002", huellaPrueba),
+			wantErr:     true,
+			errContains: "otra licencia",
+		},
+		{
+			name:        "Activación firmada con otra clave",
+			key:         validKey,
+			activacion:  activacionPrueba(t, otroGenerador, "lic-0001", huellaPrueba),
+			wantErr:     true,
+			errContains: "activación inválido",
+		},
+		{
+			name:        "Sin activación",
+			key:         validKey,
+			wantErr:     true,
+			errContains: "activación inválido",
+		},
+		{
+			name:        "Licencia firmada con otra clave",
+			key:         ajena,
 			wantErr:     true,
 			errContains: "inválida",
 		},
 		{
-			name:        "Formato inválido",
-			key:         "invalid-key",
+			name:        "Formato anterior",
+			key:         "YOY2025-9AE6-67EA",
 			wantErr:     true,
 			errContains: "formato",
 		},
 		{
 			name:           "Error al guardar",
 			key:            validKey,
+			activacion:     activacion,
 			mockErrGuardar: errTest,
 			wantErr:        true,
 			errContains:    "guardando",
@@ -97,10 +236,9 @@ func TestValidarLicencia(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo.guardarCalled = false
-			mockRepo.errGuardar = tt.mockErrGuardar
+			*mockRepo = MockLicenseRepo{errGuardar: tt.mockErrGuardar}
 
-			info, err := service.ValidarLicencia(tt.key)
+			info, err := service.ValidarLicencia(tt.key, tt.activacion)
 
 			if tt.wantErr {
 				if err == nil {
@@ -138,6 +276,15 @@ func TestValidarLicencia(t *testing.T) {
 				if !mockRepo.licencia.Activa {
 					t.Error("Guardar() licencia should be active")
 				}
+				if mockRepo.licencia.Activacion != tt.activacion {
+					t.Error("Guardar() should keep the activation code")
+				}
+				if !mockRepo.licencia.FechaExpiracion.Equal(hasta) {
+					t.Errorf("Guardar() expiración = %v, want %v (de la licencia)", mockRepo.licencia.FechaExpiracion, hasta)
+				}
+			}
+			if info.ClienteID != "CLI-0001" || info.Edicion != models.EdicionPro {
+				t.Errorf("info = %+v, want cliente CLI-0001 edición pro", info)
 			}
 		})
 	}
@@ -150,13 +297,14 @@ func TestObtenerInfoLicencia(t *testing.T) {
 		name       string
 		licencia   *models.Licencia
 		mockErr    error
+		sinFirmar  bool
 		wantEstado models.EstadoLicencia
 		wantErr    bool
 	}{
 		{
-			name:       "Sin licencia configurada",
+			name:       "Sin licencia - período de prueba",
 			licencia:   nil,
-			wantEstado: models.LicenciaNoConfigurada,
+			wantEstado: models.LicenciaPrueba,
 			wantErr:    false,
 		},
 		{
@@ -195,6 +343,26 @@ func TestObtenerInfoLicencia(t *testing.T) {
 			wantEstado: models.LicenciaExpirada,
 			wantErr:    false,
 		},
+		{
+			name: "Formato anterior - fechas no firmadas",
+			licencia: &models.Licencia{
+				LicenseKey:      "YOY2025-9AE6-67EA",
+				FechaExpiracion: time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC),
+				Activa:          true,
+			},
+			sinFirmar:  true,
+			wantEstado: models.LicenciaNoConfigurada,
+		},
+		{
+			name: "Clave inválida - fila editada a mano",
+			licencia: &models.Licencia{
+				LicenseKey:      "cualquier-cosa",
+				FechaExpiracion: time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC),
+				Activa:          true,
+			},
+			sinFirmar:  true,
+			wantEstado: models.LicenciaAlterada,
+		},
 		{
 			name:       "Error al obtener",
 			licencia:   nil,
@@ -210,7 +378,10 @@ func TestObtenerInfoLicencia(t *testing.T) {
 				licencia:   tt.licencia,
 				errObtener: tt.mockErr,
 			}
-			service := NewService(mockRepo)
+			service, generator := servicioPrueba(t, mockRepo)
+			if tt.licencia != nil && !tt.sinFirmar {
+				firmarFila(t, generator, tt.licencia)
+			}
 
 			info, err := service.ObtenerInfoLicencia()
 
@@ -239,8 +410,13 @@ func TestObtenerInfoLicencia(t *testing.T) {
 				t.Error("ObtenerInfoLicencia() mensaje should not be empty")
 			}
 
+			// Las fechas de una fila sin licencia firmada no se informan.
+			if tt.sinFirmar && (!info.FechaExpiracion.IsZero() || info.DiasRestantes != 0) {
+				t.Errorf("ObtenerInfoLicencia() = %v, %d días; want sin fechas", info.FechaExpiracion, info.DiasRestantes)
+			}
+
 			// Verificar días restantes
-			if tt.licencia != nil {
+			if tt.licencia != nil && !tt.sinFirmar {
 				expectedDays := int(tt.licencia.FechaExpiracion.Sub(now).Hours() / 24)
 				// Permitir margen de error de 1 día por redondeo
 				if abs(info.DiasRestantes-expectedDays) > 1 {
@@ -252,24 +428,139 @@ func TestObtenerInfoLicencia(t *testing.T) {
 	}
 }
 
+func TestObtenerInfoLicencia_Cobertura(t *testing.T) {
+	expiracion := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name          string
+		compilacion   time.Time
+		wantCobertura models.CoberturaActualizacion
+	}{
+		{"fecha desconocida", time.Time{}, ""},
+		{"publicada antes del vencimiento", time.Date(2025, 11, 2, 0, 0, 0, 0, time.UTC), models.VersionCubierta},
+		{"publicada el día del vencimiento", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), models.VersionCubierta},
+		{"publicada después del vencimiento", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), models.VersionNoCubierta},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			licencia := &models.Licencia{
+				FechaActivacion: expiracion.AddDate(-1, 0, 0),
+				FechaExpiracion: expiracion,
+				Activa:          true,
+			}
+			service, generator := servicioPrueba(t, &MockLicenseRepo{licencia: licencia})
+			firmarFila(t, generator, licencia)
+			service.compilacion = tt.compilacion
+
+			info, err := service.ObtenerInfoLicencia()
+			if err != nil {
+				t.Fatalf("ObtenerInfoLicencia() failed: %v", err)
+			}
+			if info.Cobertura != tt.wantCobertura {
+				t.Errorf("Cobertura = %q, want %q", info.Cobertura, tt.wantCobertura)
+			}
+			if tt.wantCobertura == models.VersionNoCubierta && !containsStr(info.Mensaje, "renueve") {
+				t.Errorf("Mensaje = %q, should ask to renew", info.Mensaje)
+			}
+			// La versión no cubierta se sigue usando: el estado no cambia.
+			if info.Estado == models.LicenciaNoConfigurada {
+				t.Errorf("Estado = %v, want el de la licencia", info.Estado)
+			}
+		})
+	}
+
+	FechaCompilacion = "2025-06-01"
+	defer func() { FechaCompilacion = "" }()
+	if got := fechaCompilacion(); !got.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("fechaCompilacion() = %v, want 2025-06-01", got)
+	}
+}
+
+func TestTieneFeature(t *testing.T) {
+	hasta := time.Now().AddDate(1, 0, 0)
+	basica := claimsPrueba(hasta)
+	basica.Edicion = models.EdicionBasica
+	conReportes := basica
+	conReportes.Adicionales = []models.Feature{models.FeatureReportes}
+
+	tests := []struct {
+		name   string
+		claims *Claims
+		huella string
+		// sinFirma, si no está vacía, es la clave de una fila que no verifica.
+		sinFirma string
+		want     map[models.Feature]bool
+	}{
+		{"sin licencia (prueba)", nil, huellaPrueba, "",
+			map[models.Feature]bool{models.FeatureMultiProfesional: false, models.FeatureMensajeria: false, models.FeatureReportes: false}},
+		{"formato anterior", nil, huellaPrueba, "YOY2025-9AE6-67EA",
+			map[models.Feature]bool{models.FeatureMultiProfesional: false, models.FeatureMensajeria: false, models.FeatureReportes: false}},
+		{"pro", &Claims{}, huellaPrueba, "",
+			map[models.Feature]bool{models.FeatureMultiProfesional: true, models.FeatureMensajeria: true, models.FeatureReportes: true}},
+		{"básica", &basica, huellaPrueba, "",
+			map[models.Feature]bool{models.FeatureMultiProfesional: false, models.FeatureMensajeria: false, models.FeatureReportes: false}},
+		{"básica con reportes", &conReportes, huellaPrueba, "",
+			map[models.Feature]bool{models.FeatureMultiProfesional: false, models.FeatureMensajeria: false, models.FeatureReportes: true}},
+		{"pro activada en otra máquina", &Claims{}, "ZZZZ-ZZZZ-ZZZZ-ZZZZ", "",
+			map[models.Feature]bool{models.FeatureMultiProfesional: false, models.FeatureMensajeria: false, models.FeatureReportes: false}},
+		{"clave sin firma", nil, huellaPrueba, "cualquier-cosa",
+			map[models.Feature]bool{models.FeatureMultiProfesional: false, models.FeatureMensajeria: false, models.FeatureReportes: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockLicenseRepo{}
+			service, generator := servicioPrueba(t, mockRepo)
+			switch {
+			case tt.sinFirma != "":
+				mockRepo.licencia = &models.Licencia{LicenseKey: tt.sinFirma, FechaExpiracion: hasta, Activa: true}
+			case tt.claims != nil:
+				claims := *tt.claims
+				if claims.ID == "" {
+					claims = claimsPrueba(hasta)
+				}
+				mockRepo.licencia = &models.Licencia{
+					LicenseKey:      licenciaPrueba(t, generator, claims),
+					FechaExpiracion: hasta,
+					Activa:          true,
+					Activacion:      activacionPrueba(t, generator, claims.ID, tt.huella),
+				}
+			}
+
+			for f, want := range tt.want {
+				if got := service.TieneFeature(f); got != want {
+					t.Errorf("TieneFeature(%s) = %v, want %v", f, got, want)
+				}
+			}
+		})
+	}
+}
+
 func TestRequiereActivacion(t *testing.T) {
 	tests := []struct {
 		name     string
 		licencia *models.Licencia
+		sinFirma bool
+		prueba   string
 		mockErr  error
 		want     bool
 		wantErr  bool
 	}{
 		{
-			name:     "Sin licencia - requiere activación",
+			name:     "Sin licencia - en período de prueba",
+			licencia: nil,
+			want:     false,
+			wantErr:  false,
+		},
+		{
+			name:     "Sin licencia - prueba vencida",
 			licencia: nil,
+			prueba:   marcaPrueba(time.Now().AddDate(0, 0, -DiasPrueba-1), huellaPrueba),
 			want:     true,
 			wantErr:  false,
 		},
 		{
 			name: "Con licencia - no requiere activación",
 			licencia: &models.Licencia{
-				LicenseKey:      "YOY2025-TEST-TEST",
 				FechaActivacion: time.Now(),
 				FechaExpiracion: time.Now().AddDate(1, 0, 0),
 				Activa:          true,
@@ -277,6 +568,26 @@ func TestRequiereActivacion(t *testing.T) {
 			want:    false,
 			wantErr: false,
 		},
+		{
+			name: "Formato anterior",
+			licencia: &models.Licencia{
+				LicenseKey:      "YOY2025-9AE6-67EA",
+				FechaExpiracion: time.Now().AddDate(1, 0, 0),
+				Activa:          true,
+			},
+			sinFirma: true,
+			want:     true,
+		},
+		{
+			name: "Clave inválida",
+			licencia: &models.Licencia{
+				LicenseKey:      "cualquier-cosa",
+				FechaExpiracion: time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC),
+				Activa:          true,
+			},
+			sinFirma: true,
+			want:     true,
+		},
 		{
 			name:     "Error al obtener",
 			licencia: nil,
@@ -290,9 +601,13 @@ func TestRequiereActivacion(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := &MockLicenseRepo{
 				licencia:   tt.licencia,
+				prueba:     tt.prueba,
 				errObtener: tt.mockErr,
 			}
-			service := NewService(mockRepo)
+			service, generator := servicioPrueba(t, mockRepo)
+			if tt.licencia != nil && !tt.sinFirma {
+				firmarFila(t, generator, tt.licencia)
+			}
 
 			requiere, err := service.RequiereActivacion()
 
@@ -315,6 +630,68 @@ func TestRequiereActivacion(t *testing.T) {
 	}
 }
 
+func TestValidarLicencia_Renovacion(t *testing.T) {
+	hoy := time.Now().UTC().Truncate(24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		expiracion time.Time
+		want       time.Time
+	}{
+		// Los días que quedaban se conservan.
+		{"anticipada", hoy.AddDate(0, 0, 100), hoy.AddDate(0, 0, 100).AddDate(0, 12, 0)},
+		// Vencida: el período cuenta desde hoy.
+		{"vencida", hoy.AddDate(0, 0, -30), hoy.AddDate(0, 12, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockLicenseRepo{}
+			service, generator := servicioPrueba(t, mockRepo)
+
+			primera := claimsPrueba(tt.expiracion)
+			primera.Emision = tt.expiracion.AddDate(-1, 0, 0)
+			key := licenciaPrueba(t, generator, primera)
+			if _, err := service.ValidarLicencia(key, activacionPrueba(t, generator, primera.ID, huellaPrueba)); err != nil {
+				t.Fatalf("ValidarLicencia() failed: %v", err)
+			}
+			activada := mockRepo.licencia.FechaActivacion
+
+			renovacion := claimsPrueba(hoy)
+			renovacion.ID = "lic-0002"
+			renovacion.Emision = hoy
+			renovada := licenciaPrueba(t, generator, renovacion)
+			activacion := activacionPrueba(t, generator, renovacion.ID, huellaPrueba)
+			info, err := service.ValidarLicencia(renovada, activacion)
+			if err != nil {
+				t.Fatalf("ValidarLicencia() renovación failed: %v", err)
+			}
+
+			if !info.FechaExpiracion.Truncate(24 * time.Hour).Equal(tt.want) {
+				t.Errorf("FechaExpiracion = %v, want %v", info.FechaExpiracion, tt.want)
+			}
+			if !info.FechaActivacion.Equal(activada) {
+				t.Errorf("FechaActivacion = %v, want la de la primera activación %v", info.FechaActivacion, activada)
+			}
+
+			historial, err := service.HistorialLicencias()
+			if err != nil || len(historial) != 2 {
+				t.Fatalf("HistorialLicencias() = %d, %v; want 2", len(historial), err)
+			}
+			if historial[0].LicenciaID != "lic-0002" || historial[0].ExpiracionAnterior == nil ||
+				!historial[0].ExpiracionAnterior.Equal(tt.expiracion) {
+				t.Errorf("historial[0] = %+v, want renovación desde %v", historial[0], tt.expiracion)
+			}
+
+			// Reingresar una licencia ya aplicada no vuelve a extender el período.
+			for _, k := range []string{renovada, key} {
+				if _, err := service.ValidarLicencia(k, activacion); err == nil || !containsStr(err.Error(), "ya se aplicó") {
+					t.Errorf("ValidarLicencia() reingreso error = %v, want 'ya se aplicó'", err)
+				}
+			}
+		})
+	}
+}
+
 func TestValidarLicencia_Integration(t *testing.T) {
 	// Test de integración que usa el generador real
 	tempDir := t.TempDir()
@@ -325,14 +702,27 @@ func TestValidarLicencia_Integration(t *testing.T) {
 	defer database.Close()
 
 	repo := db.NewLicenseRepo(database)
-	service := NewService(repo)
+	service, generator := servicioPrueba(t, repo)
 
 	// Generar licencia válida
-	generator := NewGenerator("yoyaku_secret_2024")
-	validKey := generator.GenerateLicenseKey(2025)
+	validKey := licenciaPrueba(t, generator, claimsPrueba(time.Now().AddDate(1, 0, 0)))
+
+	// Solicitar la activación y responderla como lo haría el emisor
+	codigo, err := service.SolicitarActivacion(validKey)
+	if err != nil {
+		t.Fatalf("SolicitarActivacion() failed: %v", err)
+	}
+	solicitud, err := LeerSolicitud(codigo)
+	if err != nil {
+		t.Fatalf("LeerSolicitud() failed: %v", err)
+	}
+	if solicitud.LicenciaID != "lic-0001" || solicitud.ClienteID != "CLI-0001" || solicitud.Huella != huellaPrueba {
+		t.Errorf("solicitud = %+v, want licencia, cliente y huella de esta máquina", solicitud)
+	}
+	activacion := activacionPrueba(t, generator, solicitud.LicenciaID, solicitud.Huella)
 
 	// Validar y activar
-	info, err := service.ValidarLicencia(validKey)
+	info, err := service.ValidarLicencia(validKey, activacion)
 	if err != nil {
 		t.Fatalf("ValidarLicencia() failed: %v", err)
 	}
@@ -369,6 +759,28 @@ func TestValidarLicencia_Integration(t *testing.T) {
 		t.Error("Should not require activation after validation")
 	}
 
+	// La misma base copiada a otra máquina vuelve a pedir activación
+	service.huella = func() (string, error) { return "ZZZZ-ZZZZ-ZZZZ-ZZZZ", nil }
+	if requiere, err := service.RequiereActivacion(); err != nil || !requiere {
+		t.Errorf("RequiereActivacion() on another machine = %v, %v; want true", requiere, err)
+	}
+
+	// Reingresar la licencia vigente con la activación de la otra máquina la
+	// mueve sin extender el período.
+	if _, err := service.ValidarLicencia(validKey, activacionPrueba(t, generator, "lic-0001", "ZZZZ-ZZZZ-ZZZZ-ZZZZ")); err != nil {
+		t.Fatalf("ValidarLicencia() on another machine failed: %v", err)
+	}
+	if movida, err := repo.Obtener(); err != nil || !movida.FechaExpiracion.Equal(licencia.FechaExpiracion) {
+		t.Errorf("Obtener() after moving = %+v, %v; want la misma expiración", movida, err)
+	}
+	if _, err := service.ValidarLicencia(validKey, activacion); err == nil {
+		t.Error("ValidarLicencia() of an applied license on its machine should fail")
+	}
+	historial, err := service.HistorialLicencias()
+	if err != nil || len(historial) != 1 {
+		t.Errorf("HistorialLicencias() = %d, %v; want 1", len(historial), err)
+	}
+
 	// Obtener info de licencia
 	info2, err := service.ObtenerInfoLicencia()
 	if err != nil {
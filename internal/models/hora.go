@@ -0,0 +1,113 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hora es una hora del día con precisión de minutos. Se guarda y se
+// serializa como "HH:MM".
+type Hora int
+
+// minutosPorDia es el límite exclusivo de una Hora válida.
+const minutosPorDia = 24 * 60
+
+func NuevaHora(hora, minuto int) Hora {
+	return Hora(hora*60 + minuto)
+}
+
+// HoraDe devuelve la hora del día de t, en la zona de t.
+func HoraDe(t time.Time) Hora {
+	return NuevaHora(t.Hour(), t.Minute())
+}
+
+// ParsearHora interpreta "HH:MM" o "H:MM" en formato de 24 horas.
+func ParsearHora(s string) (Hora, error) {
+	s = strings.TrimSpace(s)
+	h, m, ok := strings.Cut(s, ":")
+	if !ok || len(m) != 2 || len(h) < 1 || len(h) > 2 {
+		return 0, fmt.Errorf("hora inválida %q, se espera HH:MM", s)
+	}
+	hora, errH := strconv.Atoi(h)
+	minuto, errM := strconv.Atoi(m)
+	if errH != nil || errM != nil || hora < 0 || hora > 23 || minuto < 0 || minuto > 59 {
+		return 0, fmt.Errorf("hora inválida %q, se espera HH:MM", s)
+	}
+	return NuevaHora(hora, minuto), nil
+}
+
+// Valida indica si la hora está entre 00:00 y 23:59.
+func (h Hora) Valida() bool {
+	return h >= 0 && h < minutosPorDia
+}
+
+func (h Hora) Horas() int {
+	return int(h) / 60
+}
+
+func (h Hora) Minutos() int {
+	return int(h) % 60
+}
+
+func (h Hora) String() string {
+	return fmt.Sprintf("%02d:%02d", h.Horas(), h.Minutos())
+}
+
+// EnFecha devuelve el instante de esta hora en el día de fecha, interpretado
+// en la zona indicada.
+func (h Hora) EnFecha(fecha time.Time, zona *time.Location) time.Time {
+	return time.Date(fecha.Year(), fecha.Month(), fecha.Day(), h.Horas(), h.Minutos(), 0, 0, zona)
+}
+
+func (h Hora) MarshalJSON() ([]byte, error) {
+	if !h.Valida() {
+		return nil, fmt.Errorf("hora fuera de rango: %d minutos", int(h))
+	}
+	return json.Marshal(h.String())
+}
+
+func (h *Hora) UnmarshalJSON(datos []byte) error {
+	var s string
+	if err := json.Unmarshal(datos, &s); err != nil {
+		return fmt.Errorf("hora inválida: %s", datos)
+	}
+	hora, err := ParsearHora(s)
+	if err != nil {
+		return err
+	}
+	*h = hora
+	return nil
+}
+
+// Value guarda la hora como texto "HH:MM", el formato histórico de la
+// columna.
+func (h Hora) Value() (driver.Value, error) {
+	if !h.Valida() {
+		return nil, fmt.Errorf("hora fuera de rango: %d minutos", int(h))
+	}
+	return h.String(), nil
+}
+
+// Scan lee la hora guardada y falla si no tiene el formato esperado, en lugar
+// de dejar un valor en cero.
+func (h *Hora) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("hora inválida en la base de datos: %v", src)
+	}
+	hora, err := ParsearHora(s)
+	if err != nil {
+		return fmt.Errorf("en la base de datos: %w", err)
+	}
+	*h = hora
+	return nil
+}
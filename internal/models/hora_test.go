@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParsearHora(t *testing.T) {
+	validas := map[string]Hora{
+		"09:30": NuevaHora(9, 30),
+		"9:05":  NuevaHora(9, 5),
+		"00:00": 0,
+		"23:59": NuevaHora(23, 59),
+	}
+	for texto, want := range validas {
+		got, err := ParsearHora(texto)
+		if err != nil || got != want {
+			t.Errorf("ParsearHora(%q) = %v, %v; want %v", texto, got, err, want)
+		}
+	}
+
+	for _, texto := range []string{"", "mañana", "24:00", "9:5", "10:60", "-1:00", "123:00"} {
+		if _, err := ParsearHora(texto); err == nil {
+			t.Errorf("ParsearHora(%q) should fail", texto)
+		}
+	}
+}
+
+func TestHora_JSON(t *testing.T) {
+	datos, err := json.Marshal(NuevaHora(8, 5))
+	if err != nil || string(datos) != `"08:05"` {
+		t.Errorf("Marshal = %s, %v; want \"08:05\"", datos, err)
+	}
+
+	var h Hora
+	if err := json.Unmarshal([]byte(`"17:45"`), &h); err != nil || h != NuevaHora(17, 45) {
+		t.Errorf("Unmarshal = %v, %v; want 17:45", h, err)
+	}
+	if err := json.Unmarshal([]byte(`"25:00"`), &h); err == nil {
+		t.Error("Unmarshal de hora inválida should fail")
+	}
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Paciente struct {
 	ID        int64     `json:"id"`
@@ -12,29 +16,137 @@ type Paciente struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+type ResultadoBusquedaPacientes struct {
+	Pacientes []Paciente `json:"pacientes"`
+	Total     int        `json:"total"`
+	Pagina    int        `json:"pagina"`
+	PorPagina int        `json:"porPagina"`
+}
+
+type MapeoImportacion struct {
+	Nombre   string `json:"nombre"`
+	Telefono string `json:"telefono"`
+	Email    string `json:"email,omitempty"`
+	Notas    string `json:"notas,omitempty"`
+}
+
+type ArchivoImportacion struct {
+	Ruta          string           `json:"ruta"`
+	Encabezados   []string         `json:"encabezados"`
+	TotalFilas    int              `json:"totalFilas"`
+	MapeoSugerido MapeoImportacion `json:"mapeoSugerido"`
+}
+
+type EstadoFilaImportacion string
+
+const (
+	FilaNueva     EstadoFilaImportacion = "nueva"
+	FilaDuplicada EstadoFilaImportacion = "duplicada"
+	FilaInvalida  EstadoFilaImportacion = "invalida"
+	// FilaPosibleDuplicado se importa como nueva, pero su teléfono se parece
+	// al de otro paciente y conviene revisarla.
+	FilaPosibleDuplicado EstadoFilaImportacion = "posible_duplicado"
+)
+
+type FilaImportacion struct {
+	Fila          int                   `json:"fila"`
+	Paciente      Paciente              `json:"paciente"`
+	Estado        EstadoFilaImportacion `json:"estado"`
+	Mensaje       string                `json:"mensaje,omitempty"`
+	DuplicadoDeID int64                 `json:"duplicadoDeId,omitempty"`
+}
+
+type ReporteImportacion struct {
+	Archivo    string            `json:"archivo"`
+	Simulacion bool              `json:"simulacion"`
+	Filas      []FilaImportacion `json:"filas"`
+	Nuevos     int               `json:"nuevos"`
+	Duplicados int               `json:"duplicados"`
+	Invalidos  int               `json:"invalidos"`
+	Importados int               `json:"importados"`
+	// PosiblesDuplicados cuenta las filas nuevas marcadas como
+	// FilaPosibleDuplicado; también están incluidas en Nuevos.
+	PosiblesDuplicados int `json:"posiblesDuplicados"`
+}
+
+type EstadoTurnoImportado string
+
+const (
+	TurnoImportadoNuevo     EstadoTurnoImportado = "nuevo"
+	TurnoImportadoDuplicado EstadoTurnoImportado = "duplicado"
+	TurnoImportadoConflicto EstadoTurnoImportado = "conflicto"
+	TurnoImportadoInvalido  EstadoTurnoImportado = "invalido"
+)
+
+type TurnoImportado struct {
+	UID            string               `json:"uid"`
+	Resumen        string               `json:"resumen"`
+	Turno          Turno                `json:"turno"`
+	PacienteNuevo  bool                 `json:"pacienteNuevo"`
+	Estado         EstadoTurnoImportado `json:"estado"`
+	Mensaje        string               `json:"mensaje,omitempty"`
+	ConflictoConID int64                `json:"conflictoConId,omitempty"`
+}
+
+type ReporteImportacionICS struct {
+	Archivo         string           `json:"archivo"`
+	Simulacion      bool             `json:"simulacion"`
+	Eventos         int              `json:"eventos"`
+	Turnos          []TurnoImportado `json:"turnos"`
+	Nuevos          int              `json:"nuevos"`
+	Duplicados      int              `json:"duplicados"`
+	Conflictos      int              `json:"conflictos"`
+	Invalidos       int              `json:"invalidos"`
+	PacientesNuevos int              `json:"pacientesNuevos"`
+	Importados      int              `json:"importados"`
+}
+
 type EstadoTurno string
 
 const (
 	EstadoConfirmado EstadoTurno = "confirmado"
 	EstadoPendiente  EstadoTurno = "pendiente"
+	EstadoEnEspera   EstadoTurno = "en_sala_espera"
+	EstadoEnConsulta EstadoTurno = "en_consulta"
 	EstadoAtendido   EstadoTurno = "atendido"
 	EstadoAusente    EstadoTurno = "ausente"
 	EstadoCancelado  EstadoTurno = "cancelado"
 )
 
 type Turno struct {
-	ID           int64       `json:"id"`
-	PacienteID   int64       `json:"pacienteId"`
-	Paciente     *Paciente   `json:"paciente,omitempty"`
-	Fecha        time.Time   `json:"fecha"`
-	Hora         string      `json:"hora"`
-	Duracion     int         `json:"duracion"`
-	Motivo       string      `json:"motivo"`
-	Estado       EstadoTurno `json:"estado"`
-	Notas        string      `json:"notas,omitempty"`
-	RiesgoNoShow bool        `json:"riesgoNoShow"`
-	CreatedAt    time.Time   `json:"createdAt"`
-	UpdatedAt    time.Time   `json:"updatedAt"`
+	ID            int64       `json:"id"`
+	PacienteID    int64       `json:"pacienteId"`
+	Paciente      *Paciente   `json:"paciente,omitempty"`
+	ProfesionalID int64       `json:"profesionalId"`
+	Fecha         time.Time   `json:"fecha"`
+	Hora          Hora        `json:"hora"`
+	Duracion      int         `json:"duracion"`
+	Motivo        string      `json:"motivo"`
+	Estado        EstadoTurno `json:"estado"`
+	Notas         string      `json:"notas,omitempty"`
+	HoraLlegada   *time.Time  `json:"horaLlegada,omitempty"`
+	HoraInicio    *time.Time  `json:"horaInicio,omitempty"`
+	HoraFin       *time.Time  `json:"horaFin,omitempty"`
+	RiesgoNoShow  bool        `json:"riesgoNoShow"`
+	// InicioEstimado y DemoraEstimada (en minutos) proyectan cuándo será
+	// atendido un turno pendiente del día.
+	InicioEstimado *time.Time `json:"inicioEstimado,omitempty"`
+	DemoraEstimada int        `json:"demoraEstimada,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// ProfesionalPrincipalID es el profesional asignado a los turnos que no
+// indican otro.
+const ProfesionalPrincipalID int64 = 1
+
+type Profesional struct {
+	ID           int64     `json:"id"`
+	Nombre       string    `json:"nombre"`
+	Especialidad string    `json:"especialidad,omitempty"`
+	Activo       bool      `json:"activo"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
 type HistorialNoShow struct {
@@ -45,6 +157,176 @@ type HistorialNoShow struct {
 	CreatedAt  time.Time `json:"createdAt"`
 }
 
+type EstimacionTurno struct {
+	TurnoID        int64     `json:"turnoId"`
+	InicioEstimado time.Time `json:"inicioEstimado"`
+	DemoraMinutos  int       `json:"demoraMinutos"`
+}
+
+// ProyeccionAtraso es la demora actual del consultorio y el inicio estimado
+// de cada turno que falta atender.
+type ProyeccionAtraso struct {
+	AtrasoMinutos int               `json:"atrasoMinutos"`
+	Estimaciones  []EstimacionTurno `json:"estimaciones"`
+}
+
+// Feriado es un feriado nacional. Fecha es medianoche UTC del día.
+type Feriado struct {
+	Fecha  time.Time `json:"fecha"`
+	Nombre string    `json:"nombre"`
+}
+
+// Cierre es un período, de Desde a Hasta inclusive, en el que no se dan
+// turnos: vacaciones, congresos o cierres del consultorio. Con ProfesionalID
+// en cero alcanza a todos los profesionales.
+type Cierre struct {
+	ID            int64     `json:"id"`
+	ProfesionalID int64     `json:"profesionalId"`
+	Desde         time.Time `json:"desde"`
+	Hasta         time.Time `json:"hasta"`
+	Motivo        string    `json:"motivo"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// DiaCerrado es un día sin atención para un profesional, por un feriado o
+// por un cierre.
+type DiaCerrado struct {
+	Fecha    time.Time `json:"fecha"`
+	Motivo   string    `json:"motivo"`
+	Feriado  bool      `json:"feriado"`
+	CierreID int64     `json:"cierreId,omitempty"`
+}
+
+// ReporteCierre acompaña a un cierre recién creado con los turnos ya dados
+// que caen en él y hay que reprogramar.
+type ReporteCierre struct {
+	Cierre          *Cierre `json:"cierre"`
+	TurnosAfectados []Turno `json:"turnosAfectados"`
+}
+
+// HorarioLaboral es el horario en que se dan turnos: de Apertura a Cierre
+// los días de la semana indicados.
+type HorarioLaboral struct {
+	Apertura Hora           `json:"apertura"`
+	Cierre   Hora           `json:"cierre"`
+	Dias     []time.Weekday `json:"dias"`
+}
+
+// nombresDias son los días de la semana en el orden de time.Weekday.
+var nombresDias = [...]string{"Domingo", "Lunes", "Martes", "Miércoles", "Jueves", "Viernes", "Sábado"}
+
+// Descripcion resume el horario como texto, por ejemplo "Lunes a Viernes de
+// 9:00 a 18:00". Los días se listan de lunes a domingo y los consecutivos se
+// agrupan.
+func (h HorarioLaboral) Descripcion() string {
+	atiende := map[time.Weekday]bool{}
+	for _, d := range h.Dias {
+		atiende[d] = true
+	}
+
+	var tramos []string
+	for i := 0; i < 7; {
+		if !atiende[time.Weekday((i+1)%7)] {
+			i++
+			continue
+		}
+		fin := i
+		for fin+1 < 7 && atiende[time.Weekday((fin+2)%7)] {
+			fin++
+		}
+		desde, hasta := nombresDias[(i+1)%7], nombresDias[(fin+1)%7]
+		switch fin - i {
+		case 0:
+			tramos = append(tramos, desde)
+		case 1:
+			tramos = append(tramos, desde, hasta)
+		default:
+			tramos = append(tramos, desde+" a "+hasta)
+		}
+		i = fin + 1
+	}
+	if len(tramos) == 0 {
+		return "Sin días de atención"
+	}
+
+	dias := tramos[0]
+	if n := len(tramos); n > 1 {
+		dias = strings.Join(tramos[:n-1], ", ") + " y " + tramos[n-1]
+	}
+	return fmt.Sprintf("%s de %d:%02d a %d:%02d", dias,
+		h.Apertura.Horas(), h.Apertura.Minutos(), h.Cierre.Horas(), h.Cierre.Minutos())
+}
+
+// Atiende indica si el día de la semana de fecha es laborable.
+func (h HorarioLaboral) Atiende(fecha time.Time) bool {
+	for _, dia := range h.Dias {
+		if dia == fecha.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+type EstrategiaReprogramacion string
+
+const (
+	// ReprogramarAlDia mueve los turnos a FechaDestino, manteniendo la hora
+	// cuando está libre.
+	ReprogramarAlDia EstrategiaReprogramacion = "dia"
+	// ReprogramarProximosLibres ubica cada turno en el primer lugar libre a
+	// partir de FechaDestino, o del día siguiente al rango si no se indica.
+	ReprogramarProximosLibres EstrategiaReprogramacion = "proximos_libres"
+)
+
+// SolicitudReprogramacion pide mover los turnos activos de Desde a Hasta
+// inclusive. Con ProfesionalID en cero se mueven los de todos los
+// profesionales y Simular sólo devuelve el plan.
+type SolicitudReprogramacion struct {
+	ProfesionalID int64                    `json:"profesionalId"`
+	Desde         time.Time                `json:"desde"`
+	Hasta         time.Time                `json:"hasta"`
+	Estrategia    EstrategiaReprogramacion `json:"estrategia"`
+	FechaDestino  time.Time                `json:"fechaDestino"`
+	Simular       bool                     `json:"simular"`
+}
+
+// CambioTurno es el nuevo horario propuesto para un turno. Turno conserva
+// la fecha y hora originales.
+type CambioTurno struct {
+	Turno Turno     `json:"turno"`
+	Fecha time.Time `json:"fecha"`
+	Hora  Hora      `json:"hora"`
+}
+
+type PlanReprogramacion struct {
+	Cambios []CambioTurno `json:"cambios"`
+	// SinLugar son los turnos para los que no se encontró un horario libre.
+	SinLugar          []Turno `json:"sinLugar"`
+	Aplicado          bool    `json:"aplicado"`
+	MensajesEncolados int     `json:"mensajesEncolados"`
+}
+
+type EstadoMensaje string
+
+const (
+	MensajePendiente  EstadoMensaje = "pendiente"
+	MensajeEnviado    EstadoMensaje = "enviado"
+	MensajeDescartado EstadoMensaje = "descartado"
+)
+
+// Mensaje es un aviso a un paciente que queda en cola hasta que se envía
+// por WhatsApp desde la aplicación.
+type Mensaje struct {
+	ID         int64         `json:"id"`
+	PacienteID int64         `json:"pacienteId"`
+	TurnoID    int64         `json:"turnoId"`
+	Telefono   string        `json:"telefono"`
+	Texto      string        `json:"texto"`
+	Estado     EstadoMensaje `json:"estado"`
+	EnviadoAt  *time.Time    `json:"enviadoAt,omitempty"`
+	CreatedAt  time.Time     `json:"createdAt"`
+}
+
 type AgendaDia struct {
 	Fecha            string  `json:"fecha"`
 	Turnos           []Turno `json:"turnos"`
@@ -54,16 +336,71 @@ type AgendaDia struct {
 }
 
 type Configuracion struct {
-	ID                  int64     `json:"id"`
-	NombreConsultorio   string    `json:"nombreConsultorio"`
-	NombreMedico        string    `json:"nombreMedico"`
-	TelefonoConsultorio string    `json:"telefonoConsultorio"`
-	Direccion           string    `json:"direccion"`
-	MensajeConfirmacion string    `json:"mensajeConfirmacion"`
-	MensajeRecordatorio string    `json:"mensajeRecordatorio"`
-	MensajeDemora       string    `json:"mensajeDemora"`
-	HorarioAtencion     string    `json:"horarioAtencion"`
-	UpdatedAt           time.Time `json:"updatedAt"`
+	ID                    int64  `json:"id"`
+	NombreConsultorio     string `json:"nombreConsultorio"`
+	NombreMedico          string `json:"nombreMedico"`
+	TelefonoConsultorio   string `json:"telefonoConsultorio"`
+	Direccion             string `json:"direccion"`
+	MensajeConfirmacion   string `json:"mensajeConfirmacion"`
+	MensajeRecordatorio   string `json:"mensajeRecordatorio"`
+	MensajeDemora         string `json:"mensajeDemora"`
+	MensajeReprogramacion string `json:"mensajeReprogramacion"`
+	// HorarioAtencion es la Descripcion del HorarioLaboral, que es el que
+	// se configura; al guardar se ignora.
+	HorarioAtencion string    `json:"horarioAtencion"`
+	ZonaHoraria     string    `json:"zonaHoraria"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// ZonaHorariaPorDefecto es la zona horaria del consultorio hasta que se
+// configure otra. Fecha y Hora de los turnos se interpretan en ella.
+const ZonaHorariaPorDefecto = "America/Argentina/Buenos_Aires"
+
+type ConfiguracionServidor struct {
+	Habilitado    bool `json:"habilitado"`
+	Puerto        int  `json:"puerto"`
+	APIHabilitada bool `json:"apiHabilitada"`
+}
+
+type ModoPuesto string
+
+const (
+	PuestoIndependiente ModoPuesto = "independiente"
+	PuestoAnfitrion     ModoPuesto = "anfitrion"
+	PuestoCliente       ModoPuesto = "cliente"
+)
+
+// ConfiguracionPuesto indica si esta instancia usa su propia base de datos,
+// la comparte con otros puestos de la red o usa la de otro puesto.
+type ConfiguracionPuesto struct {
+	Modo               ModoPuesto `json:"modo"`
+	DireccionAnfitrion string     `json:"direccionAnfitrion,omitempty"`
+	Clave              string     `json:"clave,omitempty"`
+}
+
+// AnfitrionDescubierto es un puesto anfitrión encontrado en la red local.
+type AnfitrionDescubierto struct {
+	Nombre    string `json:"nombre"`
+	Direccion string `json:"direccion"`
+}
+
+type TokenAPI struct {
+	ID        int64      `json:"id"`
+	Nombre    string     `json:"nombre"`
+	Prefijo   string     `json:"prefijo"`
+	Token     string     `json:"token,omitempty"`
+	UltimoUso *time.Time `json:"ultimoUso,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type FeedCalendario struct {
+	ID            int64     `json:"id"`
+	ProfesionalID int64     `json:"profesionalId"`
+	Token         string    `json:"token"`
+	Descripcion   string    `json:"descripcion,omitempty"`
+	Redactar      bool      `json:"redactar"`
+	URLs          []string  `json:"urls,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
 }
 
 type Licencia struct {
@@ -73,6 +410,22 @@ type Licencia struct {
 	FechaExpiracion time.Time `json:"fechaExpiracion"`
 	Activa          bool      `json:"activa"`
 	Version         string    `json:"version"`
+	// Activacion es el código firmado que liga la licencia a esta máquina.
+	Activacion string `json:"activacion,omitempty"`
+}
+
+// LicenciaAplicada registra cada licencia ingresada en esta instalación y
+// cómo cambió el vencimiento del período de actualizaciones.
+type LicenciaAplicada struct {
+	ID                 int64      `json:"id"`
+	LicenciaID         string     `json:"licenciaId"`
+	LicenseKey         string     `json:"licenseKey"`
+	ClienteID          string     `json:"clienteId"`
+	Edicion            Edicion    `json:"edicion"`
+	Meses              int        `json:"meses"`
+	ExpiracionAnterior *time.Time `json:"expiracionAnterior,omitempty"`
+	ExpiracionNueva    time.Time  `json:"expiracionNueva"`
+	AplicadaAt         time.Time  `json:"aplicadaAt"`
 }
 
 type EstadoLicencia string
@@ -81,12 +434,98 @@ const (
 	LicenciaActiva        EstadoLicencia = "activa"
 	LicenciaExpirada      EstadoLicencia = "expirada"
 	LicenciaNoConfigurada EstadoLicencia = "no_configurada"
+	// La licencia figura en la lista de revocación.
+	LicenciaRevocada EstadoLicencia = "revocada"
+	// Las fechas guardadas no coinciden con las licencias firmadas.
+	LicenciaAlterada EstadoLicencia = "alterada"
+	// El reloj del sistema está antes del último uso registrado.
+	LicenciaRelojAtrasado EstadoLicencia = "reloj_atrasado"
+	// Sin licencia, durante los primeros días de uso y al terminarlos.
+	LicenciaPrueba        EstadoLicencia = "prueba"
+	LicenciaPruebaVencida EstadoLicencia = "prueba_vencida"
 )
 
+// ListaRevocacion enumera las licencias revocadas por el emisor. Numero crece
+// con cada lista nueva, así que nunca se reemplaza una por otra anterior.
+type ListaRevocacion struct {
+	Numero    int       `json:"numero"`
+	Fecha     time.Time `json:"fecha"`
+	Licencias []string  `json:"licencias"`
+}
+
+// Incluye indica si la licencia id está revocada.
+func (l *ListaRevocacion) Incluye(id string) bool {
+	if l == nil {
+		return false
+	}
+	for _, revocada := range l.Licencias {
+		if revocada == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CoberturaActualizacion indica si esta versión de la aplicación se publicó
+// dentro del período de actualizaciones de la licencia. Nunca impide usarla.
+type CoberturaActualizacion string
+
+const (
+	VersionCubierta   CoberturaActualizacion = "cubierta"
+	VersionNoCubierta CoberturaActualizacion = "no_cubierta"
+)
+
+// Edicion es la variante del producto que habilita una licencia.
+type Edicion string
+
+const (
+	EdicionBasica Edicion = "basica"
+	EdicionPro    Edicion = "pro"
+)
+
+// Feature es una función de la aplicación que no incluyen todas las
+// ediciones.
+type Feature string
+
+const (
+	FeatureMultiProfesional Feature = "multi_profesional"
+	FeatureMensajeria       Feature = "mensajeria"
+	FeatureReportes         Feature = "reportes"
+)
+
+// Nombre devuelve la descripción de la función para los mensajes al usuario.
+func (f Feature) Nombre() string {
+	switch f {
+	case FeatureMultiProfesional:
+		return "La agenda de varios profesionales"
+	case FeatureMensajeria:
+		return "El envío de mensajes a pacientes"
+	case FeatureReportes:
+		return "Los reportes"
+	}
+	return string(f)
+}
+
+// FeatureNoIncluidaError indica que la licencia no incluye una función.
+type FeatureNoIncluidaError struct {
+	Feature Feature
+}
+
+func (e *FeatureNoIncluidaError) Error() string {
+	return e.Feature.Nombre() + " no está incluida en su licencia. Disponible en la edición Pro."
+}
+
 type InfoLicencia struct {
 	Estado          EstadoLicencia `json:"estado"`
 	FechaActivacion time.Time      `json:"fechaActivacion,omitempty"`
 	FechaExpiracion time.Time      `json:"fechaExpiracion,omitempty"`
 	DiasRestantes   int            `json:"diasRestantes"`
-	Mensaje         string         `json:"mensaje"`
+	ClienteID       string         `json:"clienteId,omitempty"`
+	Edicion         Edicion        `json:"edicion,omitempty"`
+	// Features son las funciones que habilita la licencia, o la prueba.
+	Features []Feature `json:"features"`
+	// Cobertura queda vacía si no se conoce la fecha de publicación.
+	Cobertura        CoberturaActualizacion `json:"cobertura,omitempty"`
+	FechaCompilacion *time.Time             `json:"fechaCompilacion,omitempty"`
+	Mensaje          string                 `json:"mensaje"`
 }
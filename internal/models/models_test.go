@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHorarioLaboral_Descripcion(t *testing.T) {
+	tests := []struct {
+		dias []time.Weekday
+		want string
+	}{
+		{[]time.Weekday{1, 2, 3, 4, 5}, "Lunes a Viernes de 9:00 a 18:30"},
+		{[]time.Weekday{5, 3, 1}, "Lunes, Miércoles y Viernes de 9:00 a 18:30"},
+		{[]time.Weekday{1, 2, 4, 5, 6}, "Lunes, Martes y Jueves a Sábado de 9:00 a 18:30"},
+		{[]time.Weekday{0, 1, 2, 3, 4, 5, 6}, "Lunes a Domingo de 9:00 a 18:30"},
+		{[]time.Weekday{6, 0}, "Sábado y Domingo de 9:00 a 18:30"},
+		{nil, "Sin días de atención"},
+	}
+	for _, tt := range tests {
+		h := HorarioLaboral{Apertura: NuevaHora(9, 0), Cierre: NuevaHora(18, 30), Dias: tt.dias}
+		if got := h.Descripcion(); got != tt.want {
+			t.Errorf("Descripcion(%v) = %q, want %q", tt.dias, got, tt.want)
+		}
+	}
+}
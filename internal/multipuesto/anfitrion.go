@@ -0,0 +1,369 @@
+package multipuesto
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"yoyaku/internal/agenda"
+	"yoyaku/internal/models"
+)
+
+// Prefijo es la ruta base del protocolo entre puestos.
+const Prefijo = "/puesto/v1"
+
+// intervaloLatido es cada cuánto se envía un comentario por el stream de
+// eventos para que proxies y firewalls no corten la conexión ociosa.
+const intervaloLatido = 30 * time.Second
+
+// Parámetros de las operaciones. Los usan tanto el anfitrión como Remoto.
+type (
+	argsID struct {
+		ID int64 `json:"id"`
+	}
+	argsFecha struct {
+		Fecha time.Time `json:"fecha"`
+	}
+	argsEstado struct {
+		ID     int64              `json:"id"`
+		Estado models.EstadoTurno `json:"estado"`
+	}
+	argsBusqueda struct {
+		Termino   string `json:"termino"`
+		Pagina    int    `json:"pagina"`
+		PorPagina int    `json:"porPagina"`
+	}
+	argsMensajes struct {
+		ID     int64                `json:"id,omitempty"`
+		Estado models.EstadoMensaje `json:"estado"`
+	}
+	argsRango struct {
+		ProfesionalID int64     `json:"profesionalId,omitempty"`
+		Desde         time.Time `json:"desde"`
+		Hasta         time.Time `json:"hasta"`
+	}
+)
+
+type operacion func(cuerpo *json.Decoder) (interface{}, error)
+
+// Anfitrion publica un Backend Local para los puestos cliente. Cada
+// operación del Backend es un POST a Prefijo/{operacion} con los parámetros
+// en JSON, y los eventos de modificación se envían como server-sent events en
+// Prefijo/eventos.
+type Anfitrion struct {
+	local       *Local
+	operaciones map[string]operacion
+
+	habilitado atomic.Bool
+	mu         sync.RWMutex
+	clave      string
+}
+
+func NewAnfitrion(local *Local) *Anfitrion {
+	a := &Anfitrion{local: local}
+	a.operaciones = map[string]operacion{
+		"agenda": func(d *json.Decoder) (interface{}, error) {
+			var args argsFecha
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.AgendaDelDia(args.Fecha)
+		},
+		"sala_espera": func(d *json.Decoder) (interface{}, error) {
+			var args argsFecha
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.SalaDeEspera(args.Fecha)
+		},
+		"turno.obtener": func(d *json.Decoder) (interface{}, error) {
+			var args argsID
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.ObtenerTurno(args.ID)
+		},
+		"turno.crear": func(d *json.Decoder) (interface{}, error) {
+			var turno models.Turno
+			if err := d.Decode(&turno); err != nil {
+				return nil, err
+			}
+			return &turno, local.CrearTurno(&turno)
+		},
+		"turno.actualizar": func(d *json.Decoder) (interface{}, error) {
+			var turno models.Turno
+			if err := d.Decode(&turno); err != nil {
+				return nil, err
+			}
+			return &turno, local.ActualizarTurno(&turno)
+		},
+		"turno.eliminar": func(d *json.Decoder) (interface{}, error) {
+			var args argsID
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return nil, local.EliminarTurno(args.ID)
+		},
+		"turno.estado": func(d *json.Decoder) (interface{}, error) {
+			var args argsEstado
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return nil, local.CambiarEstadoTurno(args.ID, args.Estado)
+		},
+		"paciente.historial": func(d *json.Decoder) (interface{}, error) {
+			var args argsID
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.HistorialPaciente(args.ID)
+		},
+		"paciente.obtener": func(d *json.Decoder) (interface{}, error) {
+			var args argsID
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.ObtenerPaciente(args.ID)
+		},
+		"paciente.buscar": func(d *json.Decoder) (interface{}, error) {
+			var args argsBusqueda
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.BuscarPacientes(args.Termino, args.Pagina, args.PorPagina)
+		},
+		"paciente.crear": func(d *json.Decoder) (interface{}, error) {
+			var paciente models.Paciente
+			if err := d.Decode(&paciente); err != nil {
+				return nil, err
+			}
+			return &paciente, local.CrearPaciente(&paciente)
+		},
+		"paciente.actualizar": func(d *json.Decoder) (interface{}, error) {
+			var paciente models.Paciente
+			if err := d.Decode(&paciente); err != nil {
+				return nil, err
+			}
+			return &paciente, local.ActualizarPaciente(&paciente)
+		},
+		"paciente.eliminar": func(d *json.Decoder) (interface{}, error) {
+			var args argsID
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return nil, local.EliminarPaciente(args.ID)
+		},
+		"configuracion.obtener": func(d *json.Decoder) (interface{}, error) {
+			return local.ObtenerConfiguracion()
+		},
+		"configuracion.guardar": func(d *json.Decoder) (interface{}, error) {
+			var config models.Configuracion
+			if err := d.Decode(&config); err != nil {
+				return nil, err
+			}
+			return nil, local.GuardarConfiguracion(&config)
+		},
+		"profesional.listar": func(d *json.Decoder) (interface{}, error) {
+			return local.ListarProfesionales()
+		},
+		"profesional.crear": func(d *json.Decoder) (interface{}, error) {
+			var profesional models.Profesional
+			if err := d.Decode(&profesional); err != nil {
+				return nil, err
+			}
+			return &profesional, local.CrearProfesional(&profesional)
+		},
+		"profesional.actualizar": func(d *json.Decoder) (interface{}, error) {
+			var profesional models.Profesional
+			if err := d.Decode(&profesional); err != nil {
+				return nil, err
+			}
+			return &profesional, local.ActualizarProfesional(&profesional)
+		},
+		"dias_cerrados": func(d *json.Decoder) (interface{}, error) {
+			var args argsRango
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.DiasCerrados(args.ProfesionalID, args.Desde, args.Hasta)
+		},
+		"cierre.listar": func(d *json.Decoder) (interface{}, error) {
+			var args argsRango
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.ListarCierres(args.Desde, args.Hasta)
+		},
+		"cierre.crear": func(d *json.Decoder) (interface{}, error) {
+			var cierre models.Cierre
+			if err := d.Decode(&cierre); err != nil {
+				return nil, err
+			}
+			return local.CrearCierre(&cierre)
+		},
+		"cierre.eliminar": func(d *json.Decoder) (interface{}, error) {
+			var args argsID
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return nil, local.EliminarCierre(args.ID)
+		},
+		"cierre.afectados": func(d *json.Decoder) (interface{}, error) {
+			var args argsID
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.TurnosAfectados(args.ID)
+		},
+		"horario.obtener": func(d *json.Decoder) (interface{}, error) {
+			return local.ObtenerHorarioLaboral()
+		},
+		"horario.guardar": func(d *json.Decoder) (interface{}, error) {
+			var horario models.HorarioLaboral
+			if err := d.Decode(&horario); err != nil {
+				return nil, err
+			}
+			return nil, local.GuardarHorarioLaboral(&horario)
+		},
+		"turno.reprogramar": func(d *json.Decoder) (interface{}, error) {
+			var solicitud models.SolicitudReprogramacion
+			if err := d.Decode(&solicitud); err != nil {
+				return nil, err
+			}
+			return local.ReprogramarTurnos(solicitud)
+		},
+		"mensaje.listar": func(d *json.Decoder) (interface{}, error) {
+			var args argsMensajes
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return local.ListarMensajes(args.Estado)
+		},
+		"mensaje.estado": func(d *json.Decoder) (interface{}, error) {
+			var args argsMensajes
+			if err := d.Decode(&args); err != nil {
+				return nil, err
+			}
+			return nil, local.CambiarEstadoMensaje(args.ID, args.Estado)
+		},
+	}
+	return a
+}
+
+// Configurar habilita o deshabilita el acceso de los clientes y fija la clave
+// que deben presentar.
+func (a *Anfitrion) Configurar(habilitado bool, clave string) {
+	a.mu.Lock()
+	a.clave = clave
+	a.mu.Unlock()
+	a.habilitado.Store(habilitado && clave != "")
+}
+
+// Registrar agrega las rutas del anfitrión al mux del servidor.
+func (a *Anfitrion) Registrar(handle func(patron string, handler http.Handler)) {
+	handle("GET "+Prefijo+"/eventos", a.autenticado(http.HandlerFunc(a.eventos)))
+	handle("POST "+Prefijo+"/{operacion}", a.autenticado(http.HandlerFunc(a.ejecutar)))
+}
+
+func (a *Anfitrion) autenticado(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.habilitado.Load() {
+			http.NotFound(w, r)
+			return
+		}
+
+		a.mu.RLock()
+		clave := a.clave
+		a.mu.RUnlock()
+
+		recibida, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(recibida), []byte(clave)) != 1 {
+			escribirError(w, http.StatusUnauthorized, "clave de puesto incorrecta")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Anfitrion) ejecutar(w http.ResponseWriter, r *http.Request) {
+	op, ok := a.operaciones[r.PathValue("operacion")]
+	if !ok {
+		escribirError(w, http.StatusNotFound, "operación desconocida: "+r.PathValue("operacion"))
+		return
+	}
+
+	resultado, err := op(json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)))
+	if err != nil {
+		escribirError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(resultado)
+}
+
+// eventos mantiene abierta la conexión y reenvía cada evento del bus del
+// Backend Local como un server-sent event.
+func (a *Anfitrion) eventos(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		escribirError(w, http.StatusInternalServerError, "streaming no soportado")
+		return
+	}
+
+	cola := make(chan agenda.Evento, 64)
+	cancelar := a.local.Eventos().Suscribir(func(e agenda.Evento) {
+		select {
+		case cola <- e:
+		default:
+			// Cliente lento: se descarta el evento en lugar de frenar las
+			// escrituras del anfitrión.
+		}
+	})
+	defer cancelar()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": conectado\n\n")
+	flusher.Flush()
+
+	latido := time.NewTicker(intervaloLatido)
+	defer latido.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-latido.C:
+			fmt.Fprint(w, ": latido\n\n")
+		case e := <-cola:
+			datos, _ := json.Marshal(e)
+			fmt.Fprintf(w, "data: %s\n\n", datos)
+		}
+		flusher.Flush()
+	}
+}
+
+func escribirError(w http.ResponseWriter, status int, mensaje string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": mensaje})
+}
+
+// GenerarClave crea una clave aleatoria para emparejar los puestos cliente
+// con el anfitrión.
+func GenerarClave() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generando clave de puesto: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
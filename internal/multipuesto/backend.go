@@ -0,0 +1,62 @@
+// Package multipuesto permite que varias instancias de yoyaku en la red local
+// compartan una misma base de datos: un puesto anfitrión publica sus datos y
+// los puestos cliente los usan a través de un Backend remoto.
+package multipuesto
+
+import (
+	"time"
+
+	"yoyaku/internal/agenda"
+	"yoyaku/internal/models"
+)
+
+// Backend agrupa las operaciones de datos que usa la aplicación. Local las
+// resuelve contra la base SQLite de la máquina y Remoto contra el puesto
+// anfitrión.
+type Backend interface {
+	AgendaDelDia(fecha time.Time) (*models.AgendaDia, error)
+	SalaDeEspera(fecha time.Time) ([]models.Turno, error)
+	ObtenerTurno(id int64) (*models.Turno, error)
+	CrearTurno(turno *models.Turno) error
+	ActualizarTurno(turno *models.Turno) error
+	EliminarTurno(id int64) error
+	CambiarEstadoTurno(id int64, estado models.EstadoTurno) error
+	HistorialPaciente(pacienteID int64) ([]models.Turno, error)
+
+	ObtenerPaciente(id int64) (*models.Paciente, error)
+	BuscarPacientes(termino string, pagina, porPagina int) (*models.ResultadoBusquedaPacientes, error)
+	CrearPaciente(paciente *models.Paciente) error
+	ActualizarPaciente(paciente *models.Paciente) error
+	EliminarPaciente(id int64) error
+
+	ObtenerConfiguracion() (*models.Configuracion, error)
+	GuardarConfiguracion(config *models.Configuracion) error
+
+	ListarProfesionales() ([]models.Profesional, error)
+	CrearProfesional(profesional *models.Profesional) error
+	ActualizarProfesional(profesional *models.Profesional) error
+
+	DiasCerrados(profesionalID int64, desde, hasta time.Time) ([]models.DiaCerrado, error)
+	ListarCierres(desde, hasta time.Time) ([]models.Cierre, error)
+	CrearCierre(cierre *models.Cierre) (*models.ReporteCierre, error)
+	EliminarCierre(id int64) error
+	TurnosAfectados(cierreID int64) ([]models.Turno, error)
+
+	ObtenerHorarioLaboral() (*models.HorarioLaboral, error)
+	GuardarHorarioLaboral(horario *models.HorarioLaboral) error
+	ReprogramarTurnos(solicitud models.SolicitudReprogramacion) (*models.PlanReprogramacion, error)
+	ListarMensajes(estado models.EstadoMensaje) ([]models.Mensaje, error)
+	CambiarEstadoMensaje(id int64, estado models.EstadoMensaje) error
+}
+
+// Eventos que el paquete agrega a los de agenda. Se publican en el mismo
+// bus y llegan a los clientes por el mismo stream.
+const (
+	EventoConfiguracionActualizada agenda.TipoEvento = "configuracion:actualizada"
+	EventoProfesionalCreado        agenda.TipoEvento = "profesional:creado"
+	EventoProfesionalActualizado   agenda.TipoEvento = "profesional:actualizado"
+	EventoMensajesActualizados     agenda.TipoEvento = "mensajes:actualizados"
+	// EventoReconectado se emite en un puesto cliente al recuperar la
+	// conexión con el anfitrión, porque pudo perderse cualquier evento.
+	EventoReconectado agenda.TipoEvento = "puesto:reconectado"
+)
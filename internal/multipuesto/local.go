@@ -0,0 +1,178 @@
+package multipuesto
+
+import (
+	"time"
+
+	"yoyaku/internal/agenda"
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+// Local implementa Backend sobre la base de datos de esta máquina. Las
+// modificaciones se publican en el bus de eventos de agendaSvc.
+type Local struct {
+	configRepo      *db.ConfigRepo
+	profesionalRepo *db.ProfesionalRepo
+	turnoRepo       *db.TurnoRepo
+	pacienteRepo    *db.PacienteRepo
+	mensajeRepo     *db.MensajeRepo
+	agendaSvc       *agenda.Service
+}
+
+func NewLocal(database *db.DB, agendaSvc *agenda.Service) *Local {
+	return &Local{
+		configRepo:      db.NewConfigRepo(database),
+		profesionalRepo: db.NewProfesionalRepo(database),
+		turnoRepo:       db.NewTurnoRepo(database),
+		pacienteRepo:    db.NewPacienteRepo(database),
+		mensajeRepo:     db.NewMensajeRepo(database),
+		agendaSvc:       agendaSvc,
+	}
+}
+
+// Eventos devuelve el bus en el que se publican las modificaciones.
+func (l *Local) Eventos() *agenda.Bus {
+	return l.agendaSvc.Eventos()
+}
+
+func (l *Local) AgendaDelDia(fecha time.Time) (*models.AgendaDia, error) {
+	return l.agendaSvc.ObtenerAgendaDelDia(fecha)
+}
+
+func (l *Local) SalaDeEspera(fecha time.Time) ([]models.Turno, error) {
+	return l.agendaSvc.SalaDeEspera(fecha)
+}
+
+func (l *Local) ObtenerTurno(id int64) (*models.Turno, error) {
+	return l.turnoRepo.ObtenerPorID(id)
+}
+
+func (l *Local) CrearTurno(turno *models.Turno) error {
+	return l.agendaSvc.CrearTurno(turno)
+}
+
+func (l *Local) ActualizarTurno(turno *models.Turno) error {
+	return l.agendaSvc.ActualizarTurno(turno)
+}
+
+func (l *Local) EliminarTurno(id int64) error {
+	return l.agendaSvc.EliminarTurno(id)
+}
+
+func (l *Local) CambiarEstadoTurno(id int64, estado models.EstadoTurno) error {
+	return l.agendaSvc.CambiarEstado(id, estado)
+}
+
+func (l *Local) HistorialPaciente(pacienteID int64) ([]models.Turno, error) {
+	return l.turnoRepo.ListarPorPaciente(pacienteID)
+}
+
+func (l *Local) ObtenerPaciente(id int64) (*models.Paciente, error) {
+	return l.pacienteRepo.ObtenerPorID(id)
+}
+
+func (l *Local) BuscarPacientes(termino string, pagina, porPagina int) (*models.ResultadoBusquedaPacientes, error) {
+	return l.pacienteRepo.BuscarPaginado(termino, pagina, porPagina)
+}
+
+func (l *Local) CrearPaciente(paciente *models.Paciente) error {
+	return l.agendaSvc.CrearPaciente(paciente)
+}
+
+func (l *Local) ActualizarPaciente(paciente *models.Paciente) error {
+	return l.agendaSvc.ActualizarPaciente(paciente)
+}
+
+func (l *Local) EliminarPaciente(id int64) error {
+	return l.agendaSvc.EliminarPaciente(id)
+}
+
+func (l *Local) ObtenerConfiguracion() (*models.Configuracion, error) {
+	return l.configRepo.Obtener()
+}
+
+func (l *Local) GuardarConfiguracion(config *models.Configuracion) error {
+	if err := l.configRepo.Guardar(config); err != nil {
+		return err
+	}
+	l.Eventos().Publicar(agenda.Evento{Tipo: EventoConfiguracionActualizada})
+	return nil
+}
+
+func (l *Local) ListarProfesionales() ([]models.Profesional, error) {
+	return l.profesionalRepo.ListarTodos()
+}
+
+func (l *Local) CrearProfesional(profesional *models.Profesional) error {
+	if err := l.agendaSvc.VerificarFeature(models.FeatureMultiProfesional); err != nil {
+		return err
+	}
+	if err := l.profesionalRepo.Crear(profesional); err != nil {
+		return err
+	}
+	l.Eventos().Publicar(agenda.Evento{Tipo: EventoProfesionalCreado})
+	return nil
+}
+
+func (l *Local) ActualizarProfesional(profesional *models.Profesional) error {
+	if err := l.profesionalRepo.Actualizar(profesional); err != nil {
+		return err
+	}
+	l.Eventos().Publicar(agenda.Evento{Tipo: EventoProfesionalActualizado})
+	return nil
+}
+
+func (l *Local) DiasCerrados(profesionalID int64, desde, hasta time.Time) ([]models.DiaCerrado, error) {
+	return l.agendaSvc.DiasCerrados(profesionalID, desde, hasta)
+}
+
+func (l *Local) ListarCierres(desde, hasta time.Time) ([]models.Cierre, error) {
+	return l.agendaSvc.ListarCierres(desde, hasta)
+}
+
+func (l *Local) CrearCierre(cierre *models.Cierre) (*models.ReporteCierre, error) {
+	return l.agendaSvc.CrearCierre(cierre)
+}
+
+func (l *Local) EliminarCierre(id int64) error {
+	return l.agendaSvc.EliminarCierre(id)
+}
+
+func (l *Local) TurnosAfectados(cierreID int64) ([]models.Turno, error) {
+	return l.agendaSvc.TurnosAfectados(cierreID)
+}
+
+func (l *Local) ObtenerHorarioLaboral() (*models.HorarioLaboral, error) {
+	return l.configRepo.ObtenerHorarioLaboral()
+}
+
+func (l *Local) GuardarHorarioLaboral(horario *models.HorarioLaboral) error {
+	if err := l.configRepo.GuardarHorarioLaboral(horario); err != nil {
+		return err
+	}
+	l.Eventos().Publicar(agenda.Evento{Tipo: EventoConfiguracionActualizada})
+	return nil
+}
+
+func (l *Local) ReprogramarTurnos(solicitud models.SolicitudReprogramacion) (*models.PlanReprogramacion, error) {
+	plan, err := l.agendaSvc.ReprogramarTurnos(solicitud)
+	if err != nil {
+		return nil, err
+	}
+	if plan.MensajesEncolados > 0 {
+		l.Eventos().Publicar(agenda.Evento{Tipo: EventoMensajesActualizados})
+	}
+	return plan, nil
+}
+
+func (l *Local) ListarMensajes(estado models.EstadoMensaje) ([]models.Mensaje, error) {
+	return l.mensajeRepo.ListarPorEstado(estado)
+}
+
+func (l *Local) CambiarEstadoMensaje(id int64, estado models.EstadoMensaje) error {
+	if err := l.mensajeRepo.CambiarEstado(id, estado); err != nil {
+		return err
+	}
+	l.Eventos().Publicar(agenda.Evento{Tipo: EventoMensajesActualizados})
+	return nil
+}
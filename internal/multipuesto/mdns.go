@@ -0,0 +1,461 @@
+package multipuesto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"yoyaku/internal/models"
+	"yoyaku/internal/server"
+)
+
+// Implementación mínima de mDNS/DNS-SD (RFC 6762 y 6763), suficiente para
+// anunciar el servicio _yoyaku._tcp y encontrarlo desde otro puesto.
+
+const servicioMDNS = "_yoyaku._tcp.local."
+
+const (
+	tipoA   = 1
+	tipoPTR = 12
+	tipoTXT = 16
+	tipoSRV = 33
+	tipoANY = 255
+
+	claseIN = 1
+	// bitUnicast es el bit alto de la clase: en una pregunta pide respuesta
+	// unicast y en un registro indica cache-flush.
+	bitUnicast    = 0x8000
+	flagRespuesta = 0x8400
+
+	ttlMDNS = 120
+)
+
+var grupoMDNS = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+type pregunta struct {
+	nombre string
+	tipo   uint16
+	clase  uint16
+}
+
+type registro struct {
+	nombre  string
+	tipo    uint16
+	clase   uint16
+	ttl     uint32
+	destino string // PTR y SRV
+	puerto  uint16 // SRV
+	ip      net.IP // A
+	texto   []string
+}
+
+type mensaje struct {
+	id          uint16
+	flags       uint16
+	preguntas   []pregunta
+	respuestas  []registro
+	adicionales []registro
+}
+
+func (m *mensaje) codificar() []byte {
+	b := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(b[0:], m.id)
+	binary.BigEndian.PutUint16(b[2:], m.flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.preguntas)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.respuestas)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(m.adicionales)))
+
+	for _, p := range m.preguntas {
+		b = escribirNombre(b, p.nombre)
+		b = binary.BigEndian.AppendUint16(b, p.tipo)
+		b = binary.BigEndian.AppendUint16(b, p.clase)
+	}
+	for _, r := range append(m.respuestas, m.adicionales...) {
+		b = escribirNombre(b, r.nombre)
+		b = binary.BigEndian.AppendUint16(b, r.tipo)
+		b = binary.BigEndian.AppendUint16(b, r.clase)
+		b = binary.BigEndian.AppendUint32(b, r.ttl)
+
+		var datos []byte
+		switch r.tipo {
+		case tipoPTR:
+			datos = escribirNombre(nil, r.destino)
+		case tipoSRV:
+			datos = make([]byte, 6) // prioridad y peso en cero
+			binary.BigEndian.PutUint16(datos[4:], r.puerto)
+			datos = escribirNombre(datos, r.destino)
+		case tipoA:
+			datos = r.ip.To4()
+		case tipoTXT:
+			for _, t := range r.texto {
+				datos = append(datos, byte(len(t)))
+				datos = append(datos, t...)
+			}
+		}
+		b = binary.BigEndian.AppendUint16(b, uint16(len(datos)))
+		b = append(b, datos...)
+	}
+	return b
+}
+
+func escribirNombre(b []byte, nombre string) []byte {
+	for _, etiqueta := range etiquetas(nombre) {
+		b = append(b, byte(len(etiqueta)))
+		b = append(b, etiqueta...)
+	}
+	return append(b, 0)
+}
+
+// etiquetas separa un nombre en sus etiquetas. La primera etiqueta de una
+// instancia DNS-SD puede contener puntos escapados como "\.".
+func etiquetas(nombre string) []string {
+	var resultado []string
+	var actual strings.Builder
+	for i := 0; i < len(nombre); i++ {
+		switch {
+		case nombre[i] == '\\' && i+1 < len(nombre):
+			i++
+			actual.WriteByte(nombre[i])
+		case nombre[i] == '.':
+			resultado = append(resultado, actual.String())
+			actual.Reset()
+		default:
+			actual.WriteByte(nombre[i])
+		}
+	}
+	if actual.Len() > 0 {
+		resultado = append(resultado, actual.String())
+	}
+	return resultado
+}
+
+var errMensajeCorto = errors.New("mensaje DNS truncado")
+
+func decodificarMensaje(b []byte) (*mensaje, error) {
+	if len(b) < 12 {
+		return nil, errMensajeCorto
+	}
+	m := &mensaje{
+		id:    binary.BigEndian.Uint16(b[0:]),
+		flags: binary.BigEndian.Uint16(b[2:]),
+	}
+	cantPreguntas := int(binary.BigEndian.Uint16(b[4:]))
+	cantRegistros := int(binary.BigEndian.Uint16(b[6:])) + int(binary.BigEndian.Uint16(b[8:])) + int(binary.BigEndian.Uint16(b[10:]))
+
+	off := 12
+	for i := 0; i < cantPreguntas; i++ {
+		nombre, n, err := leerNombre(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if off+4 > len(b) {
+			return nil, errMensajeCorto
+		}
+		m.preguntas = append(m.preguntas, pregunta{
+			nombre: nombre,
+			tipo:   binary.BigEndian.Uint16(b[off:]),
+			clase:  binary.BigEndian.Uint16(b[off+2:]),
+		})
+		off += 4
+	}
+
+	for i := 0; i < cantRegistros; i++ {
+		nombre, n, err := leerNombre(b, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if off+10 > len(b) {
+			return nil, errMensajeCorto
+		}
+		r := registro{
+			nombre: nombre,
+			tipo:   binary.BigEndian.Uint16(b[off:]),
+			clase:  binary.BigEndian.Uint16(b[off+2:]),
+			ttl:    binary.BigEndian.Uint32(b[off+4:]),
+		}
+		largo := int(binary.BigEndian.Uint16(b[off+8:]))
+		off += 10
+		if off+largo > len(b) {
+			return nil, errMensajeCorto
+		}
+		datos := b[off : off+largo]
+
+		switch r.tipo {
+		case tipoPTR:
+			if r.destino, _, err = leerNombre(b, off); err != nil {
+				return nil, err
+			}
+		case tipoSRV:
+			if largo < 7 {
+				return nil, errMensajeCorto
+			}
+			r.puerto = binary.BigEndian.Uint16(datos[4:])
+			if r.destino, _, err = leerNombre(b, off+6); err != nil {
+				return nil, err
+			}
+		case tipoA:
+			if largo == 4 {
+				r.ip = net.IP(append([]byte(nil), datos...))
+			}
+		case tipoTXT:
+			for j := 0; j < len(datos); {
+				l := int(datos[j])
+				if j+1+l > len(datos) {
+					break
+				}
+				r.texto = append(r.texto, string(datos[j+1:j+1+l]))
+				j += 1 + l
+			}
+		}
+		m.respuestas = append(m.respuestas, r)
+		off += largo
+	}
+
+	return m, nil
+}
+
+// leerNombre decodifica el nombre en off siguiendo punteros de compresión y
+// devuelve la posición siguiente al nombre. Los puntos dentro de una
+// etiqueta se escapan para que el nombre pueda volver a codificarse.
+func leerNombre(b []byte, off int) (string, int, error) {
+	var partes []string
+	siguiente := -1
+	for saltos := 0; ; {
+		if off >= len(b) {
+			return "", 0, errMensajeCorto
+		}
+		l := int(b[off])
+		switch {
+		case l == 0:
+			if siguiente < 0 {
+				siguiente = off + 1
+			}
+			return strings.Join(partes, ".") + ".", siguiente, nil
+		case l&0xC0 == 0xC0:
+			if off+1 >= len(b) {
+				return "", 0, errMensajeCorto
+			}
+			if siguiente < 0 {
+				siguiente = off + 2
+			}
+			saltos++
+			if saltos > 16 {
+				return "", 0, errors.New("nombre DNS con punteros en bucle")
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3FFF)
+		default:
+			if off+1+l > len(b) {
+				return "", 0, errMensajeCorto
+			}
+			partes = append(partes, strings.ReplaceAll(string(b[off+1:off+1+l]), ".", `\.`))
+			off += 1 + l
+		}
+	}
+}
+
+func mismoNombre(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// Anunciante responde las consultas mDNS por el servicio de yoyaku mientras
+// el puesto actúa como anfitrión.
+type Anunciante struct {
+	conn      *net.UDPConn
+	instancia string
+	equipo    string
+	puerto    int
+}
+
+// Anunciar publica este puesto con el nombre visible indicado y el puerto
+// del servidor local.
+func Anunciar(nombre string, puerto int) (*Anunciante, error) {
+	conn, err := net.ListenMulticastUDP("udp4", nil, grupoMDNS)
+	if err != nil {
+		return nil, fmt.Errorf("error escuchando mDNS: %w", err)
+	}
+
+	a := &Anunciante{
+		conn:      conn,
+		instancia: nombreInstancia(nombre),
+		equipo:    nombreEquipo(),
+		puerto:    puerto,
+	}
+
+	// Anuncio inicial para que los puestos que ya están buscando lo vean
+	// sin esperar a su próxima consulta.
+	if _, err := conn.WriteToUDP(a.respuesta(nil).codificar(), grupoMDNS); err != nil {
+		log.Printf("error anunciando por mDNS: %v", err)
+	}
+
+	go a.atender()
+	return a, nil
+}
+
+// Cerrar deja de responder consultas.
+func (a *Anunciante) Cerrar() error {
+	return a.conn.Close()
+}
+
+func (a *Anunciante) atender() {
+	buf := make([]byte, 9000)
+	for {
+		n, origen, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		m, err := decodificarMensaje(buf[:n])
+		if err != nil || m.flags&0x8000 != 0 || !a.consultaServicio(m) {
+			continue
+		}
+
+		// Las consultas desde un puerto distinto de 5353 son "legacy
+		// unicast" y se responden directamente al origen.
+		if origen.Port != grupoMDNS.Port {
+			a.conn.WriteToUDP(a.respuesta(m).codificar(), origen)
+			continue
+		}
+		destino := grupoMDNS
+		for _, p := range m.preguntas {
+			if p.clase&bitUnicast != 0 {
+				destino = origen
+			}
+		}
+		a.conn.WriteToUDP(a.respuesta(nil).codificar(), destino)
+	}
+}
+
+func (a *Anunciante) consultaServicio(m *mensaje) bool {
+	for _, p := range m.preguntas {
+		if mismoNombre(p.nombre, servicioMDNS) && (p.tipo == tipoPTR || p.tipo == tipoANY) {
+			return true
+		}
+	}
+	return false
+}
+
+// respuesta arma los registros PTR, SRV, TXT y A del servicio. Si consulta
+// no es nil se responde en formato legacy unicast, repitiendo el id y la
+// pregunta y sin bits de cache-flush.
+func (a *Anunciante) respuesta(consulta *mensaje) *mensaje {
+	flush := uint16(bitUnicast)
+	m := &mensaje{flags: flagRespuesta}
+	if consulta != nil {
+		flush = 0
+		m.id = consulta.id
+		m.preguntas = consulta.preguntas
+	}
+
+	m.respuestas = []registro{{
+		nombre: servicioMDNS, tipo: tipoPTR, clase: claseIN, ttl: ttlMDNS, destino: a.instancia,
+	}}
+	m.adicionales = []registro{
+		{nombre: a.instancia, tipo: tipoSRV, clase: claseIN | flush, ttl: ttlMDNS, destino: a.equipo, puerto: uint16(a.puerto)},
+		{nombre: a.instancia, tipo: tipoTXT, clase: claseIN | flush, ttl: ttlMDNS, texto: []string{"v=1"}},
+	}
+	for _, ip := range server.DireccionesLocales() {
+		m.adicionales = append(m.adicionales, registro{
+			nombre: a.equipo, tipo: tipoA, clase: claseIN | flush, ttl: ttlMDNS, ip: net.ParseIP(ip),
+		})
+	}
+	return m
+}
+
+// nombreInstancia arma el nombre DNS-SD de la instancia, escapando los
+// puntos y recortando la etiqueta a los 63 bytes que permite DNS.
+func nombreInstancia(nombre string) string {
+	nombre = strings.TrimSpace(nombre)
+	if nombre == "" {
+		nombre = "yoyaku"
+	}
+	for len(nombre) > 63 {
+		r := []rune(nombre)
+		nombre = string(r[:len(r)-1])
+	}
+	return strings.ReplaceAll(nombre, ".", `\.`) + "." + servicioMDNS
+}
+
+func nombreEquipo() string {
+	equipo, err := os.Hostname()
+	if err != nil || equipo == "" {
+		equipo = "yoyaku"
+	}
+	equipo, _, _ = strings.Cut(equipo, ".")
+	return equipo + ".local."
+}
+
+// Descubrir consulta por mDNS los puestos anfitriones de la red local y
+// espera respuestas durante el tiempo indicado.
+func Descubrir(espera time.Duration) ([]models.AnfitrionDescubierto, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo socket mDNS: %w", err)
+	}
+	defer conn.Close()
+
+	consulta := &mensaje{preguntas: []pregunta{{nombre: servicioMDNS, tipo: tipoPTR, clase: claseIN | bitUnicast}}}
+	if _, err := conn.WriteToUDP(consulta.codificar(), grupoMDNS); err != nil {
+		return nil, fmt.Errorf("error enviando consulta mDNS: %w", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(espera))
+
+	encontrados := make(map[string]models.AnfitrionDescubierto)
+	buf := make([]byte, 9000)
+	for {
+		n, origen, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		m, err := decodificarMensaje(buf[:n])
+		if err != nil || m.flags&0x8000 == 0 {
+			continue
+		}
+		for _, anfitrion := range anfitrionesEn(m, origen.IP) {
+			encontrados[anfitrion.Direccion] = anfitrion
+		}
+	}
+
+	resultado := make([]models.AnfitrionDescubierto, 0, len(encontrados))
+	for _, anfitrion := range encontrados {
+		resultado = append(resultado, anfitrion)
+	}
+	sort.Slice(resultado, func(i, j int) bool {
+		return resultado[i].Nombre < resultado[j].Nombre
+	})
+	return resultado, nil
+}
+
+// anfitrionesEn extrae los anfitriones anunciados en una respuesta. Se usa
+// la IP de origen del paquete, que es alcanzable desde este puesto, en lugar
+// de los registros A, que pueden incluir interfaces de otras redes.
+func anfitrionesEn(m *mensaje, ip net.IP) []models.AnfitrionDescubierto {
+	var anfitriones []models.AnfitrionDescubierto
+	for _, ptr := range m.respuestas {
+		if ptr.tipo != tipoPTR || !mismoNombre(ptr.nombre, servicioMDNS) {
+			continue
+		}
+		for _, srv := range m.respuestas {
+			if srv.tipo != tipoSRV || !mismoNombre(srv.nombre, ptr.destino) {
+				continue
+			}
+			etiqueta := etiquetas(ptr.destino)
+			anfitriones = append(anfitriones, models.AnfitrionDescubierto{
+				Nombre:    etiqueta[0],
+				Direccion: net.JoinHostPort(ip.String(), strconv.Itoa(int(srv.puerto))),
+			})
+		}
+	}
+	return anfitriones
+}
@@ -0,0 +1,63 @@
+package multipuesto
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMensajeMDNS(t *testing.T) {
+	a := &Anunciante{
+		instancia: nombreInstancia("Consultorio Dr. García"),
+		equipo:    "recepcion.local.",
+		puerto:    8737,
+	}
+
+	consulta := &mensaje{id: 42, preguntas: []pregunta{{nombre: servicioMDNS, tipo: tipoPTR, clase: claseIN | bitUnicast}}}
+	decodificada, err := decodificarMensaje(consulta.codificar())
+	if err != nil {
+		t.Fatalf("decodificarMensaje(consulta) failed: %v", err)
+	}
+	if !a.consultaServicio(decodificada) {
+		t.Fatal("consultaServicio() = false, want true")
+	}
+
+	respuesta, err := decodificarMensaje(a.respuesta(decodificada).codificar())
+	if err != nil {
+		t.Fatalf("decodificarMensaje(respuesta) failed: %v", err)
+	}
+	if respuesta.id != 42 || len(respuesta.preguntas) != 1 {
+		t.Errorf("respuesta legacy unicast id = %d, preguntas = %d; want 42, 1", respuesta.id, len(respuesta.preguntas))
+	}
+
+	anfitriones := anfitrionesEn(respuesta, net.IPv4(192, 168, 0, 10))
+	if len(anfitriones) != 1 {
+		t.Fatalf("anfitrionesEn() = %v, want 1", anfitriones)
+	}
+	if anfitriones[0].Nombre != "Consultorio Dr. García" {
+		t.Errorf("Nombre = %q, want %q", anfitriones[0].Nombre, "Consultorio Dr. García")
+	}
+	if anfitriones[0].Direccion != "192.168.0.10:8737" {
+		t.Errorf("Direccion = %q, want 192.168.0.10:8737", anfitriones[0].Direccion)
+	}
+}
+
+func TestLeerNombreComprimido(t *testing.T) {
+	// "local." en el offset 12 y "_tcp" + puntero a "local." a continuación.
+	b := make([]byte, 12)
+	b = append(b, 5, 'l', 'o', 'c', 'a', 'l', 0)
+	inicio := len(b)
+	b = append(b, 4, '_', 't', 'c', 'p', 0xC0, 12)
+
+	nombre, siguiente, err := leerNombre(b, inicio)
+	if err != nil {
+		t.Fatalf("leerNombre() failed: %v", err)
+	}
+	if nombre != "_tcp.local." || siguiente != len(b) {
+		t.Errorf("leerNombre() = %q, %d; want _tcp.local., %d", nombre, siguiente, len(b))
+	}
+
+	bucle := append(make([]byte, 12), 0xC0, 12)
+	if _, _, err := leerNombre(bucle, 12); err == nil {
+		t.Error("leerNombre() should fail on pointer loops")
+	}
+}
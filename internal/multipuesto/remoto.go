@@ -0,0 +1,326 @@
+package multipuesto
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"yoyaku/internal/agenda"
+	"yoyaku/internal/models"
+)
+
+// Remoto implementa Backend contra un puesto anfitrión de la red local.
+type Remoto struct {
+	base    string
+	clave   string
+	cliente *http.Client
+}
+
+// NewRemoto crea un Backend que usa el anfitrión en direccion, como
+// "192.168.0.10:8737" o "http://recepcion.local:8737".
+func NewRemoto(direccion, clave string) *Remoto {
+	base := strings.TrimRight(direccion, "/")
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	return &Remoto{
+		base:    base + Prefijo,
+		clave:   clave,
+		cliente: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Probar verifica que el anfitrión responda y acepte la clave.
+func (r *Remoto) Probar() error {
+	_, err := r.ObtenerConfiguracion()
+	return err
+}
+
+func (r *Remoto) llamar(op string, args, resultado interface{}) error {
+	cuerpo, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.base+"/"+op, bytes.NewReader(cuerpo))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.clave)
+
+	resp, err := r.cliente.Do(req)
+	if err != nil {
+		return fmt.Errorf("no se pudo contactar al puesto anfitrión: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			return fmt.Errorf("el puesto anfitrión respondió %s", resp.Status)
+		}
+		return errors.New(e.Error)
+	}
+
+	if resultado == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(resultado); err != nil {
+		return fmt.Errorf("respuesta inválida del puesto anfitrión: %w", err)
+	}
+	return nil
+}
+
+// Escuchar recibe los eventos del anfitrión y llama a fn por cada uno hasta
+// que se cancele ctx. Si la conexión se corta reintenta con espera creciente,
+// y al reconectar emite EventoReconectado para que se recarguen los datos que
+// pudieron cambiar mientras tanto.
+func (r *Remoto) Escuchar(ctx context.Context, fn func(agenda.Evento)) {
+	espera := time.Second
+	primera := true
+	for {
+		err := r.escucharUnaVez(ctx, func(e agenda.Evento) {
+			espera = time.Second
+			fn(e)
+		}, func() {
+			if !primera {
+				fn(agenda.Evento{Tipo: EventoReconectado})
+			}
+			primera = false
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("eventos del puesto anfitrión interrumpidos: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(espera):
+		}
+		espera = min(espera*2, 30*time.Second)
+	}
+}
+
+func (r *Remoto) escucharUnaVez(ctx context.Context, fn func(agenda.Evento), conectado func()) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/eventos", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.clave)
+	req.Header.Set("Accept", "text/event-stream")
+
+	// Sin timeout: la conexión queda abierta mientras dure la sesión.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("el puesto anfitrión respondió %s", resp.Status)
+	}
+	conectado()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		datos, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e agenda.Evento
+		if err := json.Unmarshal([]byte(datos), &e); err != nil {
+			continue
+		}
+		fn(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("conexión cerrada por el anfitrión")
+}
+
+func (r *Remoto) AgendaDelDia(fecha time.Time) (*models.AgendaDia, error) {
+	var agenda *models.AgendaDia
+	if err := r.llamar("agenda", argsFecha{Fecha: fecha}, &agenda); err != nil {
+		return nil, err
+	}
+	return agenda, nil
+}
+
+func (r *Remoto) SalaDeEspera(fecha time.Time) ([]models.Turno, error) {
+	var turnos []models.Turno
+	if err := r.llamar("sala_espera", argsFecha{Fecha: fecha}, &turnos); err != nil {
+		return nil, err
+	}
+	return turnos, nil
+}
+
+func (r *Remoto) ObtenerTurno(id int64) (*models.Turno, error) {
+	var turno *models.Turno
+	if err := r.llamar("turno.obtener", argsID{ID: id}, &turno); err != nil {
+		return nil, err
+	}
+	return turno, nil
+}
+
+func (r *Remoto) CrearTurno(turno *models.Turno) error {
+	return r.llamar("turno.crear", turno, turno)
+}
+
+func (r *Remoto) ActualizarTurno(turno *models.Turno) error {
+	return r.llamar("turno.actualizar", turno, turno)
+}
+
+func (r *Remoto) EliminarTurno(id int64) error {
+	return r.llamar("turno.eliminar", argsID{ID: id}, nil)
+}
+
+func (r *Remoto) CambiarEstadoTurno(id int64, estado models.EstadoTurno) error {
+	return r.llamar("turno.estado", argsEstado{ID: id, Estado: estado}, nil)
+}
+
+func (r *Remoto) HistorialPaciente(pacienteID int64) ([]models.Turno, error) {
+	var turnos []models.Turno
+	if err := r.llamar("paciente.historial", argsID{ID: pacienteID}, &turnos); err != nil {
+		return nil, err
+	}
+	return turnos, nil
+}
+
+func (r *Remoto) ObtenerPaciente(id int64) (*models.Paciente, error) {
+	var paciente *models.Paciente
+	if err := r.llamar("paciente.obtener", argsID{ID: id}, &paciente); err != nil {
+		return nil, err
+	}
+	return paciente, nil
+}
+
+func (r *Remoto) BuscarPacientes(termino string, pagina, porPagina int) (*models.ResultadoBusquedaPacientes, error) {
+	var resultado *models.ResultadoBusquedaPacientes
+	if err := r.llamar("paciente.buscar", argsBusqueda{Termino: termino, Pagina: pagina, PorPagina: porPagina}, &resultado); err != nil {
+		return nil, err
+	}
+	return resultado, nil
+}
+
+func (r *Remoto) CrearPaciente(paciente *models.Paciente) error {
+	return r.llamar("paciente.crear", paciente, paciente)
+}
+
+func (r *Remoto) ActualizarPaciente(paciente *models.Paciente) error {
+	return r.llamar("paciente.actualizar", paciente, paciente)
+}
+
+func (r *Remoto) EliminarPaciente(id int64) error {
+	return r.llamar("paciente.eliminar", argsID{ID: id}, nil)
+}
+
+func (r *Remoto) ObtenerConfiguracion() (*models.Configuracion, error) {
+	var config *models.Configuracion
+	if err := r.llamar("configuracion.obtener", struct{}{}, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (r *Remoto) GuardarConfiguracion(config *models.Configuracion) error {
+	return r.llamar("configuracion.guardar", config, nil)
+}
+
+func (r *Remoto) ListarProfesionales() ([]models.Profesional, error) {
+	var profesionales []models.Profesional
+	if err := r.llamar("profesional.listar", struct{}{}, &profesionales); err != nil {
+		return nil, err
+	}
+	return profesionales, nil
+}
+
+func (r *Remoto) CrearProfesional(profesional *models.Profesional) error {
+	return r.llamar("profesional.crear", profesional, profesional)
+}
+
+func (r *Remoto) ActualizarProfesional(profesional *models.Profesional) error {
+	return r.llamar("profesional.actualizar", profesional, profesional)
+}
+
+func (r *Remoto) DiasCerrados(profesionalID int64, desde, hasta time.Time) ([]models.DiaCerrado, error) {
+	var dias []models.DiaCerrado
+	if err := r.llamar("dias_cerrados", argsRango{ProfesionalID: profesionalID, Desde: desde, Hasta: hasta}, &dias); err != nil {
+		return nil, err
+	}
+	return dias, nil
+}
+
+func (r *Remoto) ListarCierres(desde, hasta time.Time) ([]models.Cierre, error) {
+	var cierres []models.Cierre
+	if err := r.llamar("cierre.listar", argsRango{Desde: desde, Hasta: hasta}, &cierres); err != nil {
+		return nil, err
+	}
+	return cierres, nil
+}
+
+func (r *Remoto) CrearCierre(cierre *models.Cierre) (*models.ReporteCierre, error) {
+	var reporte *models.ReporteCierre
+	if err := r.llamar("cierre.crear", cierre, &reporte); err != nil {
+		return nil, err
+	}
+	if reporte != nil && reporte.Cierre != nil {
+		*cierre = *reporte.Cierre
+	}
+	return reporte, nil
+}
+
+func (r *Remoto) EliminarCierre(id int64) error {
+	return r.llamar("cierre.eliminar", argsID{ID: id}, nil)
+}
+
+func (r *Remoto) TurnosAfectados(cierreID int64) ([]models.Turno, error) {
+	var turnos []models.Turno
+	if err := r.llamar("cierre.afectados", argsID{ID: cierreID}, &turnos); err != nil {
+		return nil, err
+	}
+	return turnos, nil
+}
+
+func (r *Remoto) ObtenerHorarioLaboral() (*models.HorarioLaboral, error) {
+	var horario *models.HorarioLaboral
+	if err := r.llamar("horario.obtener", struct{}{}, &horario); err != nil {
+		return nil, err
+	}
+	return horario, nil
+}
+
+func (r *Remoto) GuardarHorarioLaboral(horario *models.HorarioLaboral) error {
+	return r.llamar("horario.guardar", horario, nil)
+}
+
+func (r *Remoto) ReprogramarTurnos(solicitud models.SolicitudReprogramacion) (*models.PlanReprogramacion, error) {
+	var plan *models.PlanReprogramacion
+	if err := r.llamar("turno.reprogramar", solicitud, &plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (r *Remoto) ListarMensajes(estado models.EstadoMensaje) ([]models.Mensaje, error) {
+	var mensajes []models.Mensaje
+	if err := r.llamar("mensaje.listar", argsMensajes{Estado: estado}, &mensajes); err != nil {
+		return nil, err
+	}
+	return mensajes, nil
+}
+
+func (r *Remoto) CambiarEstadoMensaje(id int64, estado models.EstadoMensaje) error {
+	return r.llamar("mensaje.estado", argsMensajes{ID: id, Estado: estado}, nil)
+}
@@ -0,0 +1,135 @@
+package multipuesto
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"yoyaku/internal/agenda"
+	"yoyaku/internal/db"
+	"yoyaku/internal/models"
+)
+
+func setupAnfitrion(t *testing.T) (*Anfitrion, *httptest.Server) {
+	t.Helper()
+
+	database, err := db.NewDB(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	agendaSvc := agenda.NewService(db.NewTurnoRepo(database), db.NewPacienteRepo(database), db.NewCierreRepo(database), db.NewConfigRepo(database))
+	anfitrion := NewAnfitrion(NewLocal(database, agendaSvc))
+	anfitrion.Configurar(true, "clave-de-prueba")
+
+	mux := http.NewServeMux()
+	anfitrion.Registrar(mux.Handle)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return anfitrion, srv
+}
+
+func TestRemoto(t *testing.T) {
+	_, srv := setupAnfitrion(t)
+	remoto := NewRemoto(strings.TrimPrefix(srv.URL, "http://"), "clave-de-prueba")
+
+	if err := remoto.Probar(); err != nil {
+		t.Fatalf("Probar() failed: %v", err)
+	}
+
+	paciente := &models.Paciente{Nombre: "María González", Telefono: "11 1234-5678"}
+	if err := remoto.CrearPaciente(paciente); err != nil {
+		t.Fatalf("CrearPaciente() failed: %v", err)
+	}
+	if paciente.ID == 0 {
+		t.Error("CrearPaciente() should set the ID returned by the host")
+	}
+
+	fecha := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	turno := &models.Turno{PacienteID: paciente.ID, Fecha: fecha, Hora: models.NuevaHora(10, 0), Duracion: 30, Estado: models.EstadoPendiente}
+	if err := remoto.CrearTurno(turno); err != nil {
+		t.Fatalf("CrearTurno() failed: %v", err)
+	}
+
+	if err := remoto.CambiarEstadoTurno(turno.ID, models.EstadoConfirmado); err != nil {
+		t.Fatalf("CambiarEstadoTurno() failed: %v", err)
+	}
+
+	agendaDia, err := remoto.AgendaDelDia(fecha)
+	if err != nil {
+		t.Fatalf("AgendaDelDia() failed: %v", err)
+	}
+	if agendaDia.TotalTurnos != 1 || agendaDia.Turnos[0].Estado != models.EstadoConfirmado {
+		t.Errorf("AgendaDelDia() = %+v, want 1 turno confirmado", agendaDia)
+	}
+
+	resultado, err := remoto.BuscarPacientes("gonzalez", 1, 0)
+	if err != nil {
+		t.Fatalf("BuscarPacientes() failed: %v", err)
+	}
+	if resultado.Total != 1 {
+		t.Errorf("BuscarPacientes() total = %d, want 1", resultado.Total)
+	}
+
+	inexistente, err := remoto.ObtenerTurno(999)
+	if err != nil {
+		t.Fatalf("ObtenerTurno() failed: %v", err)
+	}
+	if inexistente != nil {
+		t.Errorf("ObtenerTurno(999) = %+v, want nil", inexistente)
+	}
+}
+
+func TestRemotoClaveIncorrecta(t *testing.T) {
+	anfitrion, srv := setupAnfitrion(t)
+
+	err := NewRemoto(srv.URL, "otra").Probar()
+	if err == nil || !strings.Contains(err.Error(), "clave") {
+		t.Errorf("Probar() error = %v, want clave incorrecta", err)
+	}
+
+	anfitrion.Configurar(false, "clave-de-prueba")
+	if err := NewRemoto(srv.URL, "clave-de-prueba").Probar(); err == nil {
+		t.Error("Probar() should fail when the host is disabled")
+	}
+}
+
+func TestRemotoEscuchar(t *testing.T) {
+	_, srv := setupAnfitrion(t)
+	remoto := NewRemoto(srv.URL, "clave-de-prueba")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventos := make(chan agenda.Evento, 10)
+	go remoto.Escuchar(ctx, func(e agenda.Evento) { eventos <- e })
+
+	// El stream puede no estar suscripto todavía: se reintenta la escritura
+	// hasta recibir el evento.
+	paciente := &models.Paciente{Nombre: "Juan Pérez"}
+	limite := time.After(5 * time.Second)
+	for {
+		if err := NewRemoto(srv.URL, "clave-de-prueba").CrearPaciente(paciente); err != nil {
+			t.Fatalf("CrearPaciente() failed: %v", err)
+		}
+		select {
+		case e := <-eventos:
+			if e.Tipo != agenda.EventoPacienteCreado || e.PacienteID != paciente.ID {
+				t.Errorf("evento = %+v, want paciente creado %d", e, paciente.ID)
+			}
+			if e.Paciente == nil || e.Paciente.Nombre != "Juan Pérez" {
+				t.Errorf("evento.Paciente = %+v, want Juan Pérez", e.Paciente)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-limite:
+			t.Fatal("no se recibió el evento")
+		}
+		paciente.ID = 0
+	}
+}
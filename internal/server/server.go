@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Server es el servidor HTTP embebido que publica servicios de yoyaku en la
+// red local. Los paquetes que lo usan registran sus rutas con Handle antes de
+// iniciarlo.
+type Server struct {
+	mux *http.ServeMux
+
+	mu     sync.Mutex
+	http   *http.Server
+	puerto int
+}
+
+func New() *Server {
+	return &Server{mux: http.NewServeMux()}
+}
+
+// Handle registra un handler con la sintaxis de patrones de http.ServeMux.
+func (s *Server) Handle(patron string, handler http.Handler) {
+	s.mux.Handle(patron, handler)
+}
+
+// Iniciar escucha en todas las interfaces en el puerto indicado y atiende
+// las conexiones en segundo plano.
+func (s *Server) Iniciar(puerto int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.http != nil {
+		return fmt.Errorf("el servidor ya está iniciado")
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(puerto)))
+	if err != nil {
+		return fmt.Errorf("error escuchando en el puerto %d: %w", puerto, err)
+	}
+
+	s.http = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.puerto = ln.Addr().(*net.TCPAddr).Port
+
+	srv := s.http
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("error en servidor HTTP: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Detener cierra el servidor esperando a que terminen las peticiones en
+// curso hasta que venza el contexto.
+func (s *Server) Detener(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.http
+	s.http = nil
+	s.mu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// Iniciado indica si el servidor está escuchando.
+func (s *Server) Iniciado() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.http != nil
+}
+
+// Puerto devuelve el puerto en el que escucha el servidor, o 0 si está
+// detenido.
+func (s *Server) Puerto() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.http == nil {
+		return 0
+	}
+	return s.puerto
+}
+
+// URLs devuelve la URL de ruta para cada dirección IPv4 de la máquina en la
+// red local, para mostrarlas al usuario.
+func (s *Server) URLs(ruta string) []string {
+	s.mu.Lock()
+	puerto := s.puerto
+	s.mu.Unlock()
+
+	if puerto == 0 {
+		return nil
+	}
+
+	var urls []string
+	for _, ip := range DireccionesLocales() {
+		urls = append(urls, fmt.Sprintf("http://%s%s", net.JoinHostPort(ip, strconv.Itoa(puerto)), ruta))
+	}
+	return urls
+}
+
+// DireccionesLocales devuelve las direcciones IPv4 de las interfaces activas
+// que no son loopback.
+func DireccionesLocales() []string {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var ips []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+			ips = append(ips, ipNet.IP.String())
+		}
+	}
+	return ips
+}
@@ -0,0 +1,74 @@
+package telefono
+
+import "strings"
+
+// digitosComparables es la cantidad de dígitos finales que se usan para
+// detectar teléfonos parecidos: el número local, sin el característico de
+// área ni prefijos como +54, 9 o 0.
+const digitosComparables = 8
+
+// Digitos devuelve sólo los dígitos del teléfono.
+func Digitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Normalizar deja el teléfono en un formato uniforme: sólo dígitos,
+// conservando el "+" inicial si el número lo tenía.
+func Normalizar(s string) string {
+	s = strings.TrimSpace(s)
+	digitos := Digitos(s)
+	if digitos == "" {
+		return ""
+	}
+	if strings.HasPrefix(s, "+") {
+		return "+" + digitos
+	}
+	return digitos
+}
+
+// Iguales indica si dos teléfonos son el mismo número aunque estén cargados
+// con distinto formato o con y sin los prefijos +54, 9 o 0.
+func Iguales(a, b string) bool {
+	da, db := Digitos(a), Digitos(b)
+	if da == "" || db == "" {
+		return false
+	}
+	return nacional(da) == nacional(db)
+}
+
+// Parecidos indica si dos teléfonos terminan en los mismos
+// digitosComparables dígitos. Pueden ser el mismo número con un prefijo que
+// Iguales no reconoce o dos números distintos, así que sirve para avisar de
+// un posible duplicado, no para darlo por hecho.
+func Parecidos(a, b string) bool {
+	da, db := Digitos(a), Digitos(b)
+	if da == "" || db == "" {
+		return false
+	}
+	return sufijo(da) == sufijo(db)
+}
+
+// nacional quita el código de país, el 9 de los celulares y el 0 de larga
+// distancia, dejando el número nacional de 10 dígitos.
+func nacional(digitos string) string {
+	if len(digitos) > 10 && strings.HasPrefix(digitos, "54") {
+		digitos = digitos[2:]
+	}
+	if len(digitos) > 10 && strings.HasPrefix(digitos, "9") {
+		digitos = digitos[1:]
+	}
+	return strings.TrimPrefix(digitos, "0")
+}
+
+func sufijo(digitos string) string {
+	if len(digitos) <= digitosComparables {
+		return digitos
+	}
+	return digitos[len(digitos)-digitosComparables:]
+}
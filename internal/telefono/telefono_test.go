@@ -0,0 +1,50 @@
+package telefono
+
+import "testing"
+
+func TestNormalizar(t *testing.T) {
+	tests := []struct {
+		entrada string
+		want    string
+	}{
+		{"11 4433-2211", "1144332211"},
+		{"(011) 4433.2211", "01144332211"},
+		{"+54 9 11 2345-6789", "+5491123456789"},
+		{"  +54 11 2345 6789 ", "+541123456789"},
+		{"11 2345 6789 +", "1123456789"},
+		{"sin número", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalizar(tt.entrada); got != tt.want {
+			t.Errorf("Normalizar(%q) = %q, want %q", tt.entrada, got, tt.want)
+		}
+	}
+}
+
+func TestComparar(t *testing.T) {
+	tests := []struct {
+		name          string
+		a, b          string
+		wantIguales   bool
+		wantParecidos bool
+	}{
+		{"mismo formato", "1144332211", "11 4433-2211", true, true},
+		{"con 0", "(011) 4433.2211", "11 4433 2211", true, true},
+		{"con +54 9", "+54 9 11 2345-6789", "1123456789", true, true},
+		{"con 54 sin +", "541123456789", "01123456789", true, true},
+		{"otra área, mismos 8 dígitos", "11 4433-2211", "351 4433-2211", false, true},
+		{"distintos", "1144332211", "1155550000", false, false},
+		{"vacío", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Iguales(tt.a, tt.b); got != tt.wantIguales {
+				t.Errorf("Iguales(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.wantIguales)
+			}
+			if got := Parecidos(tt.a, tt.b); got != tt.wantParecidos {
+				t.Errorf("Parecidos(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.wantParecidos)
+			}
+		})
+	}
+}
@@ -57,7 +57,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[0].ID,
 			Fecha:      fechaHoy,
-			Hora:       "09:00",
+			Hora:       models.NuevaHora(9, 0),
 			Duracion:   30,
 			Motivo:     "Consulta general",
 			Estado:     models.EstadoAtendido,
@@ -65,7 +65,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[1].ID,
 			Fecha:      fechaHoy,
-			Hora:       "09:30",
+			Hora:       models.NuevaHora(9, 30),
 			Duracion:   30,
 			Motivo:     "Control de presión",
 			Estado:     models.EstadoAtendido,
@@ -73,7 +73,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[2].ID,
 			Fecha:      fechaHoy,
-			Hora:       "10:00",
+			Hora:       models.NuevaHora(10, 0),
 			Duracion:   30,
 			Motivo:     "Dolor de cabeza",
 			Estado:     models.EstadoConfirmado,
@@ -81,7 +81,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[3].ID,
 			Fecha:      fechaHoy,
-			Hora:       "10:30",
+			Hora:       models.NuevaHora(10, 30),
 			Duracion:   30,
 			Motivo:     "Chequeo anual",
 			Estado:     models.EstadoConfirmado,
@@ -89,7 +89,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[4].ID,
 			Fecha:      fechaHoy,
-			Hora:       "11:00",
+			Hora:       models.NuevaHora(11, 0),
 			Duracion:   30,
 			Motivo:     "Receta médica",
 			Estado:     models.EstadoPendiente,
@@ -97,7 +97,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[5].ID,
 			Fecha:      fechaHoy,
-			Hora:       "11:30",
+			Hora:       models.NuevaHora(11, 30),
 			Duracion:   30,
 			Motivo:     "Seguimiento",
 			Estado:     models.EstadoPendiente,
@@ -105,7 +105,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[0].ID,
 			Fecha:      fechaHoy,
-			Hora:       "12:00",
+			Hora:       models.NuevaHora(12, 0),
 			Duracion:   30,
 			Motivo:     "Resultados de laboratorio",
 			Estado:     models.EstadoConfirmado,
@@ -113,7 +113,7 @@ func (s *SeedService) SeedData(ctx context.Context) error {
 		{
 			PacienteID: pacientesCreados[2].ID,
 			Fecha:      fechaHoy,
-			Hora:       "12:30",
+			Hora:       models.NuevaHora(12, 30),
 			Duracion:   30,
 			Motivo:     "Consulta de seguimiento",
 			Estado:     models.EstadoPendiente,